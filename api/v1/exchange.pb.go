@@ -0,0 +1,756 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.31.1
+// source: api/v1/exchange.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type BlockStatus int32
+
+const (
+	BlockStatus_BLOCK_STATUS_OK             BlockStatus = 0
+	BlockStatus_BLOCK_STATUS_NOT_FOUND      BlockStatus = 1
+	BlockStatus_BLOCK_STATUS_RATE_LIMITED   BlockStatus = 2
+	BlockStatus_BLOCK_STATUS_BAD_REQUEST    BlockStatus = 3
+	BlockStatus_BLOCK_STATUS_INTERNAL_ERROR BlockStatus = 4
+)
+
+// Enum value maps for BlockStatus.
+var (
+	BlockStatus_name = map[int32]string{
+		0: "BLOCK_STATUS_OK",
+		1: "BLOCK_STATUS_NOT_FOUND",
+		2: "BLOCK_STATUS_RATE_LIMITED",
+		3: "BLOCK_STATUS_BAD_REQUEST",
+		4: "BLOCK_STATUS_INTERNAL_ERROR",
+	}
+	BlockStatus_value = map[string]int32{
+		"BLOCK_STATUS_OK":             0,
+		"BLOCK_STATUS_NOT_FOUND":      1,
+		"BLOCK_STATUS_RATE_LIMITED":   2,
+		"BLOCK_STATUS_BAD_REQUEST":    3,
+		"BLOCK_STATUS_INTERNAL_ERROR": 4,
+	}
+)
+
+func (x BlockStatus) Enum() *BlockStatus {
+	p := new(BlockStatus)
+	*p = x
+	return p
+}
+
+func (x BlockStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BlockStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_v1_exchange_proto_enumTypes[0].Descriptor()
+}
+
+func (BlockStatus) Type() protoreflect.EnumType {
+	return &file_api_v1_exchange_proto_enumTypes[0]
+}
+
+func (x BlockStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BlockStatus.Descriptor instead.
+func (BlockStatus) EnumDescriptor() ([]byte, []int) {
+	return file_api_v1_exchange_proto_rawDescGZIP(), []int{0}
+}
+
+type WantType int32
+
+const (
+	// Ask whether the peer has the block, without transferring it.
+	WantType_WANT_TYPE_HAVE WantType = 0
+	// Ask the peer to send the block if it has it.
+	WantType_WANT_TYPE_BLOCK WantType = 1
+)
+
+// Enum value maps for WantType.
+var (
+	WantType_name = map[int32]string{
+		0: "WANT_TYPE_HAVE",
+		1: "WANT_TYPE_BLOCK",
+	}
+	WantType_value = map[string]int32{
+		"WANT_TYPE_HAVE":  0,
+		"WANT_TYPE_BLOCK": 1,
+	}
+)
+
+func (x WantType) Enum() *WantType {
+	p := new(WantType)
+	*p = x
+	return p
+}
+
+func (x WantType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (WantType) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_v1_exchange_proto_enumTypes[1].Descriptor()
+}
+
+func (WantType) Type() protoreflect.EnumType {
+	return &file_api_v1_exchange_proto_enumTypes[1]
+}
+
+func (x WantType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use WantType.Descriptor instead.
+func (WantType) EnumDescriptor() ([]byte, []int) {
+	return file_api_v1_exchange_proto_rawDescGZIP(), []int{1}
+}
+
+// BlockRequest asks a peer for blocks over the block exchange protocol.
+// Any number of requests may be sent on one stream; each CID is answered
+// by its own BlockResponse carrying the same request id.
+type BlockRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Cids          []string               `protobuf:"bytes,2,rep,name=cids,proto3" json:"cids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockRequest) Reset() {
+	*x = BlockRequest{}
+	mi := &file_api_v1_exchange_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockRequest) ProtoMessage() {}
+
+func (x *BlockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_exchange_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockRequest.ProtoReflect.Descriptor instead.
+func (*BlockRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_exchange_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *BlockRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *BlockRequest) GetCids() []string {
+	if x != nil {
+		return x.Cids
+	}
+	return nil
+}
+
+type BlockResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	RequestId uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Cid       string                 `protobuf:"bytes,2,opt,name=cid,proto3" json:"cid,omitempty"`
+	Status    BlockStatus            `protobuf:"varint,3,opt,name=status,proto3,enum=storage.v1.BlockStatus" json:"status,omitempty"`
+	Data      []byte                 `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	// Human readable detail for non-OK statuses.
+	Error         string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockResponse) Reset() {
+	*x = BlockResponse{}
+	mi := &file_api_v1_exchange_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockResponse) ProtoMessage() {}
+
+func (x *BlockResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_exchange_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockResponse.ProtoReflect.Descriptor instead.
+func (*BlockResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_exchange_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BlockResponse) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *BlockResponse) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *BlockResponse) GetStatus() BlockStatus {
+	if x != nil {
+		return x.Status
+	}
+	return BlockStatus_BLOCK_STATUS_OK
+}
+
+func (x *BlockResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *BlockResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type WantEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cid           string                 `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	Type          WantType               `protobuf:"varint,2,opt,name=type,proto3,enum=storage.v1.WantType" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WantEntry) Reset() {
+	*x = WantEntry{}
+	mi := &file_api_v1_exchange_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WantEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WantEntry) ProtoMessage() {}
+
+func (x *WantEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_exchange_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WantEntry.ProtoReflect.Descriptor instead.
+func (*WantEntry) Descriptor() ([]byte, []int) {
+	return file_api_v1_exchange_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *WantEntry) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *WantEntry) GetType() WantType {
+	if x != nil {
+		return x.Type
+	}
+	return WantType_WANT_TYPE_HAVE
+}
+
+type BlockPresence struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cid           string                 `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	Have          bool                   `protobuf:"varint,2,opt,name=have,proto3" json:"have,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockPresence) Reset() {
+	*x = BlockPresence{}
+	mi := &file_api_v1_exchange_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockPresence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockPresence) ProtoMessage() {}
+
+func (x *BlockPresence) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_exchange_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockPresence.ProtoReflect.Descriptor instead.
+func (*BlockPresence) Descriptor() ([]byte, []int) {
+	return file_api_v1_exchange_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BlockPresence) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *BlockPresence) GetHave() bool {
+	if x != nil {
+		return x.Have
+	}
+	return false
+}
+
+type BlockData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cid           string                 `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockData) Reset() {
+	*x = BlockData{}
+	mi := &file_api_v1_exchange_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockData) ProtoMessage() {}
+
+func (x *BlockData) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_exchange_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockData.ProtoReflect.Descriptor instead.
+func (*BlockData) Descriptor() ([]byte, []int) {
+	return file_api_v1_exchange_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BlockData) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *BlockData) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// WantMessage is exchanged on the want-list protocol. The requester sends a
+// single message listing its wants and closes its side of the stream. The
+// responder replies with one message answering every entry with a presence
+// (HAVE or DONT_HAVE), followed by one message per requested block it holds.
+type WantMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Wants         []*WantEntry           `protobuf:"bytes,1,rep,name=wants,proto3" json:"wants,omitempty"`
+	Presences     []*BlockPresence       `protobuf:"bytes,2,rep,name=presences,proto3" json:"presences,omitempty"`
+	Block         *BlockData             `protobuf:"bytes,3,opt,name=block,proto3" json:"block,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WantMessage) Reset() {
+	*x = WantMessage{}
+	mi := &file_api_v1_exchange_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WantMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WantMessage) ProtoMessage() {}
+
+func (x *WantMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_exchange_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WantMessage.ProtoReflect.Descriptor instead.
+func (*WantMessage) Descriptor() ([]byte, []int) {
+	return file_api_v1_exchange_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *WantMessage) GetWants() []*WantEntry {
+	if x != nil {
+		return x.Wants
+	}
+	return nil
+}
+
+func (x *WantMessage) GetPresences() []*BlockPresence {
+	if x != nil {
+		return x.Presences
+	}
+	return nil
+}
+
+func (x *WantMessage) GetBlock() *BlockData {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+// ReplicateOffer opens a stream on the replication protocol. The sender
+// lists every block of a file, root first, and asks the receiver to keep a
+// pinned copy.
+type ReplicateOffer struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Root  string                 `protobuf:"bytes,1,opt,name=root,proto3" json:"root,omitempty"`
+	Cids  []string               `protobuf:"bytes,2,rep,name=cids,proto3" json:"cids,omitempty"`
+	// Pin root on its own rather than as a file, as is done for the shards
+	// of an erasure-coded file.
+	Direct        bool `protobuf:"varint,3,opt,name=direct,proto3" json:"direct,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicateOffer) Reset() {
+	*x = ReplicateOffer{}
+	mi := &file_api_v1_exchange_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicateOffer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicateOffer) ProtoMessage() {}
+
+func (x *ReplicateOffer) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_exchange_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicateOffer.ProtoReflect.Descriptor instead.
+func (*ReplicateOffer) Descriptor() ([]byte, []int) {
+	return file_api_v1_exchange_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ReplicateOffer) GetRoot() string {
+	if x != nil {
+		return x.Root
+	}
+	return ""
+}
+
+func (x *ReplicateOffer) GetCids() []string {
+	if x != nil {
+		return x.Cids
+	}
+	return nil
+}
+
+func (x *ReplicateOffer) GetDirect() bool {
+	if x != nil {
+		return x.Direct
+	}
+	return false
+}
+
+// ReplicateWants answers an offer with the blocks the receiver does not
+// have yet. The sender then writes one BlockData message for each of them.
+// A non-empty error means the receiver declined the offer.
+type ReplicateWants struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Missing       []string               `protobuf:"bytes,1,rep,name=missing,proto3" json:"missing,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicateWants) Reset() {
+	*x = ReplicateWants{}
+	mi := &file_api_v1_exchange_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicateWants) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicateWants) ProtoMessage() {}
+
+func (x *ReplicateWants) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_exchange_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicateWants.ProtoReflect.Descriptor instead.
+func (*ReplicateWants) Descriptor() ([]byte, []int) {
+	return file_api_v1_exchange_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ReplicateWants) GetMissing() []string {
+	if x != nil {
+		return x.Missing
+	}
+	return nil
+}
+
+func (x *ReplicateWants) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// ReplicateResult ends the exchange once the receiver has stored and pinned
+// the file. An empty error means success.
+type ReplicateResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Error         string                 `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicateResult) Reset() {
+	*x = ReplicateResult{}
+	mi := &file_api_v1_exchange_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicateResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicateResult) ProtoMessage() {}
+
+func (x *ReplicateResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_exchange_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicateResult.ProtoReflect.Descriptor instead.
+func (*ReplicateResult) Descriptor() ([]byte, []int) {
+	return file_api_v1_exchange_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReplicateResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_api_v1_exchange_proto protoreflect.FileDescriptor
+
+const file_api_v1_exchange_proto_rawDesc = "" +
+	"\n" +
+	"\x15api/v1/exchange.proto\x12\n" +
+	"storage.v1\"2\n" +
+	"\fBlockRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04cids\x18\x02 \x03(\tR\x04cids\"\x9b\x01\n" +
+	"\rBlockResponse\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x10\n" +
+	"\x03cid\x18\x02 \x01(\tR\x03cid\x12/\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x17.storage.v1.BlockStatusR\x06status\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\fR\x04data\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\"G\n" +
+	"\tWantEntry\x12\x10\n" +
+	"\x03cid\x18\x01 \x01(\tR\x03cid\x12(\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x14.storage.v1.WantTypeR\x04type\"5\n" +
+	"\rBlockPresence\x12\x10\n" +
+	"\x03cid\x18\x01 \x01(\tR\x03cid\x12\x12\n" +
+	"\x04have\x18\x02 \x01(\bR\x04have\"1\n" +
+	"\tBlockData\x12\x10\n" +
+	"\x03cid\x18\x01 \x01(\tR\x03cid\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"\xa0\x01\n" +
+	"\vWantMessage\x12+\n" +
+	"\x05wants\x18\x01 \x03(\v2\x15.storage.v1.WantEntryR\x05wants\x127\n" +
+	"\tpresences\x18\x02 \x03(\v2\x19.storage.v1.BlockPresenceR\tpresences\x12+\n" +
+	"\x05block\x18\x03 \x01(\v2\x15.storage.v1.BlockDataR\x05block\"P\n" +
+	"\x0eReplicateOffer\x12\x12\n" +
+	"\x04root\x18\x01 \x01(\tR\x04root\x12\x12\n" +
+	"\x04cids\x18\x02 \x03(\tR\x04cids\x12\x16\n" +
+	"\x06direct\x18\x03 \x01(\bR\x06direct\"@\n" +
+	"\x0eReplicateWants\x12\x18\n" +
+	"\amissing\x18\x01 \x03(\tR\amissing\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"'\n" +
+	"\x0fReplicateResult\x12\x14\n" +
+	"\x05error\x18\x01 \x01(\tR\x05error*\x9c\x01\n" +
+	"\vBlockStatus\x12\x13\n" +
+	"\x0fBLOCK_STATUS_OK\x10\x00\x12\x1a\n" +
+	"\x16BLOCK_STATUS_NOT_FOUND\x10\x01\x12\x1d\n" +
+	"\x19BLOCK_STATUS_RATE_LIMITED\x10\x02\x12\x1c\n" +
+	"\x18BLOCK_STATUS_BAD_REQUEST\x10\x03\x12\x1f\n" +
+	"\x1bBLOCK_STATUS_INTERNAL_ERROR\x10\x04*3\n" +
+	"\bWantType\x12\x12\n" +
+	"\x0eWANT_TYPE_HAVE\x10\x00\x12\x13\n" +
+	"\x0fWANT_TYPE_BLOCK\x10\x01B'Z%github.com/Yashh56/p2p-storage/api/v1b\x06proto3"
+
+var (
+	file_api_v1_exchange_proto_rawDescOnce sync.Once
+	file_api_v1_exchange_proto_rawDescData []byte
+)
+
+func file_api_v1_exchange_proto_rawDescGZIP() []byte {
+	file_api_v1_exchange_proto_rawDescOnce.Do(func() {
+		file_api_v1_exchange_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_v1_exchange_proto_rawDesc), len(file_api_v1_exchange_proto_rawDesc)))
+	})
+	return file_api_v1_exchange_proto_rawDescData
+}
+
+var file_api_v1_exchange_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_api_v1_exchange_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_api_v1_exchange_proto_goTypes = []any{
+	(BlockStatus)(0),        // 0: storage.v1.BlockStatus
+	(WantType)(0),           // 1: storage.v1.WantType
+	(*BlockRequest)(nil),    // 2: storage.v1.BlockRequest
+	(*BlockResponse)(nil),   // 3: storage.v1.BlockResponse
+	(*WantEntry)(nil),       // 4: storage.v1.WantEntry
+	(*BlockPresence)(nil),   // 5: storage.v1.BlockPresence
+	(*BlockData)(nil),       // 6: storage.v1.BlockData
+	(*WantMessage)(nil),     // 7: storage.v1.WantMessage
+	(*ReplicateOffer)(nil),  // 8: storage.v1.ReplicateOffer
+	(*ReplicateWants)(nil),  // 9: storage.v1.ReplicateWants
+	(*ReplicateResult)(nil), // 10: storage.v1.ReplicateResult
+}
+var file_api_v1_exchange_proto_depIdxs = []int32{
+	0, // 0: storage.v1.BlockResponse.status:type_name -> storage.v1.BlockStatus
+	1, // 1: storage.v1.WantEntry.type:type_name -> storage.v1.WantType
+	4, // 2: storage.v1.WantMessage.wants:type_name -> storage.v1.WantEntry
+	5, // 3: storage.v1.WantMessage.presences:type_name -> storage.v1.BlockPresence
+	6, // 4: storage.v1.WantMessage.block:type_name -> storage.v1.BlockData
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_api_v1_exchange_proto_init() }
+func file_api_v1_exchange_proto_init() {
+	if File_api_v1_exchange_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_v1_exchange_proto_rawDesc), len(file_api_v1_exchange_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_api_v1_exchange_proto_goTypes,
+		DependencyIndexes: file_api_v1_exchange_proto_depIdxs,
+		EnumInfos:         file_api_v1_exchange_proto_enumTypes,
+		MessageInfos:      file_api_v1_exchange_proto_msgTypes,
+	}.Build()
+	File_api_v1_exchange_proto = out.File
+	file_api_v1_exchange_proto_goTypes = nil
+	file_api_v1_exchange_proto_depIdxs = nil
+}
@@ -21,6 +21,57 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type PinType int32
+
+const (
+	PinType_PIN_TYPE_UNSPECIFIED PinType = 0
+	// Protects a single block.
+	PinType_PIN_TYPE_DIRECT PinType = 1
+	// Protects a root manifest and every block it references.
+	PinType_PIN_TYPE_RECURSIVE PinType = 2
+)
+
+// Enum value maps for PinType.
+var (
+	PinType_name = map[int32]string{
+		0: "PIN_TYPE_UNSPECIFIED",
+		1: "PIN_TYPE_DIRECT",
+		2: "PIN_TYPE_RECURSIVE",
+	}
+	PinType_value = map[string]int32{
+		"PIN_TYPE_UNSPECIFIED": 0,
+		"PIN_TYPE_DIRECT":      1,
+		"PIN_TYPE_RECURSIVE":   2,
+	}
+)
+
+func (x PinType) Enum() *PinType {
+	p := new(PinType)
+	*p = x
+	return p
+}
+
+func (x PinType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PinType) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_v1_storage_proto_enumTypes[0].Descriptor()
+}
+
+func (PinType) Type() protoreflect.EnumType {
+	return &file_api_v1_storage_proto_enumTypes[0]
+}
+
+func (x PinType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PinType.Descriptor instead.
+func (PinType) EnumDescriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{0}
+}
+
 type Block struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
@@ -66,8 +117,33 @@ func (x *Block) GetData() []byte {
 }
 
 type AddFileRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ChunkData     []byte                 `protobuf:"bytes,1,opt,name=chunk_data,json=chunkData,proto3" json:"chunk_data,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ChunkData []byte                 `protobuf:"bytes,1,opt,name=chunk_data,json=chunkData,proto3" json:"chunk_data,omitempty"`
+	// Chunker spec used to split the file, e.g. "fixed-1048576" or
+	// "cdc-262144-1048576-4194304". Only read from the first message.
+	Chunker string `protobuf:"bytes,2,opt,name=chunker,proto3" json:"chunker,omitempty"`
+	// Number of nodes, including this one, that should keep a pinned copy
+	// of the file. Zero or one keeps it on this node only. Only read from
+	// the first message.
+	Replication uint32 `protobuf:"varint,3,opt,name=replication,proto3" json:"replication,omitempty"`
+	// Erasure-code every stripe of data_shards chunks with parity_shards
+	// parity blocks. Both zero stores the file without parity. Only read
+	// from the first message.
+	DataShards   uint32 `protobuf:"varint,4,opt,name=data_shards,json=dataShards,proto3" json:"data_shards,omitempty"`
+	ParityShards uint32 `protobuf:"varint,5,opt,name=parity_shards,json=parityShards,proto3" json:"parity_shards,omitempty"`
+	// Encrypt the file before it is stored: "file" for a random per-file
+	// key, "convergent" for keys derived from each chunk's content so that
+	// identical chunks are stored once. Empty stores the file unencrypted.
+	// Only read from the first message.
+	Encryption string `protobuf:"bytes,6,opt,name=encryption,proto3" json:"encryption,omitempty"`
+	// Cipher used when encrypting: "aes-256-gcm" (default) or
+	// "xchacha20-poly1305". Only read from the first message.
+	Cipher string `protobuf:"bytes,7,opt,name=cipher,proto3" json:"cipher,omitempty"`
+	// Original file name, without directories, and MIME type recorded in
+	// the manifest. The content type is guessed from the name or the
+	// content when empty. Only read from the first message.
+	Name          string `protobuf:"bytes,8,opt,name=name,proto3" json:"name,omitempty"`
+	ContentType   string `protobuf:"bytes,9,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -109,9 +185,67 @@ func (x *AddFileRequest) GetChunkData() []byte {
 	return nil
 }
 
+func (x *AddFileRequest) GetChunker() string {
+	if x != nil {
+		return x.Chunker
+	}
+	return ""
+}
+
+func (x *AddFileRequest) GetReplication() uint32 {
+	if x != nil {
+		return x.Replication
+	}
+	return 0
+}
+
+func (x *AddFileRequest) GetDataShards() uint32 {
+	if x != nil {
+		return x.DataShards
+	}
+	return 0
+}
+
+func (x *AddFileRequest) GetParityShards() uint32 {
+	if x != nil {
+		return x.ParityShards
+	}
+	return 0
+}
+
+func (x *AddFileRequest) GetEncryption() string {
+	if x != nil {
+		return x.Encryption
+	}
+	return ""
+}
+
+func (x *AddFileRequest) GetCipher() string {
+	if x != nil {
+		return x.Cipher
+	}
+	return ""
+}
+
+func (x *AddFileRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AddFileRequest) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
 type AddFileResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	RootCid       string                 `protobuf:"bytes,1,opt,name=root_cid,json=rootCid,proto3" json:"root_cid,omitempty"`
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	RootCid string                 `protobuf:"bytes,1,opt,name=root_cid,json=rootCid,proto3" json:"root_cid,omitempty"`
+	// Key needed to read an encrypted file. The node does not keep it.
+	Key           string `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -153,9 +287,22 @@ func (x *AddFileResponse) GetRootCid() string {
 	return ""
 }
 
+func (x *AddFileResponse) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
 type GetFileRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Cid           string                 `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Cid   string                 `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	// Key returned when an encrypted file was added.
+	Key string `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	// Byte range to read. Only the chunks covering it are fetched. A zero
+	// length reads to the end of the file.
+	Offset        uint64 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	Length        uint64 `protobuf:"varint,4,opt,name=length,proto3" json:"length,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -197,9 +344,32 @@ func (x *GetFileRequest) GetCid() string {
 	return ""
 }
 
+func (x *GetFileRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *GetFileRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *GetFileRequest) GetLength() uint64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
 type GetFileResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ChunkData     []byte                 `protobuf:"bytes,1,opt,name=chunk_data,json=chunkData,proto3" json:"chunk_data,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ChunkData []byte                 `protobuf:"bytes,1,opt,name=chunk_data,json=chunkData,proto3" json:"chunk_data,omitempty"`
+	// Only set in the first message.
+	Info          *FileInfo `protobuf:"bytes,2,opt,name=info,proto3" json:"info,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -241,27 +411,48 @@ func (x *GetFileResponse) GetChunkData() []byte {
 	return nil
 }
 
-type Manifest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	BlockCids     []string               `protobuf:"bytes,1,rep,name=block_cids,json=blockCids,proto3" json:"block_cids,omitempty"`
+func (x *GetFileResponse) GetInfo() *FileInfo {
+	if x != nil {
+		return x.Info
+	}
+	return nil
+}
+
+// FileInfo is the metadata recorded in a file's manifest.
+type FileInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Cid   string                 `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	// Version of the manifest schema. Version 0 manifests record neither
+	// sizes nor any of the fields below chunks.
+	ManifestVersion uint32 `protobuf:"varint,2,opt,name=manifest_version,json=manifestVersion,proto3" json:"manifest_version,omitempty"`
+	// Size in bytes, or -1 when the manifest does not record it.
+	Size        int64  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	Chunks      uint32 `protobuf:"varint,4,opt,name=chunks,proto3" json:"chunks,omitempty"`
+	Encrypted   bool   `protobuf:"varint,5,opt,name=encrypted,proto3" json:"encrypted,omitempty"`
+	Directory   bool   `protobuf:"varint,10,opt,name=directory,proto3" json:"directory,omitempty"`
+	Name        string `protobuf:"bytes,6,opt,name=name,proto3" json:"name,omitempty"`
+	ContentType string `protobuf:"bytes,7,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	// Unix time in seconds.
+	CreatedAt     int64  `protobuf:"varint,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Chunker       string `protobuf:"bytes,9,opt,name=chunker,proto3" json:"chunker,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Manifest) Reset() {
-	*x = Manifest{}
+func (x *FileInfo) Reset() {
+	*x = FileInfo{}
 	mi := &file_api_v1_storage_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Manifest) String() string {
+func (x *FileInfo) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Manifest) ProtoMessage() {}
+func (*FileInfo) ProtoMessage() {}
 
-func (x *Manifest) ProtoReflect() protoreflect.Message {
+func (x *FileInfo) ProtoReflect() protoreflect.Message {
 	mi := &file_api_v1_storage_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -273,42 +464,3138 @@ func (x *Manifest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Manifest.ProtoReflect.Descriptor instead.
-func (*Manifest) Descriptor() ([]byte, []int) {
+// Deprecated: Use FileInfo.ProtoReflect.Descriptor instead.
+func (*FileInfo) Descriptor() ([]byte, []int) {
 	return file_api_v1_storage_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *Manifest) GetBlockCids() []string {
+func (x *FileInfo) GetCid() string {
 	if x != nil {
-		return x.BlockCids
+		return x.Cid
 	}
-	return nil
+	return ""
 }
 
-var File_api_v1_storage_proto protoreflect.FileDescriptor
+func (x *FileInfo) GetManifestVersion() uint32 {
+	if x != nil {
+		return x.ManifestVersion
+	}
+	return 0
+}
 
-const file_api_v1_storage_proto_rawDesc = "" +
-	"\n" +
-	"\x14api/v1/storage.proto\x12\n" +
-	"storage.v1\"\x1b\n" +
-	"\x05Block\x12\x12\n" +
-	"\x04data\x18\x01 \x01(\fR\x04data\"/\n" +
-	"\x0eAddFileRequest\x12\x1d\n" +
-	"\n" +
-	"chunk_data\x18\x01 \x01(\fR\tchunkData\",\n" +
-	"\x0fAddFileResponse\x12\x19\n" +
-	"\broot_cid\x18\x01 \x01(\tR\arootCid\"\"\n" +
-	"\x0eGetFileRequest\x12\x10\n" +
-	"\x03cid\x18\x01 \x01(\tR\x03cid\"0\n" +
-	"\x0fGetFileResponse\x12\x1d\n" +
-	"\n" +
-	"chunk_data\x18\x01 \x01(\fR\tchunkData\")\n" +
-	"\bManifest\x12\x1d\n" +
-	"\n" +
-	"block_cids\x18\x01 \x03(\tR\tblockCids2\x9c\x01\n" +
-	"\x0eStorageService\x12D\n" +
-	"\aAddFile\x12\x1a.storage.v1.AddFileRequest\x1a\x1b.storage.v1.AddFileResponse(\x01\x12D\n" +
-	"\aGetFile\x12\x1a.storage.v1.GetFileRequest\x1a\x1b.storage.v1.GetFileResponse0\x01B'Z%github.com/Yashh56/p2p-storage/api/v1b\x06proto3"
+func (x *FileInfo) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *FileInfo) GetChunks() uint32 {
+	if x != nil {
+		return x.Chunks
+	}
+	return 0
+}
+
+func (x *FileInfo) GetEncrypted() bool {
+	if x != nil {
+		return x.Encrypted
+	}
+	return false
+}
+
+func (x *FileInfo) GetDirectory() bool {
+	if x != nil {
+		return x.Directory
+	}
+	return false
+}
+
+func (x *FileInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FileInfo) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *FileInfo) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *FileInfo) GetChunker() string {
+	if x != nil {
+		return x.Chunker
+	}
+	return ""
+}
+
+type PinRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Cid   string                 `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	// Defaults to PIN_TYPE_RECURSIVE.
+	Type          PinType `protobuf:"varint,2,opt,name=type,proto3,enum=storage.v1.PinType" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PinRequest) Reset() {
+	*x = PinRequest{}
+	mi := &file_api_v1_storage_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PinRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PinRequest) ProtoMessage() {}
+
+func (x *PinRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PinRequest.ProtoReflect.Descriptor instead.
+func (*PinRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PinRequest) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *PinRequest) GetType() PinType {
+	if x != nil {
+		return x.Type
+	}
+	return PinType_PIN_TYPE_UNSPECIFIED
+}
+
+type PinResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PinResponse) Reset() {
+	*x = PinResponse{}
+	mi := &file_api_v1_storage_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PinResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PinResponse) ProtoMessage() {}
+
+func (x *PinResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PinResponse.ProtoReflect.Descriptor instead.
+func (*PinResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{7}
+}
+
+type UnpinRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cid           string                 `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnpinRequest) Reset() {
+	*x = UnpinRequest{}
+	mi := &file_api_v1_storage_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnpinRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnpinRequest) ProtoMessage() {}
+
+func (x *UnpinRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnpinRequest.ProtoReflect.Descriptor instead.
+func (*UnpinRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UnpinRequest) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+type UnpinResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnpinResponse) Reset() {
+	*x = UnpinResponse{}
+	mi := &file_api_v1_storage_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnpinResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnpinResponse) ProtoMessage() {}
+
+func (x *UnpinResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnpinResponse.ProtoReflect.Descriptor instead.
+func (*UnpinResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{9}
+}
+
+type ListPinsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPinsRequest) Reset() {
+	*x = ListPinsRequest{}
+	mi := &file_api_v1_storage_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPinsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPinsRequest) ProtoMessage() {}
+
+func (x *ListPinsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPinsRequest.ProtoReflect.Descriptor instead.
+func (*ListPinsRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{10}
+}
+
+type PinInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cid           string                 `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	Type          PinType                `protobuf:"varint,2,opt,name=type,proto3,enum=storage.v1.PinType" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PinInfo) Reset() {
+	*x = PinInfo{}
+	mi := &file_api_v1_storage_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PinInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PinInfo) ProtoMessage() {}
+
+func (x *PinInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PinInfo.ProtoReflect.Descriptor instead.
+func (*PinInfo) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PinInfo) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *PinInfo) GetType() PinType {
+	if x != nil {
+		return x.Type
+	}
+	return PinType_PIN_TYPE_UNSPECIFIED
+}
+
+type ListPinsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pins          []*PinInfo             `protobuf:"bytes,1,rep,name=pins,proto3" json:"pins,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPinsResponse) Reset() {
+	*x = ListPinsResponse{}
+	mi := &file_api_v1_storage_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPinsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPinsResponse) ProtoMessage() {}
+
+func (x *ListPinsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPinsResponse.ProtoReflect.Descriptor instead.
+func (*ListPinsResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListPinsResponse) GetPins() []*PinInfo {
+	if x != nil {
+		return x.Pins
+	}
+	return nil
+}
+
+type AddDirectoryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Options as in AddFileRequest, applied to every file. Only read from
+	// the first message.
+	Chunker     string `protobuf:"bytes,1,opt,name=chunker,proto3" json:"chunker,omitempty"`
+	Replication uint32 `protobuf:"varint,2,opt,name=replication,proto3" json:"replication,omitempty"`
+	// Name recorded for the root directory. Only read from the first
+	// message.
+	Name string `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	// Starts the next entry of the tree. A directory must come before the
+	// entries in it. The content of a file follows in chunk_data, starting
+	// with the message that carries its entry.
+	Entry         *TreeEntry `protobuf:"bytes,4,opt,name=entry,proto3" json:"entry,omitempty"`
+	ChunkData     []byte     `protobuf:"bytes,5,opt,name=chunk_data,json=chunkData,proto3" json:"chunk_data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddDirectoryRequest) Reset() {
+	*x = AddDirectoryRequest{}
+	mi := &file_api_v1_storage_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddDirectoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddDirectoryRequest) ProtoMessage() {}
+
+func (x *AddDirectoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddDirectoryRequest.ProtoReflect.Descriptor instead.
+func (*AddDirectoryRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *AddDirectoryRequest) GetChunker() string {
+	if x != nil {
+		return x.Chunker
+	}
+	return ""
+}
+
+func (x *AddDirectoryRequest) GetReplication() uint32 {
+	if x != nil {
+		return x.Replication
+	}
+	return 0
+}
+
+func (x *AddDirectoryRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AddDirectoryRequest) GetEntry() *TreeEntry {
+	if x != nil {
+		return x.Entry
+	}
+	return nil
+}
+
+func (x *AddDirectoryRequest) GetChunkData() []byte {
+	if x != nil {
+		return x.ChunkData
+	}
+	return nil
+}
+
+type TreeEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Path relative to the root of the tree, with "/" as separator.
+	Path      string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Directory bool   `protobuf:"varint,2,opt,name=directory,proto3" json:"directory,omitempty"`
+	// Unix permission bits.
+	Mode uint32 `protobuf:"varint,3,opt,name=mode,proto3" json:"mode,omitempty"`
+	// Modification time, Unix seconds.
+	Mtime         int64 `protobuf:"varint,4,opt,name=mtime,proto3" json:"mtime,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TreeEntry) Reset() {
+	*x = TreeEntry{}
+	mi := &file_api_v1_storage_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TreeEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TreeEntry) ProtoMessage() {}
+
+func (x *TreeEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TreeEntry.ProtoReflect.Descriptor instead.
+func (*TreeEntry) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *TreeEntry) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *TreeEntry) GetDirectory() bool {
+	if x != nil {
+		return x.Directory
+	}
+	return false
+}
+
+func (x *TreeEntry) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *TreeEntry) GetMtime() int64 {
+	if x != nil {
+		return x.Mtime
+	}
+	return 0
+}
+
+type AddDirectoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RootCid       string                 `protobuf:"bytes,1,opt,name=root_cid,json=rootCid,proto3" json:"root_cid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddDirectoryResponse) Reset() {
+	*x = AddDirectoryResponse{}
+	mi := &file_api_v1_storage_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddDirectoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddDirectoryResponse) ProtoMessage() {}
+
+func (x *AddDirectoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddDirectoryResponse.ProtoReflect.Descriptor instead.
+func (*AddDirectoryResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *AddDirectoryResponse) GetRootCid() string {
+	if x != nil {
+		return x.RootCid
+	}
+	return ""
+}
+
+type ListDirectoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cid           string                 `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDirectoryRequest) Reset() {
+	*x = ListDirectoryRequest{}
+	mi := &file_api_v1_storage_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDirectoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDirectoryRequest) ProtoMessage() {}
+
+func (x *ListDirectoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDirectoryRequest.ProtoReflect.Descriptor instead.
+func (*ListDirectoryRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListDirectoryRequest) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+type ListDirectoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Info          *FileInfo              `protobuf:"bytes,1,opt,name=info,proto3" json:"info,omitempty"`
+	Entries       []*DirectoryEntry      `protobuf:"bytes,2,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDirectoryResponse) Reset() {
+	*x = ListDirectoryResponse{}
+	mi := &file_api_v1_storage_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDirectoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDirectoryResponse) ProtoMessage() {}
+
+func (x *ListDirectoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDirectoryResponse.ProtoReflect.Descriptor instead.
+func (*ListDirectoryResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ListDirectoryResponse) GetInfo() *FileInfo {
+	if x != nil {
+		return x.Info
+	}
+	return nil
+}
+
+func (x *ListDirectoryResponse) GetEntries() []*DirectoryEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type StartUploadRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Options as in AddFileRequest.
+	Chunker       string `protobuf:"bytes,1,opt,name=chunker,proto3" json:"chunker,omitempty"`
+	Replication   uint32 `protobuf:"varint,2,opt,name=replication,proto3" json:"replication,omitempty"`
+	DataShards    uint32 `protobuf:"varint,3,opt,name=data_shards,json=dataShards,proto3" json:"data_shards,omitempty"`
+	ParityShards  uint32 `protobuf:"varint,4,opt,name=parity_shards,json=parityShards,proto3" json:"parity_shards,omitempty"`
+	Encryption    string `protobuf:"bytes,5,opt,name=encryption,proto3" json:"encryption,omitempty"`
+	Cipher        string `protobuf:"bytes,6,opt,name=cipher,proto3" json:"cipher,omitempty"`
+	Name          string `protobuf:"bytes,7,opt,name=name,proto3" json:"name,omitempty"`
+	ContentType   string `protobuf:"bytes,8,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartUploadRequest) Reset() {
+	*x = StartUploadRequest{}
+	mi := &file_api_v1_storage_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartUploadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartUploadRequest) ProtoMessage() {}
+
+func (x *StartUploadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartUploadRequest.ProtoReflect.Descriptor instead.
+func (*StartUploadRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *StartUploadRequest) GetChunker() string {
+	if x != nil {
+		return x.Chunker
+	}
+	return ""
+}
+
+func (x *StartUploadRequest) GetReplication() uint32 {
+	if x != nil {
+		return x.Replication
+	}
+	return 0
+}
+
+func (x *StartUploadRequest) GetDataShards() uint32 {
+	if x != nil {
+		return x.DataShards
+	}
+	return 0
+}
+
+func (x *StartUploadRequest) GetParityShards() uint32 {
+	if x != nil {
+		return x.ParityShards
+	}
+	return 0
+}
+
+func (x *StartUploadRequest) GetEncryption() string {
+	if x != nil {
+		return x.Encryption
+	}
+	return ""
+}
+
+func (x *StartUploadRequest) GetCipher() string {
+	if x != nil {
+		return x.Cipher
+	}
+	return ""
+}
+
+func (x *StartUploadRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *StartUploadRequest) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+type StartUploadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UploadId      string                 `protobuf:"bytes,1,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartUploadResponse) Reset() {
+	*x = StartUploadResponse{}
+	mi := &file_api_v1_storage_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartUploadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartUploadResponse) ProtoMessage() {}
+
+func (x *StartUploadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartUploadResponse.ProtoReflect.Descriptor instead.
+func (*StartUploadResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *StartUploadResponse) GetUploadId() string {
+	if x != nil {
+		return x.UploadId
+	}
+	return ""
+}
+
+type UploadRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// upload_id and offset are only read from the first message. offset is
+	// the position in the file of the first byte sent; the content follows
+	// contiguously in chunk_data. Bytes before the committed offset are
+	// skipped, so a client may resend data it is unsure about.
+	UploadId      string `protobuf:"bytes,1,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`
+	Offset        uint64 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	ChunkData     []byte `protobuf:"bytes,3,opt,name=chunk_data,json=chunkData,proto3" json:"chunk_data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadRequest) Reset() {
+	*x = UploadRequest{}
+	mi := &file_api_v1_storage_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadRequest) ProtoMessage() {}
+
+func (x *UploadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadRequest.ProtoReflect.Descriptor instead.
+func (*UploadRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *UploadRequest) GetUploadId() string {
+	if x != nil {
+		return x.UploadId
+	}
+	return ""
+}
+
+func (x *UploadRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *UploadRequest) GetChunkData() []byte {
+	if x != nil {
+		return x.ChunkData
+	}
+	return nil
+}
+
+type UploadResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Number of bytes of the file committed so far.
+	Offset        uint64 `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadResponse) Reset() {
+	*x = UploadResponse{}
+	mi := &file_api_v1_storage_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadResponse) ProtoMessage() {}
+
+func (x *UploadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadResponse.ProtoReflect.Descriptor instead.
+func (*UploadResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *UploadResponse) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type GetUploadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UploadId      string                 `protobuf:"bytes,1,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUploadRequest) Reset() {
+	*x = GetUploadRequest{}
+	mi := &file_api_v1_storage_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUploadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUploadRequest) ProtoMessage() {}
+
+func (x *GetUploadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUploadRequest.ProtoReflect.Descriptor instead.
+func (*GetUploadRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *GetUploadRequest) GetUploadId() string {
+	if x != nil {
+		return x.UploadId
+	}
+	return ""
+}
+
+type GetUploadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Offset        uint64                 `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUploadResponse) Reset() {
+	*x = GetUploadResponse{}
+	mi := &file_api_v1_storage_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUploadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUploadResponse) ProtoMessage() {}
+
+func (x *GetUploadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUploadResponse.ProtoReflect.Descriptor instead.
+func (*GetUploadResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *GetUploadResponse) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type FinishUploadRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	UploadId string                 `protobuf:"bytes,1,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`
+	// Size of the whole file. The upload fails unless exactly this many
+	// bytes were committed.
+	Size          uint64 `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FinishUploadRequest) Reset() {
+	*x = FinishUploadRequest{}
+	mi := &file_api_v1_storage_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FinishUploadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FinishUploadRequest) ProtoMessage() {}
+
+func (x *FinishUploadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FinishUploadRequest.ProtoReflect.Descriptor instead.
+func (*FinishUploadRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *FinishUploadRequest) GetUploadId() string {
+	if x != nil {
+		return x.UploadId
+	}
+	return ""
+}
+
+func (x *FinishUploadRequest) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+type StatFileRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Cid   string                 `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	// Key needed for the metadata of an encrypted file.
+	Key           string `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatFileRequest) Reset() {
+	*x = StatFileRequest{}
+	mi := &file_api_v1_storage_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatFileRequest) ProtoMessage() {}
+
+func (x *StatFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatFileRequest.ProtoReflect.Descriptor instead.
+func (*StatFileRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *StatFileRequest) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *StatFileRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type ListChunksRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Cid   string                 `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	Key   string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	// Byte range whose chunks are listed. A zero length lists to the end.
+	Offset        uint64 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	Length        uint64 `protobuf:"varint,4,opt,name=length,proto3" json:"length,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListChunksRequest) Reset() {
+	*x = ListChunksRequest{}
+	mi := &file_api_v1_storage_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListChunksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListChunksRequest) ProtoMessage() {}
+
+func (x *ListChunksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListChunksRequest.ProtoReflect.Descriptor instead.
+func (*ListChunksRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ListChunksRequest) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *ListChunksRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ListChunksRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListChunksRequest) GetLength() uint64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+type ListChunksResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only set in the first message.
+	Info          *FileInfo    `protobuf:"bytes,1,opt,name=info,proto3" json:"info,omitempty"`
+	Chunks        []*ChunkInfo `protobuf:"bytes,2,rep,name=chunks,proto3" json:"chunks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListChunksResponse) Reset() {
+	*x = ListChunksResponse{}
+	mi := &file_api_v1_storage_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListChunksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListChunksResponse) ProtoMessage() {}
+
+func (x *ListChunksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListChunksResponse.ProtoReflect.Descriptor instead.
+func (*ListChunksResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListChunksResponse) GetInfo() *FileInfo {
+	if x != nil {
+		return x.Info
+	}
+	return nil
+}
+
+func (x *ListChunksResponse) GetChunks() []*ChunkInfo {
+	if x != nil {
+		return x.Chunks
+	}
+	return nil
+}
+
+type PublishNameRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name of the key to publish under: "self" or empty for the node's
+	// identity, otherwise a key created with "key gen".
+	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Cid string `protobuf:"bytes,2,opt,name=cid,proto3" json:"cid,omitempty"`
+	// How long the record stays valid, in seconds. Zero means 48 hours.
+	// The node keeps publishing a fresh record while it runs.
+	Lifetime      int64 `protobuf:"varint,3,opt,name=lifetime,proto3" json:"lifetime,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishNameRequest) Reset() {
+	*x = PublishNameRequest{}
+	mi := &file_api_v1_storage_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishNameRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishNameRequest) ProtoMessage() {}
+
+func (x *PublishNameRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishNameRequest.ProtoReflect.Descriptor instead.
+func (*PublishNameRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *PublishNameRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *PublishNameRequest) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *PublishNameRequest) GetLifetime() int64 {
+	if x != nil {
+		return x.Lifetime
+	}
+	return 0
+}
+
+type ResolveNameRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveNameRequest) Reset() {
+	*x = ResolveNameRequest{}
+	mi := &file_api_v1_storage_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveNameRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveNameRequest) ProtoMessage() {}
+
+func (x *ResolveNameRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveNameRequest.ProtoReflect.Descriptor instead.
+func (*ResolveNameRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ResolveNameRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type NameEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The name, the Peer ID of the key that signed the record.
+	Name     string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Cid      string `protobuf:"bytes,2,opt,name=cid,proto3" json:"cid,omitempty"`
+	Sequence uint64 `protobuf:"varint,3,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Unix time in seconds after which the record is no longer valid.
+	ExpiresAt     int64 `protobuf:"varint,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NameEntry) Reset() {
+	*x = NameEntry{}
+	mi := &file_api_v1_storage_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NameEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NameEntry) ProtoMessage() {}
+
+func (x *NameEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NameEntry.ProtoReflect.Descriptor instead.
+func (*NameEntry) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *NameEntry) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *NameEntry) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *NameEntry) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *NameEntry) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type ChunkInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Position of the chunk in the file. Version 0 manifests record no
+	// sizes, so their listing stops after the first chunk with a size of -1.
+	Offset uint64 `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	Size   int64  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Cid    string `protobuf:"bytes,3,opt,name=cid,proto3" json:"cid,omitempty"`
+	// SHA-256 of the chunk's content, when known.
+	Sha256        []byte `protobuf:"bytes,4,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChunkInfo) Reset() {
+	*x = ChunkInfo{}
+	mi := &file_api_v1_storage_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChunkInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChunkInfo) ProtoMessage() {}
+
+func (x *ChunkInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChunkInfo.ProtoReflect.Descriptor instead.
+func (*ChunkInfo) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ChunkInfo) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ChunkInfo) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ChunkInfo) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *ChunkInfo) GetSha256() []byte {
+	if x != nil {
+		return x.Sha256
+	}
+	return nil
+}
+
+type GCRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GCRequest) Reset() {
+	*x = GCRequest{}
+	mi := &file_api_v1_storage_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GCRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GCRequest) ProtoMessage() {}
+
+func (x *GCRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GCRequest.ProtoReflect.Descriptor instead.
+func (*GCRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{32}
+}
+
+type GCResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RemovedBlocks int64                  `protobuf:"varint,1,opt,name=removed_blocks,json=removedBlocks,proto3" json:"removed_blocks,omitempty"`
+	FreedBytes    int64                  `protobuf:"varint,2,opt,name=freed_bytes,json=freedBytes,proto3" json:"freed_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GCResponse) Reset() {
+	*x = GCResponse{}
+	mi := &file_api_v1_storage_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GCResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GCResponse) ProtoMessage() {}
+
+func (x *GCResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GCResponse.ProtoReflect.Descriptor instead.
+func (*GCResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *GCResponse) GetRemovedBlocks() int64 {
+	if x != nil {
+		return x.RemovedBlocks
+	}
+	return 0
+}
+
+func (x *GCResponse) GetFreedBytes() int64 {
+	if x != nil {
+		return x.FreedBytes
+	}
+	return 0
+}
+
+type Manifest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Chunks of a version 0 or 1 manifest. Version 2 manifests use links.
+	BlockCids []string `protobuf:"bytes,1,rep,name=block_cids,json=blockCids,proto3" json:"block_cids,omitempty"`
+	// Set for erasure-coded files. block_cids are then the data shards.
+	Erasure *ErasureLayout `protobuf:"bytes,2,opt,name=erasure,proto3" json:"erasure,omitempty"`
+	// Set, instead of the other fields, in the root block of an encrypted
+	// file.
+	Encrypted *EncryptedManifest `protobuf:"bytes,3,opt,name=encrypted,proto3" json:"encrypted,omitempty"`
+	// Key of every chunk of a convergently encrypted file, in the order of
+	// block_cids. Only present inside an EncryptedManifest; when empty every
+	// chunk is encrypted with the file key.
+	ChunkKeys [][]byte `protobuf:"bytes,4,rep,name=chunk_keys,json=chunkKeys,proto3" json:"chunk_keys,omitempty"`
+	// Schema version. Manifests written before it was introduced are
+	// version 0 and carry none of the fields below. In an encrypted file
+	// they are only set in the sealed manifest.
+	Version uint32 `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	// Size of the file in bytes.
+	Size uint64 `protobuf:"varint,6,opt,name=size,proto3" json:"size,omitempty"`
+	// Size of every chunk before encryption, in the order of block_cids.
+	// Chunk i starts at the sum of the sizes before it.
+	ChunkSizes  []uint64 `protobuf:"varint,7,rep,packed,name=chunk_sizes,json=chunkSizes,proto3" json:"chunk_sizes,omitempty"`
+	Name        string   `protobuf:"bytes,8,opt,name=name,proto3" json:"name,omitempty"`
+	ContentType string   `protobuf:"bytes,9,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	// Unix time in seconds at which the file was added.
+	CreatedAt int64 `protobuf:"varint,10,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Chunker spec the file was split with, e.g. "fixed-1048576".
+	Chunker string `protobuf:"bytes,11,opt,name=chunker,proto3" json:"chunker,omitempty"`
+	// Top of the file's Merkle DAG, from version 2. Links dag_depth levels
+	// below the root point to the chunks in order; links above them point
+	// to DagNodes. Every chunk is at the same depth. An erasure-coded file
+	// always has depth 0. In an encrypted file the links are kept in the
+	// EncryptedManifest instead.
+	Links    []*DagLink `protobuf:"bytes,12,rep,name=links,proto3" json:"links,omitempty"`
+	DagDepth uint32     `protobuf:"varint,13,opt,name=dag_depth,json=dagDepth,proto3" json:"dag_depth,omitempty"`
+	// Number of chunks, from version 2.
+	Chunks uint64 `protobuf:"varint,14,opt,name=chunks,proto3" json:"chunks,omitempty"`
+	// Set in the root block of a directory, along with version, size (of
+	// all files below it), name and created_at.
+	Directory     *Directory `protobuf:"bytes,15,opt,name=directory,proto3" json:"directory,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Manifest) Reset() {
+	*x = Manifest{}
+	mi := &file_api_v1_storage_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Manifest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Manifest) ProtoMessage() {}
+
+func (x *Manifest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Manifest.ProtoReflect.Descriptor instead.
+func (*Manifest) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *Manifest) GetBlockCids() []string {
+	if x != nil {
+		return x.BlockCids
+	}
+	return nil
+}
+
+func (x *Manifest) GetErasure() *ErasureLayout {
+	if x != nil {
+		return x.Erasure
+	}
+	return nil
+}
+
+func (x *Manifest) GetEncrypted() *EncryptedManifest {
+	if x != nil {
+		return x.Encrypted
+	}
+	return nil
+}
+
+func (x *Manifest) GetChunkKeys() [][]byte {
+	if x != nil {
+		return x.ChunkKeys
+	}
+	return nil
+}
+
+func (x *Manifest) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Manifest) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Manifest) GetChunkSizes() []uint64 {
+	if x != nil {
+		return x.ChunkSizes
+	}
+	return nil
+}
+
+func (x *Manifest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Manifest) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *Manifest) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *Manifest) GetChunker() string {
+	if x != nil {
+		return x.Chunker
+	}
+	return ""
+}
+
+func (x *Manifest) GetLinks() []*DagLink {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+func (x *Manifest) GetDagDepth() uint32 {
+	if x != nil {
+		return x.DagDepth
+	}
+	return 0
+}
+
+func (x *Manifest) GetChunks() uint64 {
+	if x != nil {
+		return x.Chunks
+	}
+	return 0
+}
+
+func (x *Manifest) GetDirectory() *Directory {
+	if x != nil {
+		return x.Directory
+	}
+	return nil
+}
+
+// Directory lists the entries of a directory, sorted by name.
+type Directory struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*DirectoryEntry      `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Directory) Reset() {
+	*x = Directory{}
+	mi := &file_api_v1_storage_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Directory) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Directory) ProtoMessage() {}
+
+func (x *Directory) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Directory.ProtoReflect.Descriptor instead.
+func (*Directory) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *Directory) GetEntries() []*DirectoryEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type DirectoryEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Root block of the file or directory.
+	Cid       string `protobuf:"bytes,2,opt,name=cid,proto3" json:"cid,omitempty"`
+	Directory bool   `protobuf:"varint,3,opt,name=directory,proto3" json:"directory,omitempty"`
+	// Unix permission bits.
+	Mode uint32 `protobuf:"varint,4,opt,name=mode,proto3" json:"mode,omitempty"`
+	// Modification time, Unix seconds.
+	Mtime int64 `protobuf:"varint,5,opt,name=mtime,proto3" json:"mtime,omitempty"`
+	// Size of the file, or of all files below the directory.
+	Size          uint64 `protobuf:"varint,6,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DirectoryEntry) Reset() {
+	*x = DirectoryEntry{}
+	mi := &file_api_v1_storage_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DirectoryEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DirectoryEntry) ProtoMessage() {}
+
+func (x *DirectoryEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DirectoryEntry.ProtoReflect.Descriptor instead.
+func (*DirectoryEntry) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *DirectoryEntry) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DirectoryEntry) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *DirectoryEntry) GetDirectory() bool {
+	if x != nil {
+		return x.Directory
+	}
+	return false
+}
+
+func (x *DirectoryEntry) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *DirectoryEntry) GetMtime() int64 {
+	if x != nil {
+		return x.Mtime
+	}
+	return 0
+}
+
+func (x *DirectoryEntry) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+// DagLink points to a chunk or to a DagNode of a file's Merkle DAG.
+type DagLink struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Cid   string                 `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	// Bytes of file content below the link, before encryption.
+	Size uint64 `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	// Key of a convergently encrypted chunk, sealed with the file key.
+	// Only set on links to chunks.
+	Key           []byte `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DagLink) Reset() {
+	*x = DagLink{}
+	mi := &file_api_v1_storage_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DagLink) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DagLink) ProtoMessage() {}
+
+func (x *DagLink) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DagLink.ProtoReflect.Descriptor instead.
+func (*DagLink) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *DagLink) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *DagLink) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *DagLink) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+// DagNode is an intermediate block of a file's Merkle DAG.
+type DagNode struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Links         []*DagLink             `protobuf:"bytes,1,rep,name=links,proto3" json:"links,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DagNode) Reset() {
+	*x = DagNode{}
+	mi := &file_api_v1_storage_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DagNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DagNode) ProtoMessage() {}
+
+func (x *DagNode) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DagNode.ProtoReflect.Descriptor instead.
+func (*DagNode) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *DagNode) GetLinks() []*DagLink {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+// UploadSession is the state of a resumable upload, kept in the node's
+// metadata. The content received so far is stored as raw part blocks.
+type UploadSession struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Options of the file, as in AddFileRequest.
+	Chunker      string `protobuf:"bytes,1,opt,name=chunker,proto3" json:"chunker,omitempty"`
+	Replication  uint32 `protobuf:"varint,2,opt,name=replication,proto3" json:"replication,omitempty"`
+	DataShards   uint32 `protobuf:"varint,3,opt,name=data_shards,json=dataShards,proto3" json:"data_shards,omitempty"`
+	ParityShards uint32 `protobuf:"varint,4,opt,name=parity_shards,json=parityShards,proto3" json:"parity_shards,omitempty"`
+	Encryption   string `protobuf:"bytes,5,opt,name=encryption,proto3" json:"encryption,omitempty"`
+	Cipher       string `protobuf:"bytes,6,opt,name=cipher,proto3" json:"cipher,omitempty"`
+	Name         string `protobuf:"bytes,7,opt,name=name,proto3" json:"name,omitempty"`
+	ContentType  string `protobuf:"bytes,8,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	// Unix time in seconds at which content was last committed.
+	UpdatedAt int64 `protobuf:"varint,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Bytes committed so far.
+	Size          int64 `protobuf:"varint,11,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadSession) Reset() {
+	*x = UploadSession{}
+	mi := &file_api_v1_storage_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadSession) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadSession) ProtoMessage() {}
+
+func (x *UploadSession) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadSession.ProtoReflect.Descriptor instead.
+func (*UploadSession) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *UploadSession) GetChunker() string {
+	if x != nil {
+		return x.Chunker
+	}
+	return ""
+}
+
+func (x *UploadSession) GetReplication() uint32 {
+	if x != nil {
+		return x.Replication
+	}
+	return 0
+}
+
+func (x *UploadSession) GetDataShards() uint32 {
+	if x != nil {
+		return x.DataShards
+	}
+	return 0
+}
+
+func (x *UploadSession) GetParityShards() uint32 {
+	if x != nil {
+		return x.ParityShards
+	}
+	return 0
+}
+
+func (x *UploadSession) GetEncryption() string {
+	if x != nil {
+		return x.Encryption
+	}
+	return ""
+}
+
+func (x *UploadSession) GetCipher() string {
+	if x != nil {
+		return x.Cipher
+	}
+	return ""
+}
+
+func (x *UploadSession) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UploadSession) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *UploadSession) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *UploadSession) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+// EncryptedManifest is the root block of an encrypted file.
+type EncryptedManifest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Cipher string                 `protobuf:"bytes,1,opt,name=cipher,proto3" json:"cipher,omitempty"`
+	// Every block of a version 1 file, so that nodes without the key can
+	// still pin, replicate and garbage collect it. Version 2 files list
+	// their blocks through links and dag_depth for the same reason.
+	BlockCids []string `protobuf:"bytes,2,rep,name=block_cids,json=blockCids,proto3" json:"block_cids,omitempty"`
+	// The file's Manifest, encrypted with the file key.
+	Sealed []byte `protobuf:"bytes,3,opt,name=sealed,proto3" json:"sealed,omitempty"`
+	// Layout of an erasure-coded file, kept in the clear so that nodes
+	// without the key can spread and rebuild its shards. block_cids then
+	// start with the data shards in order.
+	Erasure       *ErasureLayout `protobuf:"bytes,4,opt,name=erasure,proto3" json:"erasure,omitempty"`
+	Links         []*DagLink     `protobuf:"bytes,5,rep,name=links,proto3" json:"links,omitempty"`
+	DagDepth      uint32         `protobuf:"varint,6,opt,name=dag_depth,json=dagDepth,proto3" json:"dag_depth,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EncryptedManifest) Reset() {
+	*x = EncryptedManifest{}
+	mi := &file_api_v1_storage_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EncryptedManifest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EncryptedManifest) ProtoMessage() {}
+
+func (x *EncryptedManifest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EncryptedManifest.ProtoReflect.Descriptor instead.
+func (*EncryptedManifest) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *EncryptedManifest) GetCipher() string {
+	if x != nil {
+		return x.Cipher
+	}
+	return ""
+}
+
+func (x *EncryptedManifest) GetBlockCids() []string {
+	if x != nil {
+		return x.BlockCids
+	}
+	return nil
+}
+
+func (x *EncryptedManifest) GetSealed() []byte {
+	if x != nil {
+		return x.Sealed
+	}
+	return nil
+}
+
+func (x *EncryptedManifest) GetErasure() *ErasureLayout {
+	if x != nil {
+		return x.Erasure
+	}
+	return nil
+}
+
+func (x *EncryptedManifest) GetLinks() []*DagLink {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+func (x *EncryptedManifest) GetDagDepth() uint32 {
+	if x != nil {
+		return x.DagDepth
+	}
+	return 0
+}
+
+// ErasureLayout describes how a file's chunks were erasure-coded. Chunks
+// are grouped into stripes of data_shards consecutive chunks; a short last
+// stripe is completed with all-zero shards, which are not stored. Every
+// shard of a stripe is zero-padded to the stripe's shard_size before
+// coding.
+type ErasureLayout struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	DataShards   uint32                 `protobuf:"varint,1,opt,name=data_shards,json=dataShards,proto3" json:"data_shards,omitempty"`
+	ParityShards uint32                 `protobuf:"varint,2,opt,name=parity_shards,json=parityShards,proto3" json:"parity_shards,omitempty"`
+	// Size of every chunk, used to strip the padding from reconstructed shards.
+	ChunkSizes    []uint64         `protobuf:"varint,3,rep,packed,name=chunk_sizes,json=chunkSizes,proto3" json:"chunk_sizes,omitempty"`
+	Stripes       []*ErasureStripe `protobuf:"bytes,4,rep,name=stripes,proto3" json:"stripes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ErasureLayout) Reset() {
+	*x = ErasureLayout{}
+	mi := &file_api_v1_storage_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ErasureLayout) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErasureLayout) ProtoMessage() {}
+
+func (x *ErasureLayout) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErasureLayout.ProtoReflect.Descriptor instead.
+func (*ErasureLayout) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ErasureLayout) GetDataShards() uint32 {
+	if x != nil {
+		return x.DataShards
+	}
+	return 0
+}
+
+func (x *ErasureLayout) GetParityShards() uint32 {
+	if x != nil {
+		return x.ParityShards
+	}
+	return 0
+}
+
+func (x *ErasureLayout) GetChunkSizes() []uint64 {
+	if x != nil {
+		return x.ChunkSizes
+	}
+	return nil
+}
+
+func (x *ErasureLayout) GetStripes() []*ErasureStripe {
+	if x != nil {
+		return x.Stripes
+	}
+	return nil
+}
+
+type ErasureStripe struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShardSize     uint64                 `protobuf:"varint,1,opt,name=shard_size,json=shardSize,proto3" json:"shard_size,omitempty"`
+	ParityCids    []string               `protobuf:"bytes,2,rep,name=parity_cids,json=parityCids,proto3" json:"parity_cids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ErasureStripe) Reset() {
+	*x = ErasureStripe{}
+	mi := &file_api_v1_storage_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ErasureStripe) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErasureStripe) ProtoMessage() {}
+
+func (x *ErasureStripe) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErasureStripe.ProtoReflect.Descriptor instead.
+func (*ErasureStripe) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *ErasureStripe) GetShardSize() uint64 {
+	if x != nil {
+		return x.ShardSize
+	}
+	return 0
+}
+
+func (x *ErasureStripe) GetParityCids() []string {
+	if x != nil {
+		return x.ParityCids
+	}
+	return nil
+}
+
+// Bucket is an entry of the object index, stored under buckets/<name>.
+type Bucket struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Unix time in seconds.
+	CreatedAt     int64 `protobuf:"varint,1,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Bucket) Reset() {
+	*x = Bucket{}
+	mi := &file_api_v1_storage_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Bucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Bucket) ProtoMessage() {}
+
+func (x *Bucket) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Bucket.ProtoReflect.Descriptor instead.
+func (*Bucket) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *Bucket) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+// Object is an entry of the object index, stored under
+// objects/<bucket>/<key>.
+type Object struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Cid         string                 `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	Size        int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Etag        string                 `protobuf:"bytes,3,opt,name=etag,proto3" json:"etag,omitempty"`
+	ContentType string                 `protobuf:"bytes,4,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	// Unix time in seconds.
+	ModifiedAt    int64             `protobuf:"varint,5,opt,name=modified_at,json=modifiedAt,proto3" json:"modified_at,omitempty"`
+	Metadata      []*ObjectMetadata `protobuf:"bytes,6,rep,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Object) Reset() {
+	*x = Object{}
+	mi := &file_api_v1_storage_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Object) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Object) ProtoMessage() {}
+
+func (x *Object) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Object.ProtoReflect.Descriptor instead.
+func (*Object) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *Object) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *Object) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Object) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+func (x *Object) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *Object) GetModifiedAt() int64 {
+	if x != nil {
+		return x.ModifiedAt
+	}
+	return 0
+}
+
+func (x *Object) GetMetadata() []*ObjectMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+// ObjectMetadata is a user-defined metadata pair of an object.
+type ObjectMetadata struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ObjectMetadata) Reset() {
+	*x = ObjectMetadata{}
+	mi := &file_api_v1_storage_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ObjectMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ObjectMetadata) ProtoMessage() {}
+
+func (x *ObjectMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ObjectMetadata.ProtoReflect.Descriptor instead.
+func (*ObjectMetadata) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *ObjectMetadata) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ObjectMetadata) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+// MultipartUpload is an object being uploaded in parts, stored under
+// multipart/<id>. Its parts are stored under multipart/<id>/<number>.
+type MultipartUpload struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Bucket      string                 `protobuf:"bytes,1,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	Key         string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	ContentType string                 `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Metadata    []*ObjectMetadata      `protobuf:"bytes,4,rep,name=metadata,proto3" json:"metadata,omitempty"`
+	// Unix time in seconds at which the upload started or last received a
+	// part.
+	UpdatedAt     int64 `protobuf:"varint,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MultipartUpload) Reset() {
+	*x = MultipartUpload{}
+	mi := &file_api_v1_storage_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MultipartUpload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultipartUpload) ProtoMessage() {}
+
+func (x *MultipartUpload) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultipartUpload.ProtoReflect.Descriptor instead.
+func (*MultipartUpload) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *MultipartUpload) GetBucket() string {
+	if x != nil {
+		return x.Bucket
+	}
+	return ""
+}
+
+func (x *MultipartUpload) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *MultipartUpload) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *MultipartUpload) GetMetadata() []*ObjectMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *MultipartUpload) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+type MultipartPart struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Blocks holding the part's content, in order.
+	Blocks        []*DagLink `protobuf:"bytes,1,rep,name=blocks,proto3" json:"blocks,omitempty"`
+	Size          int64      `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Md5           []byte     `protobuf:"bytes,3,opt,name=md5,proto3" json:"md5,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MultipartPart) Reset() {
+	*x = MultipartPart{}
+	mi := &file_api_v1_storage_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MultipartPart) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultipartPart) ProtoMessage() {}
+
+func (x *MultipartPart) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultipartPart.ProtoReflect.Descriptor instead.
+func (*MultipartPart) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *MultipartPart) GetBlocks() []*DagLink {
+	if x != nil {
+		return x.Blocks
+	}
+	return nil
+}
+
+func (x *MultipartPart) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *MultipartPart) GetMd5() []byte {
+	if x != nil {
+		return x.Md5
+	}
+	return nil
+}
+
+// NameRecord is a signed record mapping a name to a CID, stored in the DHT
+// under /name/<peer id>. The signature covers data, a marshalled
+// NameRecordData.
+type NameRecord struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Data      []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Signature []byte                 `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	// Public key of the signer, which the name is derived from.
+	PublicKey     []byte `protobuf:"bytes,3,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NameRecord) Reset() {
+	*x = NameRecord{}
+	mi := &file_api_v1_storage_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NameRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NameRecord) ProtoMessage() {}
+
+func (x *NameRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NameRecord.ProtoReflect.Descriptor instead.
+func (*NameRecord) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *NameRecord) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *NameRecord) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *NameRecord) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+type NameRecordData struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Cid   string                 `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	// Records with a higher sequence number replace lower ones.
+	Sequence uint64 `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Unix time in seconds after which the record is no longer valid.
+	ExpiresAt     int64 `protobuf:"varint,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NameRecordData) Reset() {
+	*x = NameRecordData{}
+	mi := &file_api_v1_storage_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NameRecordData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NameRecordData) ProtoMessage() {}
+
+func (x *NameRecordData) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NameRecordData.ProtoReflect.Descriptor instead.
+func (*NameRecordData) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *NameRecordData) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *NameRecordData) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *NameRecordData) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+// PublishedName is a name record published by this node, stored under
+// names/<peer id> so that it can be published again before it expires.
+type PublishedName struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name of the key the record is signed with.
+	Key    string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Record []byte `protobuf:"bytes,2,opt,name=record,proto3" json:"record,omitempty"`
+	// Lifetime of each record, in seconds.
+	Lifetime      int64 `protobuf:"varint,3,opt,name=lifetime,proto3" json:"lifetime,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishedName) Reset() {
+	*x = PublishedName{}
+	mi := &file_api_v1_storage_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishedName) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishedName) ProtoMessage() {}
+
+func (x *PublishedName) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_storage_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishedName.ProtoReflect.Descriptor instead.
+func (*PublishedName) Descriptor() ([]byte, []int) {
+	return file_api_v1_storage_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *PublishedName) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *PublishedName) GetRecord() []byte {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+func (x *PublishedName) GetLifetime() int64 {
+	if x != nil {
+		return x.Lifetime
+	}
+	return 0
+}
+
+var File_api_v1_storage_proto protoreflect.FileDescriptor
+
+const file_api_v1_storage_proto_rawDesc = "" +
+	"\n" +
+	"\x14api/v1/storage.proto\x12\n" +
+	"storage.v1\"\x1b\n" +
+	"\x05Block\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\"\xa0\x02\n" +
+	"\x0eAddFileRequest\x12\x1d\n" +
+	"\n" +
+	"chunk_data\x18\x01 \x01(\fR\tchunkData\x12\x18\n" +
+	"\achunker\x18\x02 \x01(\tR\achunker\x12 \n" +
+	"\vreplication\x18\x03 \x01(\rR\vreplication\x12\x1f\n" +
+	"\vdata_shards\x18\x04 \x01(\rR\n" +
+	"dataShards\x12#\n" +
+	"\rparity_shards\x18\x05 \x01(\rR\fparityShards\x12\x1e\n" +
+	"\n" +
+	"encryption\x18\x06 \x01(\tR\n" +
+	"encryption\x12\x16\n" +
+	"\x06cipher\x18\a \x01(\tR\x06cipher\x12\x12\n" +
+	"\x04name\x18\b \x01(\tR\x04name\x12!\n" +
+	"\fcontent_type\x18\t \x01(\tR\vcontentType\">\n" +
+	"\x0fAddFileResponse\x12\x19\n" +
+	"\broot_cid\x18\x01 \x01(\tR\arootCid\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\"d\n" +
+	"\x0eGetFileRequest\x12\x10\n" +
+	"\x03cid\x18\x01 \x01(\tR\x03cid\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x04R\x06offset\x12\x16\n" +
+	"\x06length\x18\x04 \x01(\x04R\x06length\"Z\n" +
+	"\x0fGetFileResponse\x12\x1d\n" +
+	"\n" +
+	"chunk_data\x18\x01 \x01(\fR\tchunkData\x12(\n" +
+	"\x04info\x18\x02 \x01(\v2\x14.storage.v1.FileInfoR\x04info\"\x9f\x02\n" +
+	"\bFileInfo\x12\x10\n" +
+	"\x03cid\x18\x01 \x01(\tR\x03cid\x12)\n" +
+	"\x10manifest_version\x18\x02 \x01(\rR\x0fmanifestVersion\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x16\n" +
+	"\x06chunks\x18\x04 \x01(\rR\x06chunks\x12\x1c\n" +
+	"\tencrypted\x18\x05 \x01(\bR\tencrypted\x12\x1c\n" +
+	"\tdirectory\x18\n" +
+	" \x01(\bR\tdirectory\x12\x12\n" +
+	"\x04name\x18\x06 \x01(\tR\x04name\x12!\n" +
+	"\fcontent_type\x18\a \x01(\tR\vcontentType\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\b \x01(\x03R\tcreatedAt\x12\x18\n" +
+	"\achunker\x18\t \x01(\tR\achunker\"G\n" +
+	"\n" +
+	"PinRequest\x12\x10\n" +
+	"\x03cid\x18\x01 \x01(\tR\x03cid\x12'\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x13.storage.v1.PinTypeR\x04type\"\r\n" +
+	"\vPinResponse\" \n" +
+	"\fUnpinRequest\x12\x10\n" +
+	"\x03cid\x18\x01 \x01(\tR\x03cid\"\x0f\n" +
+	"\rUnpinResponse\"\x11\n" +
+	"\x0fListPinsRequest\"D\n" +
+	"\aPinInfo\x12\x10\n" +
+	"\x03cid\x18\x01 \x01(\tR\x03cid\x12'\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x13.storage.v1.PinTypeR\x04type\";\n" +
+	"\x10ListPinsResponse\x12'\n" +
+	"\x04pins\x18\x01 \x03(\v2\x13.storage.v1.PinInfoR\x04pins\"\xb1\x01\n" +
+	"\x13AddDirectoryRequest\x12\x18\n" +
+	"\achunker\x18\x01 \x01(\tR\achunker\x12 \n" +
+	"\vreplication\x18\x02 \x01(\rR\vreplication\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12+\n" +
+	"\x05entry\x18\x04 \x01(\v2\x15.storage.v1.TreeEntryR\x05entry\x12\x1d\n" +
+	"\n" +
+	"chunk_data\x18\x05 \x01(\fR\tchunkData\"g\n" +
+	"\tTreeEntry\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1c\n" +
+	"\tdirectory\x18\x02 \x01(\bR\tdirectory\x12\x12\n" +
+	"\x04mode\x18\x03 \x01(\rR\x04mode\x12\x14\n" +
+	"\x05mtime\x18\x04 \x01(\x03R\x05mtime\"1\n" +
+	"\x14AddDirectoryResponse\x12\x19\n" +
+	"\broot_cid\x18\x01 \x01(\tR\arootCid\"(\n" +
+	"\x14ListDirectoryRequest\x12\x10\n" +
+	"\x03cid\x18\x01 \x01(\tR\x03cid\"w\n" +
+	"\x15ListDirectoryResponse\x12(\n" +
+	"\x04info\x18\x01 \x01(\v2\x14.storage.v1.FileInfoR\x04info\x124\n" +
+	"\aentries\x18\x02 \x03(\v2\x1a.storage.v1.DirectoryEntryR\aentries\"\x85\x02\n" +
+	"\x12StartUploadRequest\x12\x18\n" +
+	"\achunker\x18\x01 \x01(\tR\achunker\x12 \n" +
+	"\vreplication\x18\x02 \x01(\rR\vreplication\x12\x1f\n" +
+	"\vdata_shards\x18\x03 \x01(\rR\n" +
+	"dataShards\x12#\n" +
+	"\rparity_shards\x18\x04 \x01(\rR\fparityShards\x12\x1e\n" +
+	"\n" +
+	"encryption\x18\x05 \x01(\tR\n" +
+	"encryption\x12\x16\n" +
+	"\x06cipher\x18\x06 \x01(\tR\x06cipher\x12\x12\n" +
+	"\x04name\x18\a \x01(\tR\x04name\x12!\n" +
+	"\fcontent_type\x18\b \x01(\tR\vcontentType\"2\n" +
+	"\x13StartUploadResponse\x12\x1b\n" +
+	"\tupload_id\x18\x01 \x01(\tR\buploadId\"c\n" +
+	"\rUploadRequest\x12\x1b\n" +
+	"\tupload_id\x18\x01 \x01(\tR\buploadId\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\x12\x1d\n" +
+	"\n" +
+	"chunk_data\x18\x03 \x01(\fR\tchunkData\"(\n" +
+	"\x0eUploadResponse\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\x04R\x06offset\"/\n" +
+	"\x10GetUploadRequest\x12\x1b\n" +
+	"\tupload_id\x18\x01 \x01(\tR\buploadId\"+\n" +
+	"\x11GetUploadResponse\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\x04R\x06offset\"F\n" +
+	"\x13FinishUploadRequest\x12\x1b\n" +
+	"\tupload_id\x18\x01 \x01(\tR\buploadId\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x04R\x04size\"5\n" +
+	"\x0fStatFileRequest\x12\x10\n" +
+	"\x03cid\x18\x01 \x01(\tR\x03cid\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\"g\n" +
+	"\x11ListChunksRequest\x12\x10\n" +
+	"\x03cid\x18\x01 \x01(\tR\x03cid\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x04R\x06offset\x12\x16\n" +
+	"\x06length\x18\x04 \x01(\x04R\x06length\"m\n" +
+	"\x12ListChunksResponse\x12(\n" +
+	"\x04info\x18\x01 \x01(\v2\x14.storage.v1.FileInfoR\x04info\x12-\n" +
+	"\x06chunks\x18\x02 \x03(\v2\x15.storage.v1.ChunkInfoR\x06chunks\"T\n" +
+	"\x12PublishNameRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x10\n" +
+	"\x03cid\x18\x02 \x01(\tR\x03cid\x12\x1a\n" +
+	"\blifetime\x18\x03 \x01(\x03R\blifetime\"(\n" +
+	"\x12ResolveNameRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"l\n" +
+	"\tNameEntry\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x10\n" +
+	"\x03cid\x18\x02 \x01(\tR\x03cid\x12\x1a\n" +
+	"\bsequence\x18\x03 \x01(\x04R\bsequence\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\x03R\texpiresAt\"a\n" +
+	"\tChunkInfo\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\x04R\x06offset\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x10\n" +
+	"\x03cid\x18\x03 \x01(\tR\x03cid\x12\x16\n" +
+	"\x06sha256\x18\x04 \x01(\fR\x06sha256\"\v\n" +
+	"\tGCRequest\"T\n" +
+	"\n" +
+	"GCResponse\x12%\n" +
+	"\x0eremoved_blocks\x18\x01 \x01(\x03R\rremovedBlocks\x12\x1f\n" +
+	"\vfreed_bytes\x18\x02 \x01(\x03R\n" +
+	"freedBytes\"\x8e\x04\n" +
+	"\bManifest\x12\x1d\n" +
+	"\n" +
+	"block_cids\x18\x01 \x03(\tR\tblockCids\x123\n" +
+	"\aerasure\x18\x02 \x01(\v2\x19.storage.v1.ErasureLayoutR\aerasure\x12;\n" +
+	"\tencrypted\x18\x03 \x01(\v2\x1d.storage.v1.EncryptedManifestR\tencrypted\x12\x1d\n" +
+	"\n" +
+	"chunk_keys\x18\x04 \x03(\fR\tchunkKeys\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\rR\aversion\x12\x12\n" +
+	"\x04size\x18\x06 \x01(\x04R\x04size\x12\x1f\n" +
+	"\vchunk_sizes\x18\a \x03(\x04R\n" +
+	"chunkSizes\x12\x12\n" +
+	"\x04name\x18\b \x01(\tR\x04name\x12!\n" +
+	"\fcontent_type\x18\t \x01(\tR\vcontentType\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\n" +
+	" \x01(\x03R\tcreatedAt\x12\x18\n" +
+	"\achunker\x18\v \x01(\tR\achunker\x12)\n" +
+	"\x05links\x18\f \x03(\v2\x13.storage.v1.DagLinkR\x05links\x12\x1b\n" +
+	"\tdag_depth\x18\r \x01(\rR\bdagDepth\x12\x16\n" +
+	"\x06chunks\x18\x0e \x01(\x04R\x06chunks\x123\n" +
+	"\tdirectory\x18\x0f \x01(\v2\x15.storage.v1.DirectoryR\tdirectory\"A\n" +
+	"\tDirectory\x124\n" +
+	"\aentries\x18\x01 \x03(\v2\x1a.storage.v1.DirectoryEntryR\aentries\"\x92\x01\n" +
+	"\x0eDirectoryEntry\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x10\n" +
+	"\x03cid\x18\x02 \x01(\tR\x03cid\x12\x1c\n" +
+	"\tdirectory\x18\x03 \x01(\bR\tdirectory\x12\x12\n" +
+	"\x04mode\x18\x04 \x01(\rR\x04mode\x12\x14\n" +
+	"\x05mtime\x18\x05 \x01(\x03R\x05mtime\x12\x12\n" +
+	"\x04size\x18\x06 \x01(\x04R\x04size\"A\n" +
+	"\aDagLink\x12\x10\n" +
+	"\x03cid\x18\x01 \x01(\tR\x03cid\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x04R\x04size\x12\x10\n" +
+	"\x03key\x18\x03 \x01(\fR\x03key\"4\n" +
+	"\aDagNode\x12)\n" +
+	"\x05links\x18\x01 \x03(\v2\x13.storage.v1.DagLinkR\x05links\"\xb9\x02\n" +
+	"\rUploadSession\x12\x18\n" +
+	"\achunker\x18\x01 \x01(\tR\achunker\x12 \n" +
+	"\vreplication\x18\x02 \x01(\rR\vreplication\x12\x1f\n" +
+	"\vdata_shards\x18\x03 \x01(\rR\n" +
+	"dataShards\x12#\n" +
+	"\rparity_shards\x18\x04 \x01(\rR\fparityShards\x12\x1e\n" +
+	"\n" +
+	"encryption\x18\x05 \x01(\tR\n" +
+	"encryption\x12\x16\n" +
+	"\x06cipher\x18\x06 \x01(\tR\x06cipher\x12\x12\n" +
+	"\x04name\x18\a \x01(\tR\x04name\x12!\n" +
+	"\fcontent_type\x18\b \x01(\tR\vcontentType\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\n" +
+	" \x01(\x03R\tupdatedAt\x12\x12\n" +
+	"\x04size\x18\v \x01(\x03R\x04sizeJ\x04\b\t\x10\n" +
+	"\"\xdf\x01\n" +
+	"\x11EncryptedManifest\x12\x16\n" +
+	"\x06cipher\x18\x01 \x01(\tR\x06cipher\x12\x1d\n" +
+	"\n" +
+	"block_cids\x18\x02 \x03(\tR\tblockCids\x12\x16\n" +
+	"\x06sealed\x18\x03 \x01(\fR\x06sealed\x123\n" +
+	"\aerasure\x18\x04 \x01(\v2\x19.storage.v1.ErasureLayoutR\aerasure\x12)\n" +
+	"\x05links\x18\x05 \x03(\v2\x13.storage.v1.DagLinkR\x05links\x12\x1b\n" +
+	"\tdag_depth\x18\x06 \x01(\rR\bdagDepth\"\xab\x01\n" +
+	"\rErasureLayout\x12\x1f\n" +
+	"\vdata_shards\x18\x01 \x01(\rR\n" +
+	"dataShards\x12#\n" +
+	"\rparity_shards\x18\x02 \x01(\rR\fparityShards\x12\x1f\n" +
+	"\vchunk_sizes\x18\x03 \x03(\x04R\n" +
+	"chunkSizes\x123\n" +
+	"\astripes\x18\x04 \x03(\v2\x19.storage.v1.ErasureStripeR\astripes\"O\n" +
+	"\rErasureStripe\x12\x1d\n" +
+	"\n" +
+	"shard_size\x18\x01 \x01(\x04R\tshardSize\x12\x1f\n" +
+	"\vparity_cids\x18\x02 \x03(\tR\n" +
+	"parityCids\"'\n" +
+	"\x06Bucket\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x01 \x01(\x03R\tcreatedAt\"\xbe\x01\n" +
+	"\x06Object\x12\x10\n" +
+	"\x03cid\x18\x01 \x01(\tR\x03cid\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x12\n" +
+	"\x04etag\x18\x03 \x01(\tR\x04etag\x12!\n" +
+	"\fcontent_type\x18\x04 \x01(\tR\vcontentType\x12\x1f\n" +
+	"\vmodified_at\x18\x05 \x01(\x03R\n" +
+	"modifiedAt\x126\n" +
+	"\bmetadata\x18\x06 \x03(\v2\x1a.storage.v1.ObjectMetadataR\bmetadata\"8\n" +
+	"\x0eObjectMetadata\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\"\xb5\x01\n" +
+	"\x0fMultipartUpload\x12\x16\n" +
+	"\x06bucket\x18\x01 \x01(\tR\x06bucket\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12!\n" +
+	"\fcontent_type\x18\x03 \x01(\tR\vcontentType\x126\n" +
+	"\bmetadata\x18\x04 \x03(\v2\x1a.storage.v1.ObjectMetadataR\bmetadata\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\x03R\tupdatedAt\"b\n" +
+	"\rMultipartPart\x12+\n" +
+	"\x06blocks\x18\x01 \x03(\v2\x13.storage.v1.DagLinkR\x06blocks\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x10\n" +
+	"\x03md5\x18\x03 \x01(\fR\x03md5\"]\n" +
+	"\n" +
+	"NameRecord\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12\x1c\n" +
+	"\tsignature\x18\x02 \x01(\fR\tsignature\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x03 \x01(\fR\tpublicKey\"]\n" +
+	"\x0eNameRecordData\x12\x10\n" +
+	"\x03cid\x18\x01 \x01(\tR\x03cid\x12\x1a\n" +
+	"\bsequence\x18\x02 \x01(\x04R\bsequence\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\x03R\texpiresAt\"U\n" +
+	"\rPublishedName\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
+	"\x06record\x18\x02 \x01(\fR\x06record\x12\x1a\n" +
+	"\blifetime\x18\x03 \x01(\x03R\blifetime*P\n" +
+	"\aPinType\x12\x18\n" +
+	"\x14PIN_TYPE_UNSPECIFIED\x10\x00\x12\x13\n" +
+	"\x0fPIN_TYPE_DIRECT\x10\x01\x12\x16\n" +
+	"\x12PIN_TYPE_RECURSIVE\x10\x022\xfe\b\n" +
+	"\x0eStorageService\x12D\n" +
+	"\aAddFile\x12\x1a.storage.v1.AddFileRequest\x1a\x1b.storage.v1.AddFileResponse(\x01\x12D\n" +
+	"\aGetFile\x12\x1a.storage.v1.GetFileRequest\x1a\x1b.storage.v1.GetFileResponse0\x01\x126\n" +
+	"\x03Pin\x12\x16.storage.v1.PinRequest\x1a\x17.storage.v1.PinResponse\x12<\n" +
+	"\x05Unpin\x12\x18.storage.v1.UnpinRequest\x1a\x19.storage.v1.UnpinResponse\x12E\n" +
+	"\bListPins\x12\x1b.storage.v1.ListPinsRequest\x1a\x1c.storage.v1.ListPinsResponse\x123\n" +
+	"\x02GC\x12\x15.storage.v1.GCRequest\x1a\x16.storage.v1.GCResponse\x12S\n" +
+	"\fAddDirectory\x12\x1f.storage.v1.AddDirectoryRequest\x1a .storage.v1.AddDirectoryResponse(\x01\x12T\n" +
+	"\rListDirectory\x12 .storage.v1.ListDirectoryRequest\x1a!.storage.v1.ListDirectoryResponse\x12N\n" +
+	"\vStartUpload\x12\x1e.storage.v1.StartUploadRequest\x1a\x1f.storage.v1.StartUploadResponse\x12A\n" +
+	"\x06Upload\x12\x19.storage.v1.UploadRequest\x1a\x1a.storage.v1.UploadResponse(\x01\x12H\n" +
+	"\tGetUpload\x12\x1c.storage.v1.GetUploadRequest\x1a\x1d.storage.v1.GetUploadResponse\x12L\n" +
+	"\fFinishUpload\x12\x1f.storage.v1.FinishUploadRequest\x1a\x1b.storage.v1.AddFileResponse\x12=\n" +
+	"\bStatFile\x12\x1b.storage.v1.StatFileRequest\x1a\x14.storage.v1.FileInfo\x12M\n" +
+	"\n" +
+	"ListChunks\x12\x1d.storage.v1.ListChunksRequest\x1a\x1e.storage.v1.ListChunksResponse0\x01\x12D\n" +
+	"\vPublishName\x12\x1e.storage.v1.PublishNameRequest\x1a\x15.storage.v1.NameEntry\x12D\n" +
+	"\vResolveName\x12\x1e.storage.v1.ResolveNameRequest\x1a\x15.storage.v1.NameEntryB'Z%github.com/Yashh56/p2p-storage/api/v1b\x06proto3"
 
 var (
 	file_api_v1_storage_proto_rawDescOnce sync.Once
@@ -322,25 +3609,121 @@ func file_api_v1_storage_proto_rawDescGZIP() []byte {
 	return file_api_v1_storage_proto_rawDescData
 }
 
-var file_api_v1_storage_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_api_v1_storage_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_api_v1_storage_proto_msgTypes = make([]protoimpl.MessageInfo, 51)
 var file_api_v1_storage_proto_goTypes = []any{
-	(*Block)(nil),           // 0: storage.v1.Block
-	(*AddFileRequest)(nil),  // 1: storage.v1.AddFileRequest
-	(*AddFileResponse)(nil), // 2: storage.v1.AddFileResponse
-	(*GetFileRequest)(nil),  // 3: storage.v1.GetFileRequest
-	(*GetFileResponse)(nil), // 4: storage.v1.GetFileResponse
-	(*Manifest)(nil),        // 5: storage.v1.Manifest
+	(PinType)(0),                  // 0: storage.v1.PinType
+	(*Block)(nil),                 // 1: storage.v1.Block
+	(*AddFileRequest)(nil),        // 2: storage.v1.AddFileRequest
+	(*AddFileResponse)(nil),       // 3: storage.v1.AddFileResponse
+	(*GetFileRequest)(nil),        // 4: storage.v1.GetFileRequest
+	(*GetFileResponse)(nil),       // 5: storage.v1.GetFileResponse
+	(*FileInfo)(nil),              // 6: storage.v1.FileInfo
+	(*PinRequest)(nil),            // 7: storage.v1.PinRequest
+	(*PinResponse)(nil),           // 8: storage.v1.PinResponse
+	(*UnpinRequest)(nil),          // 9: storage.v1.UnpinRequest
+	(*UnpinResponse)(nil),         // 10: storage.v1.UnpinResponse
+	(*ListPinsRequest)(nil),       // 11: storage.v1.ListPinsRequest
+	(*PinInfo)(nil),               // 12: storage.v1.PinInfo
+	(*ListPinsResponse)(nil),      // 13: storage.v1.ListPinsResponse
+	(*AddDirectoryRequest)(nil),   // 14: storage.v1.AddDirectoryRequest
+	(*TreeEntry)(nil),             // 15: storage.v1.TreeEntry
+	(*AddDirectoryResponse)(nil),  // 16: storage.v1.AddDirectoryResponse
+	(*ListDirectoryRequest)(nil),  // 17: storage.v1.ListDirectoryRequest
+	(*ListDirectoryResponse)(nil), // 18: storage.v1.ListDirectoryResponse
+	(*StartUploadRequest)(nil),    // 19: storage.v1.StartUploadRequest
+	(*StartUploadResponse)(nil),   // 20: storage.v1.StartUploadResponse
+	(*UploadRequest)(nil),         // 21: storage.v1.UploadRequest
+	(*UploadResponse)(nil),        // 22: storage.v1.UploadResponse
+	(*GetUploadRequest)(nil),      // 23: storage.v1.GetUploadRequest
+	(*GetUploadResponse)(nil),     // 24: storage.v1.GetUploadResponse
+	(*FinishUploadRequest)(nil),   // 25: storage.v1.FinishUploadRequest
+	(*StatFileRequest)(nil),       // 26: storage.v1.StatFileRequest
+	(*ListChunksRequest)(nil),     // 27: storage.v1.ListChunksRequest
+	(*ListChunksResponse)(nil),    // 28: storage.v1.ListChunksResponse
+	(*PublishNameRequest)(nil),    // 29: storage.v1.PublishNameRequest
+	(*ResolveNameRequest)(nil),    // 30: storage.v1.ResolveNameRequest
+	(*NameEntry)(nil),             // 31: storage.v1.NameEntry
+	(*ChunkInfo)(nil),             // 32: storage.v1.ChunkInfo
+	(*GCRequest)(nil),             // 33: storage.v1.GCRequest
+	(*GCResponse)(nil),            // 34: storage.v1.GCResponse
+	(*Manifest)(nil),              // 35: storage.v1.Manifest
+	(*Directory)(nil),             // 36: storage.v1.Directory
+	(*DirectoryEntry)(nil),        // 37: storage.v1.DirectoryEntry
+	(*DagLink)(nil),               // 38: storage.v1.DagLink
+	(*DagNode)(nil),               // 39: storage.v1.DagNode
+	(*UploadSession)(nil),         // 40: storage.v1.UploadSession
+	(*EncryptedManifest)(nil),     // 41: storage.v1.EncryptedManifest
+	(*ErasureLayout)(nil),         // 42: storage.v1.ErasureLayout
+	(*ErasureStripe)(nil),         // 43: storage.v1.ErasureStripe
+	(*Bucket)(nil),                // 44: storage.v1.Bucket
+	(*Object)(nil),                // 45: storage.v1.Object
+	(*ObjectMetadata)(nil),        // 46: storage.v1.ObjectMetadata
+	(*MultipartUpload)(nil),       // 47: storage.v1.MultipartUpload
+	(*MultipartPart)(nil),         // 48: storage.v1.MultipartPart
+	(*NameRecord)(nil),            // 49: storage.v1.NameRecord
+	(*NameRecordData)(nil),        // 50: storage.v1.NameRecordData
+	(*PublishedName)(nil),         // 51: storage.v1.PublishedName
 }
 var file_api_v1_storage_proto_depIdxs = []int32{
-	1, // 0: storage.v1.StorageService.AddFile:input_type -> storage.v1.AddFileRequest
-	3, // 1: storage.v1.StorageService.GetFile:input_type -> storage.v1.GetFileRequest
-	2, // 2: storage.v1.StorageService.AddFile:output_type -> storage.v1.AddFileResponse
-	4, // 3: storage.v1.StorageService.GetFile:output_type -> storage.v1.GetFileResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	6,  // 0: storage.v1.GetFileResponse.info:type_name -> storage.v1.FileInfo
+	0,  // 1: storage.v1.PinRequest.type:type_name -> storage.v1.PinType
+	0,  // 2: storage.v1.PinInfo.type:type_name -> storage.v1.PinType
+	12, // 3: storage.v1.ListPinsResponse.pins:type_name -> storage.v1.PinInfo
+	15, // 4: storage.v1.AddDirectoryRequest.entry:type_name -> storage.v1.TreeEntry
+	6,  // 5: storage.v1.ListDirectoryResponse.info:type_name -> storage.v1.FileInfo
+	37, // 6: storage.v1.ListDirectoryResponse.entries:type_name -> storage.v1.DirectoryEntry
+	6,  // 7: storage.v1.ListChunksResponse.info:type_name -> storage.v1.FileInfo
+	32, // 8: storage.v1.ListChunksResponse.chunks:type_name -> storage.v1.ChunkInfo
+	42, // 9: storage.v1.Manifest.erasure:type_name -> storage.v1.ErasureLayout
+	41, // 10: storage.v1.Manifest.encrypted:type_name -> storage.v1.EncryptedManifest
+	38, // 11: storage.v1.Manifest.links:type_name -> storage.v1.DagLink
+	36, // 12: storage.v1.Manifest.directory:type_name -> storage.v1.Directory
+	37, // 13: storage.v1.Directory.entries:type_name -> storage.v1.DirectoryEntry
+	38, // 14: storage.v1.DagNode.links:type_name -> storage.v1.DagLink
+	42, // 15: storage.v1.EncryptedManifest.erasure:type_name -> storage.v1.ErasureLayout
+	38, // 16: storage.v1.EncryptedManifest.links:type_name -> storage.v1.DagLink
+	43, // 17: storage.v1.ErasureLayout.stripes:type_name -> storage.v1.ErasureStripe
+	46, // 18: storage.v1.Object.metadata:type_name -> storage.v1.ObjectMetadata
+	46, // 19: storage.v1.MultipartUpload.metadata:type_name -> storage.v1.ObjectMetadata
+	38, // 20: storage.v1.MultipartPart.blocks:type_name -> storage.v1.DagLink
+	2,  // 21: storage.v1.StorageService.AddFile:input_type -> storage.v1.AddFileRequest
+	4,  // 22: storage.v1.StorageService.GetFile:input_type -> storage.v1.GetFileRequest
+	7,  // 23: storage.v1.StorageService.Pin:input_type -> storage.v1.PinRequest
+	9,  // 24: storage.v1.StorageService.Unpin:input_type -> storage.v1.UnpinRequest
+	11, // 25: storage.v1.StorageService.ListPins:input_type -> storage.v1.ListPinsRequest
+	33, // 26: storage.v1.StorageService.GC:input_type -> storage.v1.GCRequest
+	14, // 27: storage.v1.StorageService.AddDirectory:input_type -> storage.v1.AddDirectoryRequest
+	17, // 28: storage.v1.StorageService.ListDirectory:input_type -> storage.v1.ListDirectoryRequest
+	19, // 29: storage.v1.StorageService.StartUpload:input_type -> storage.v1.StartUploadRequest
+	21, // 30: storage.v1.StorageService.Upload:input_type -> storage.v1.UploadRequest
+	23, // 31: storage.v1.StorageService.GetUpload:input_type -> storage.v1.GetUploadRequest
+	25, // 32: storage.v1.StorageService.FinishUpload:input_type -> storage.v1.FinishUploadRequest
+	26, // 33: storage.v1.StorageService.StatFile:input_type -> storage.v1.StatFileRequest
+	27, // 34: storage.v1.StorageService.ListChunks:input_type -> storage.v1.ListChunksRequest
+	29, // 35: storage.v1.StorageService.PublishName:input_type -> storage.v1.PublishNameRequest
+	30, // 36: storage.v1.StorageService.ResolveName:input_type -> storage.v1.ResolveNameRequest
+	3,  // 37: storage.v1.StorageService.AddFile:output_type -> storage.v1.AddFileResponse
+	5,  // 38: storage.v1.StorageService.GetFile:output_type -> storage.v1.GetFileResponse
+	8,  // 39: storage.v1.StorageService.Pin:output_type -> storage.v1.PinResponse
+	10, // 40: storage.v1.StorageService.Unpin:output_type -> storage.v1.UnpinResponse
+	13, // 41: storage.v1.StorageService.ListPins:output_type -> storage.v1.ListPinsResponse
+	34, // 42: storage.v1.StorageService.GC:output_type -> storage.v1.GCResponse
+	16, // 43: storage.v1.StorageService.AddDirectory:output_type -> storage.v1.AddDirectoryResponse
+	18, // 44: storage.v1.StorageService.ListDirectory:output_type -> storage.v1.ListDirectoryResponse
+	20, // 45: storage.v1.StorageService.StartUpload:output_type -> storage.v1.StartUploadResponse
+	22, // 46: storage.v1.StorageService.Upload:output_type -> storage.v1.UploadResponse
+	24, // 47: storage.v1.StorageService.GetUpload:output_type -> storage.v1.GetUploadResponse
+	3,  // 48: storage.v1.StorageService.FinishUpload:output_type -> storage.v1.AddFileResponse
+	6,  // 49: storage.v1.StorageService.StatFile:output_type -> storage.v1.FileInfo
+	28, // 50: storage.v1.StorageService.ListChunks:output_type -> storage.v1.ListChunksResponse
+	31, // 51: storage.v1.StorageService.PublishName:output_type -> storage.v1.NameEntry
+	31, // 52: storage.v1.StorageService.ResolveName:output_type -> storage.v1.NameEntry
+	37, // [37:53] is the sub-list for method output_type
+	21, // [21:37] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
 }
 
 func init() { file_api_v1_storage_proto_init() }
@@ -353,13 +3736,14 @@ func file_api_v1_storage_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_v1_storage_proto_rawDesc), len(file_api_v1_storage_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   6,
+			NumEnums:      1,
+			NumMessages:   51,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_api_v1_storage_proto_goTypes,
 		DependencyIndexes: file_api_v1_storage_proto_depIdxs,
+		EnumInfos:         file_api_v1_storage_proto_enumTypes,
 		MessageInfos:      file_api_v1_storage_proto_msgTypes,
 	}.Build()
 	File_api_v1_storage_proto = out.File
@@ -20,8 +20,22 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	StorageService_AddFile_FullMethodName = "/storage.v1.StorageService/AddFile"
-	StorageService_GetFile_FullMethodName = "/storage.v1.StorageService/GetFile"
+	StorageService_AddFile_FullMethodName       = "/storage.v1.StorageService/AddFile"
+	StorageService_GetFile_FullMethodName       = "/storage.v1.StorageService/GetFile"
+	StorageService_Pin_FullMethodName           = "/storage.v1.StorageService/Pin"
+	StorageService_Unpin_FullMethodName         = "/storage.v1.StorageService/Unpin"
+	StorageService_ListPins_FullMethodName      = "/storage.v1.StorageService/ListPins"
+	StorageService_GC_FullMethodName            = "/storage.v1.StorageService/GC"
+	StorageService_AddDirectory_FullMethodName  = "/storage.v1.StorageService/AddDirectory"
+	StorageService_ListDirectory_FullMethodName = "/storage.v1.StorageService/ListDirectory"
+	StorageService_StartUpload_FullMethodName   = "/storage.v1.StorageService/StartUpload"
+	StorageService_Upload_FullMethodName        = "/storage.v1.StorageService/Upload"
+	StorageService_GetUpload_FullMethodName     = "/storage.v1.StorageService/GetUpload"
+	StorageService_FinishUpload_FullMethodName  = "/storage.v1.StorageService/FinishUpload"
+	StorageService_StatFile_FullMethodName      = "/storage.v1.StorageService/StatFile"
+	StorageService_ListChunks_FullMethodName    = "/storage.v1.StorageService/ListChunks"
+	StorageService_PublishName_FullMethodName   = "/storage.v1.StorageService/PublishName"
+	StorageService_ResolveName_FullMethodName   = "/storage.v1.StorageService/ResolveName"
 )
 
 // StorageServiceClient is the client API for StorageService service.
@@ -30,6 +44,29 @@ const (
 type StorageServiceClient interface {
 	AddFile(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[AddFileRequest, AddFileResponse], error)
 	GetFile(ctx context.Context, in *GetFileRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GetFileResponse], error)
+	Pin(ctx context.Context, in *PinRequest, opts ...grpc.CallOption) (*PinResponse, error)
+	Unpin(ctx context.Context, in *UnpinRequest, opts ...grpc.CallOption) (*UnpinResponse, error)
+	ListPins(ctx context.Context, in *ListPinsRequest, opts ...grpc.CallOption) (*ListPinsResponse, error)
+	GC(ctx context.Context, in *GCRequest, opts ...grpc.CallOption) (*GCResponse, error)
+	AddDirectory(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[AddDirectoryRequest, AddDirectoryResponse], error)
+	ListDirectory(ctx context.Context, in *ListDirectoryRequest, opts ...grpc.CallOption) (*ListDirectoryResponse, error)
+	// Resumable uploads: StartUpload opens a session, Upload streams content
+	// into it and may be called again after an interruption, GetUpload
+	// reports how much was committed, and FinishUpload stores the file.
+	StartUpload(ctx context.Context, in *StartUploadRequest, opts ...grpc.CallOption) (*StartUploadResponse, error)
+	Upload(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadRequest, UploadResponse], error)
+	GetUpload(ctx context.Context, in *GetUploadRequest, opts ...grpc.CallOption) (*GetUploadResponse, error)
+	FinishUpload(ctx context.Context, in *FinishUploadRequest, opts ...grpc.CallOption) (*AddFileResponse, error)
+	// StatFile returns the metadata of a file or directory without reading
+	// its content.
+	StatFile(ctx context.Context, in *StatFileRequest, opts ...grpc.CallOption) (*FileInfo, error)
+	// ListChunks lists the chunks of a file, so a client can check content
+	// it already has.
+	ListChunks(ctx context.Context, in *ListChunksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListChunksResponse], error)
+	// PublishName points the name of one of the node's keys at a CID.
+	PublishName(ctx context.Context, in *PublishNameRequest, opts ...grpc.CallOption) (*NameEntry, error)
+	// ResolveName looks up the CID a name points to.
+	ResolveName(ctx context.Context, in *ResolveNameRequest, opts ...grpc.CallOption) (*NameEntry, error)
 }
 
 type storageServiceClient struct {
@@ -72,12 +109,190 @@ func (c *storageServiceClient) GetFile(ctx context.Context, in *GetFileRequest,
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type StorageService_GetFileClient = grpc.ServerStreamingClient[GetFileResponse]
 
+func (c *storageServiceClient) Pin(ctx context.Context, in *PinRequest, opts ...grpc.CallOption) (*PinResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PinResponse)
+	err := c.cc.Invoke(ctx, StorageService_Pin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) Unpin(ctx context.Context, in *UnpinRequest, opts ...grpc.CallOption) (*UnpinResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnpinResponse)
+	err := c.cc.Invoke(ctx, StorageService_Unpin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) ListPins(ctx context.Context, in *ListPinsRequest, opts ...grpc.CallOption) (*ListPinsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPinsResponse)
+	err := c.cc.Invoke(ctx, StorageService_ListPins_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) GC(ctx context.Context, in *GCRequest, opts ...grpc.CallOption) (*GCResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GCResponse)
+	err := c.cc.Invoke(ctx, StorageService_GC_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) AddDirectory(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[AddDirectoryRequest, AddDirectoryResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &StorageService_ServiceDesc.Streams[2], StorageService_AddDirectory_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AddDirectoryRequest, AddDirectoryResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StorageService_AddDirectoryClient = grpc.ClientStreamingClient[AddDirectoryRequest, AddDirectoryResponse]
+
+func (c *storageServiceClient) ListDirectory(ctx context.Context, in *ListDirectoryRequest, opts ...grpc.CallOption) (*ListDirectoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDirectoryResponse)
+	err := c.cc.Invoke(ctx, StorageService_ListDirectory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) StartUpload(ctx context.Context, in *StartUploadRequest, opts ...grpc.CallOption) (*StartUploadResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StartUploadResponse)
+	err := c.cc.Invoke(ctx, StorageService_StartUpload_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) Upload(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadRequest, UploadResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &StorageService_ServiceDesc.Streams[3], StorageService_Upload_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UploadRequest, UploadResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StorageService_UploadClient = grpc.ClientStreamingClient[UploadRequest, UploadResponse]
+
+func (c *storageServiceClient) GetUpload(ctx context.Context, in *GetUploadRequest, opts ...grpc.CallOption) (*GetUploadResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetUploadResponse)
+	err := c.cc.Invoke(ctx, StorageService_GetUpload_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) FinishUpload(ctx context.Context, in *FinishUploadRequest, opts ...grpc.CallOption) (*AddFileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddFileResponse)
+	err := c.cc.Invoke(ctx, StorageService_FinishUpload_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) StatFile(ctx context.Context, in *StatFileRequest, opts ...grpc.CallOption) (*FileInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileInfo)
+	err := c.cc.Invoke(ctx, StorageService_StatFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) ListChunks(ctx context.Context, in *ListChunksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListChunksResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &StorageService_ServiceDesc.Streams[4], StorageService_ListChunks_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListChunksRequest, ListChunksResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StorageService_ListChunksClient = grpc.ServerStreamingClient[ListChunksResponse]
+
+func (c *storageServiceClient) PublishName(ctx context.Context, in *PublishNameRequest, opts ...grpc.CallOption) (*NameEntry, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NameEntry)
+	err := c.cc.Invoke(ctx, StorageService_PublishName_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageServiceClient) ResolveName(ctx context.Context, in *ResolveNameRequest, opts ...grpc.CallOption) (*NameEntry, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NameEntry)
+	err := c.cc.Invoke(ctx, StorageService_ResolveName_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // StorageServiceServer is the server API for StorageService service.
 // All implementations must embed UnimplementedStorageServiceServer
 // for forward compatibility.
 type StorageServiceServer interface {
 	AddFile(grpc.ClientStreamingServer[AddFileRequest, AddFileResponse]) error
 	GetFile(*GetFileRequest, grpc.ServerStreamingServer[GetFileResponse]) error
+	Pin(context.Context, *PinRequest) (*PinResponse, error)
+	Unpin(context.Context, *UnpinRequest) (*UnpinResponse, error)
+	ListPins(context.Context, *ListPinsRequest) (*ListPinsResponse, error)
+	GC(context.Context, *GCRequest) (*GCResponse, error)
+	AddDirectory(grpc.ClientStreamingServer[AddDirectoryRequest, AddDirectoryResponse]) error
+	ListDirectory(context.Context, *ListDirectoryRequest) (*ListDirectoryResponse, error)
+	// Resumable uploads: StartUpload opens a session, Upload streams content
+	// into it and may be called again after an interruption, GetUpload
+	// reports how much was committed, and FinishUpload stores the file.
+	StartUpload(context.Context, *StartUploadRequest) (*StartUploadResponse, error)
+	Upload(grpc.ClientStreamingServer[UploadRequest, UploadResponse]) error
+	GetUpload(context.Context, *GetUploadRequest) (*GetUploadResponse, error)
+	FinishUpload(context.Context, *FinishUploadRequest) (*AddFileResponse, error)
+	// StatFile returns the metadata of a file or directory without reading
+	// its content.
+	StatFile(context.Context, *StatFileRequest) (*FileInfo, error)
+	// ListChunks lists the chunks of a file, so a client can check content
+	// it already has.
+	ListChunks(*ListChunksRequest, grpc.ServerStreamingServer[ListChunksResponse]) error
+	// PublishName points the name of one of the node's keys at a CID.
+	PublishName(context.Context, *PublishNameRequest) (*NameEntry, error)
+	// ResolveName looks up the CID a name points to.
+	ResolveName(context.Context, *ResolveNameRequest) (*NameEntry, error)
 	mustEmbedUnimplementedStorageServiceServer()
 }
 
@@ -94,6 +309,48 @@ func (UnimplementedStorageServiceServer) AddFile(grpc.ClientStreamingServer[AddF
 func (UnimplementedStorageServiceServer) GetFile(*GetFileRequest, grpc.ServerStreamingServer[GetFileResponse]) error {
 	return status.Errorf(codes.Unimplemented, "method GetFile not implemented")
 }
+func (UnimplementedStorageServiceServer) Pin(context.Context, *PinRequest) (*PinResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Pin not implemented")
+}
+func (UnimplementedStorageServiceServer) Unpin(context.Context, *UnpinRequest) (*UnpinResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unpin not implemented")
+}
+func (UnimplementedStorageServiceServer) ListPins(context.Context, *ListPinsRequest) (*ListPinsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPins not implemented")
+}
+func (UnimplementedStorageServiceServer) GC(context.Context, *GCRequest) (*GCResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GC not implemented")
+}
+func (UnimplementedStorageServiceServer) AddDirectory(grpc.ClientStreamingServer[AddDirectoryRequest, AddDirectoryResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method AddDirectory not implemented")
+}
+func (UnimplementedStorageServiceServer) ListDirectory(context.Context, *ListDirectoryRequest) (*ListDirectoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDirectory not implemented")
+}
+func (UnimplementedStorageServiceServer) StartUpload(context.Context, *StartUploadRequest) (*StartUploadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartUpload not implemented")
+}
+func (UnimplementedStorageServiceServer) Upload(grpc.ClientStreamingServer[UploadRequest, UploadResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Upload not implemented")
+}
+func (UnimplementedStorageServiceServer) GetUpload(context.Context, *GetUploadRequest) (*GetUploadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUpload not implemented")
+}
+func (UnimplementedStorageServiceServer) FinishUpload(context.Context, *FinishUploadRequest) (*AddFileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FinishUpload not implemented")
+}
+func (UnimplementedStorageServiceServer) StatFile(context.Context, *StatFileRequest) (*FileInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StatFile not implemented")
+}
+func (UnimplementedStorageServiceServer) ListChunks(*ListChunksRequest, grpc.ServerStreamingServer[ListChunksResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ListChunks not implemented")
+}
+func (UnimplementedStorageServiceServer) PublishName(context.Context, *PublishNameRequest) (*NameEntry, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PublishName not implemented")
+}
+func (UnimplementedStorageServiceServer) ResolveName(context.Context, *ResolveNameRequest) (*NameEntry, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResolveName not implemented")
+}
 func (UnimplementedStorageServiceServer) mustEmbedUnimplementedStorageServiceServer() {}
 func (UnimplementedStorageServiceServer) testEmbeddedByValue()                        {}
 
@@ -133,13 +390,281 @@ func _StorageService_GetFile_Handler(srv interface{}, stream grpc.ServerStream)
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type StorageService_GetFileServer = grpc.ServerStreamingServer[GetFileResponse]
 
+func _StorageService_Pin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).Pin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_Pin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).Pin(ctx, req.(*PinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_Unpin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnpinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).Unpin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_Unpin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).Unpin(ctx, req.(*UnpinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_ListPins_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPinsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).ListPins(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_ListPins_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).ListPins(ctx, req.(*ListPinsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_GC_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GCRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).GC(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_GC_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).GC(ctx, req.(*GCRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_AddDirectory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(StorageServiceServer).AddDirectory(&grpc.GenericServerStream[AddDirectoryRequest, AddDirectoryResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StorageService_AddDirectoryServer = grpc.ClientStreamingServer[AddDirectoryRequest, AddDirectoryResponse]
+
+func _StorageService_ListDirectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDirectoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).ListDirectory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_ListDirectory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).ListDirectory(ctx, req.(*ListDirectoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_StartUpload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartUploadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).StartUpload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_StartUpload_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).StartUpload(ctx, req.(*StartUploadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_Upload_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(StorageServiceServer).Upload(&grpc.GenericServerStream[UploadRequest, UploadResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StorageService_UploadServer = grpc.ClientStreamingServer[UploadRequest, UploadResponse]
+
+func _StorageService_GetUpload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUploadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).GetUpload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_GetUpload_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).GetUpload(ctx, req.(*GetUploadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_FinishUpload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FinishUploadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).FinishUpload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_FinishUpload_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).FinishUpload(ctx, req.(*FinishUploadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_StatFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).StatFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_StatFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).StatFile(ctx, req.(*StatFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_ListChunks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListChunksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StorageServiceServer).ListChunks(m, &grpc.GenericServerStream[ListChunksRequest, ListChunksResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StorageService_ListChunksServer = grpc.ServerStreamingServer[ListChunksResponse]
+
+func _StorageService_PublishName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PublishNameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).PublishName(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_PublishName_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).PublishName(ctx, req.(*PublishNameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageService_ResolveName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolveNameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServiceServer).ResolveName(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StorageService_ResolveName_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServiceServer).ResolveName(ctx, req.(*ResolveNameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // StorageService_ServiceDesc is the grpc.ServiceDesc for StorageService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var StorageService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "storage.v1.StorageService",
 	HandlerType: (*StorageServiceServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Pin",
+			Handler:    _StorageService_Pin_Handler,
+		},
+		{
+			MethodName: "Unpin",
+			Handler:    _StorageService_Unpin_Handler,
+		},
+		{
+			MethodName: "ListPins",
+			Handler:    _StorageService_ListPins_Handler,
+		},
+		{
+			MethodName: "GC",
+			Handler:    _StorageService_GC_Handler,
+		},
+		{
+			MethodName: "ListDirectory",
+			Handler:    _StorageService_ListDirectory_Handler,
+		},
+		{
+			MethodName: "StartUpload",
+			Handler:    _StorageService_StartUpload_Handler,
+		},
+		{
+			MethodName: "GetUpload",
+			Handler:    _StorageService_GetUpload_Handler,
+		},
+		{
+			MethodName: "FinishUpload",
+			Handler:    _StorageService_FinishUpload_Handler,
+		},
+		{
+			MethodName: "StatFile",
+			Handler:    _StorageService_StatFile_Handler,
+		},
+		{
+			MethodName: "PublishName",
+			Handler:    _StorageService_PublishName_Handler,
+		},
+		{
+			MethodName: "ResolveName",
+			Handler:    _StorageService_ResolveName_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "AddFile",
@@ -151,6 +676,21 @@ var StorageService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _StorageService_GetFile_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "AddDirectory",
+			Handler:       _StorageService_AddDirectory_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Upload",
+			Handler:       _StorageService_Upload_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ListChunks",
+			Handler:       _StorageService_ListChunks_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "api/v1/storage.proto",
 }
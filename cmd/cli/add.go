@@ -2,12 +2,15 @@ package main
 
 import (
 	"context"
-	"io"
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"time"
 
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/Yashh56/p2p-storage/api/v1"
 	"github.com/spf13/cobra"
@@ -15,8 +18,8 @@ import (
 )
 
 var addCmd = &cobra.Command{
-	Use:   "add [filePath]",
-	Short: "Adds a file to the P2P Network",
+	Use:   "add [path]",
+	Short: "Adds a file, or a directory with -r, to the P2P Network",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		filePath := args[0]
@@ -29,45 +32,122 @@ var addCmd = &cobra.Command{
 		defer conn.Close()
 		client := pb.NewStorageServiceClient(conn)
 
+		chunker, _ := cmd.Flags().GetString("chunker")
+		replication, _ := cmd.Flags().GetUint32("replication")
+		encryption, _ := cmd.Flags().GetString("encrypt")
+		cipher, _ := cmd.Flags().GetString("cipher")
+		contentType, _ := cmd.Flags().GetString("content-type")
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		erasure, _ := cmd.Flags().GetString("erasure")
+
+		if fi, err := os.Stat(filePath); err == nil && fi.IsDir() {
+			if !recursive {
+				log.Fatalf("%s is a directory; use -r to add it", filePath)
+			}
+			if encryption != "" || erasure != "" {
+				log.Fatalf("directories cannot be added with --encrypt or --erasure")
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute*10)
+			defer cancel()
+			root, err := addDirectory(ctx, client, filePath, &pb.AddDirectoryRequest{
+				Chunker:     chunker,
+				Replication: replication,
+			})
+			if err != nil {
+				log.Fatalf("failed to add directory: %v", err)
+			}
+			log.Printf("Directory added successfully! Root CID: %s", root)
+			return
+		}
+
 		file, err := os.Open(filePath)
 
 		if err != nil {
 			log.Fatalf("Failed to open file: %v", err)
 		}
 		defer file.Close()
-
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-		defer cancel()
-		stream, err := client.AddFile(ctx)
+		fi, err := file.Stat()
 		if err != nil {
-			log.Fatalf("failed to create stream: %v\n", err)
+			log.Fatalf("Failed to stat file: %v", err)
 		}
-		buf := make([]byte, 1024)
 
-		for {
-			n, err := file.Read(buf)
-			if err == io.EOF {
-				break
+		var dataShards, parityShards uint32
+		if erasure != "" {
+			if _, err := fmt.Sscanf(erasure, "%d+%d", &dataShards, &parityShards); err != nil {
+				log.Fatalf("Invalid --erasure %q, expected <data>+<parity> such as 4+2", erasure)
 			}
+		}
+		// Options are kept by the upload session, so a resumed upload
+		// ignores them.
+		uploadID, _ := cmd.Flags().GetString("resume")
+		if uploadID == "" {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+			res, err := client.StartUpload(ctx, &pb.StartUploadRequest{
+				Chunker:      chunker,
+				Replication:  replication,
+				DataShards:   dataShards,
+				ParityShards: parityShards,
+				Encryption:   encryption,
+				Cipher:       cipher,
+				Name:         filepath.Base(filePath),
+				ContentType:  contentType,
+			})
+			cancel()
 			if err != nil {
-				log.Fatalf("Failed to read chunk: %v", err)
+				log.Fatalf("failed to start upload: %v", err)
+			}
+			uploadID = res.GetUploadId()
+			log.Printf("Upload ID: %s", uploadID)
+		}
+
+		// An interrupted upload is resumed from what the node committed.
+		// Only attempts that make no progress count towards the limit.
+		var last uint64
+		for failures := 0; ; {
+			start, err := uploadFile(client, uploadID, file, uint64(fi.Size()))
+			if err == nil {
+				break
 			}
-			if err := stream.Send(&pb.AddFileRequest{
-				ChunkData: buf[:n],
-			}); err != nil {
-				log.Fatalf("Failed to send chunks: %v", err)
+			switch status.Code(err) {
+			case codes.NotFound, codes.FailedPrecondition, codes.InvalidArgument, codes.ResourceExhausted:
+				log.Fatalf("failed to upload: %v", err)
 			}
+			if start > last {
+				failures = 0
+			}
+			last = start
+			if failures++; failures > maxUploadRetries {
+				log.Fatalf("Upload interrupted: %v\nResume it with: add --resume %s %s", err, uploadID, filePath)
+			}
+			log.Printf("Upload interrupted (%v), retrying", err)
+			time.Sleep(time.Duration(failures) * time.Second)
 		}
-		res, err := stream.CloseAndRecv()
+
+		// Finishing chunks and announces the whole file.
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute*10)
+		defer cancel()
+		res, err := client.FinishUpload(ctx, &pb.FinishUploadRequest{UploadId: uploadID, Size: uint64(fi.Size())})
 		if err != nil {
-			log.Fatalf("failed to receive response: %v", err)
+			log.Fatalf("failed to finish upload: %v", err)
 		}
 
 		log.Printf("File added successfully! Root CID: %s", res.GetRootCid())
+		if res.GetKey() != "" {
+			log.Printf("Encryption key: %s", res.GetKey())
+			log.Println("Keep the key safe: the file cannot be read without it.")
+		}
 
 	},
 }
 
 func init() {
+	addCmd.Flags().String("chunker", "", `chunking strategy: "fixed", "cdc", "fixed-<size>" or "cdc-<min>-<avg>-<max>"`)
+	addCmd.Flags().Uint32("replication", 0, "number of nodes, including this one, that should keep a copy of the file")
+	addCmd.Flags().String("encrypt", "", `encrypt the file with a random per-file key ("file") or content-derived keys ("convergent")`)
+	addCmd.Flags().String("cipher", "", `cipher for --encrypt: "aes-256-gcm" (default) or "xchacha20-poly1305"`)
+	addCmd.Flags().String("content-type", "", "MIME type recorded for the file (default guessed from its name or content)")
+	addCmd.Flags().String("erasure", "", "erasure-code the file as <data>+<parity> shards per stripe, e.g. 4+2")
+	addCmd.Flags().BoolP("recursive", "r", false, "add a directory and everything below it")
+	addCmd.Flags().String("resume", "", "upload ID printed by an interrupted add, to continue it")
 	rootCmd.AddCommand(addCmd)
 }
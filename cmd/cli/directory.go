@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	pb "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/spf13/cobra"
+)
+
+var lsCmd = &cobra.Command{
+	Use:   "ls [cid]",
+	Short: "Lists the entries of a directory",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, conn := dial()
+		defer conn.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer cancel()
+		res, err := client.ListDirectory(ctx, &pb.ListDirectoryRequest{Cid: args[0]})
+		if err != nil {
+			log.Fatalf("failed to list directory: %v", err)
+		}
+		for _, e := range res.GetEntries() {
+			name, mode := e.GetName(), fs.FileMode(e.GetMode())
+			if e.GetDirectory() {
+				name, mode = name+"/", mode|fs.ModeDir
+			}
+			fmt.Printf("%s %s %10d %s %s\n", e.GetCid(), mode,
+				e.GetSize(), time.Unix(e.GetMtime(), 0).Format(time.RFC3339), name)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lsCmd)
+}
+
+// addDirectory uploads the tree rooted at root and returns its root CID.
+// Only regular files and directories are added.
+func addDirectory(ctx context.Context, client pb.StorageServiceClient, root string, first *pb.AddDirectoryRequest) (string, error) {
+	stream, err := client.AddDirectory(ctx)
+	if err != nil {
+		return "", err
+	}
+	first.Name = filepath.Base(root)
+	// Options are only read from the first message, which is sent even
+	// for an empty directory.
+	if err := stream.Send(first); err != nil {
+		return "", err
+	}
+	buf := make([]byte, 64*1024)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return err
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			log.Printf("Skipping %s: not a regular file", path)
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		req := &pb.AddDirectoryRequest{Entry: &pb.TreeEntry{
+			Path:      filepath.ToSlash(rel),
+			Directory: d.IsDir(),
+			Mode:      uint32(info.Mode().Perm()),
+			Mtime:     info.ModTime().Unix(),
+		}}
+		if d.IsDir() {
+			return stream.Send(req)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		for sent := false; ; sent = true {
+			n, err := f.Read(buf)
+			if err == io.EOF && sent {
+				return nil
+			}
+			if err != nil && err != io.EOF {
+				return err
+			}
+			req.ChunkData = buf[:n]
+			if err := stream.Send(req); err != nil {
+				return err
+			}
+			req = &pb.AddDirectoryRequest{}
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		return "", err
+	}
+	return res.GetRootCid(), nil
+}
+
+// getDirectory recreates the directory cid at path, resuming files an
+// interrupted get left behind. Modification times of directories are set
+// once their content is written.
+func getDirectory(client pb.StorageServiceClient, cid, path string, bar *progressBar) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	res, err := client.ListDirectory(ctx, &pb.ListDirectoryRequest{Cid: cid})
+	cancel()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return err
+	}
+	for _, e := range res.GetEntries() {
+		target := filepath.Join(path, e.GetName())
+		if e.GetDirectory() {
+			err = getDirectory(client, e.GetCid(), target, bar)
+		} else {
+			err = saveFile(client, e.GetCid(), target, bar)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", target, err)
+		}
+		if e.GetMode() != 0 {
+			if err := os.Chmod(target, fs.FileMode(e.GetMode())); err != nil {
+				return err
+			}
+		}
+		if e.GetMtime() != 0 {
+			mtime := time.Unix(e.GetMtime(), 0)
+			if err := os.Chtimes(target, mtime, mtime); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// saveFile downloads the file cid to path. A file saved read-only by an
+// earlier get is made writable again until it is checked.
+func saveFile(client pb.StorageServiceClient, cid, path string, bar *progressBar) error {
+	if fi, err := os.Stat(path); err == nil && fi.Mode().IsRegular() && fi.Mode().Perm()&0o200 == 0 {
+		if err := os.Chmod(path, fi.Mode().Perm()|0o200); err != nil {
+			return err
+		}
+	}
+	return downloadFile(client, cid, "", path, 0, 0, bar)
+}
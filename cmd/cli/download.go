@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	pb "github.com/Yashh56/p2p-storage/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// downloadIdleTimeout is how long a GetFile stream may go without a
+	// message before it is abandoned and retried.
+	downloadIdleTimeout = time.Minute
+	// maxDownloadRetries is how many attempts in a row may fail without
+	// progress before get gives up.
+	maxDownloadRetries = 5
+)
+
+// errDownloadInterrupted is returned by downloadFile when the transfer
+// keeps failing; running the same get again resumes it.
+var errDownloadInterrupted = errors.New("download interrupted")
+
+// downloadFile saves the file cid, from offset on and length bytes long, to
+// path. Whatever an interrupted get left there is kept as far as it matches
+// the file's chunks; a range is always retrieved afresh. An interrupted
+// stream is resumed from the last byte written; only attempts that make no
+// progress count towards maxDownloadRetries.
+func downloadFile(client pb.StorageServiceClient, cid, key, path string, offset, length uint64, bar *progressBar) error {
+	ranged := offset > 0 || length > 0
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var written int64
+	if fi, err := file.Stat(); err != nil {
+		return err
+	} else if fi.Size() > 0 && !ranged {
+		bar.finish()
+		if written, err = verifiedOffset(client, cid, key, file, fi.Size()); err != nil {
+			log.Printf("Could not verify all of %s: %v", path, err)
+		}
+		log.Printf("Resuming after %d verified bytes of %s", written, path)
+	}
+	if err := file.Truncate(written); err != nil {
+		return err
+	}
+	if _, err := file.Seek(written, io.SeekStart); err != nil {
+		return err
+	}
+	bar.skip(written)
+
+	for failures := 0; ; {
+		req := &pb.GetFileRequest{Cid: cid, Key: key, Offset: offset + uint64(written)}
+		if length > 0 {
+			req.Length = length - uint64(written)
+		}
+		n, err := downloadRange(client, req, file, bar)
+		written += n
+		if err == nil {
+			break
+		}
+		switch status.Code(err) {
+		case codes.InvalidArgument, codes.PermissionDenied, codes.OutOfRange, codes.FailedPrecondition, codes.NotFound:
+			return err
+		}
+		if n > 0 {
+			failures = 0
+		}
+		if failures++; failures > maxDownloadRetries {
+			return fmt.Errorf("%w: %w", errDownloadInterrupted, err)
+		}
+		bar.finish()
+		log.Printf("Download of %s interrupted (%v), retrying", path, err)
+		time.Sleep(time.Duration(failures) * time.Second)
+	}
+	return file.Close()
+}
+
+// verifiedOffset checks the first size bytes of f, left by an interrupted
+// get, against the chunks of the file and returns how many of them can be
+// kept: the content up to the first chunk that is incomplete, differs or
+// cannot be checked. Reading a large file back can take a while, so the
+// check is only abandoned without a message for downloadIdleTimeout. On
+// error, the content verified so far can still be kept.
+func verifiedOffset(client pb.StorageServiceClient, cid, key string, f *os.File, size int64) (int64, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	idle := time.AfterFunc(downloadIdleTimeout, cancel)
+	defer idle.Stop()
+
+	stream, err := client.ListChunks(ctx, &pb.ListChunksRequest{Cid: cid, Key: key, Length: uint64(size)})
+	if err != nil {
+		return 0, err
+	}
+	var buf []byte
+	var verified int64
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return verified, nil
+		}
+		if err != nil {
+			return verified, err
+		}
+		idle.Reset(downloadIdleTimeout)
+		for _, c := range res.GetChunks() {
+			offset := int64(c.GetOffset())
+			if c.GetSize() < 0 || offset+c.GetSize() > size || len(c.GetSha256()) != sha256.Size {
+				return verified, nil
+			}
+			if int64(cap(buf)) < c.GetSize() {
+				buf = make([]byte, c.GetSize())
+			}
+			buf = buf[:c.GetSize()]
+			if _, err := f.ReadAt(buf, offset); err != nil {
+				return verified, err
+			}
+			if sum := sha256.Sum256(buf); !bytes.Equal(sum[:], c.GetSha256()) {
+				return verified, nil
+			}
+			verified = offset + c.GetSize()
+		}
+	}
+}
+
+// downloadRange writes the file from req.Offset on to f, which is positioned
+// there, and returns the number of bytes written. Without a message for
+// downloadIdleTimeout the attempt is abandoned.
+func downloadRange(client pb.StorageServiceClient, req *pb.GetFileRequest, f *os.File, bar *progressBar) (int64, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	idle := time.AfterFunc(downloadIdleTimeout, cancel)
+	defer idle.Stop()
+
+	stream, err := client.GetFile(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	var written int64
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+		idle.Reset(downloadIdleTimeout)
+		n, err := f.Write(res.GetChunkData())
+		written += int64(n)
+		bar.add(n)
+		if err != nil {
+			return written, err
+		}
+	}
+}
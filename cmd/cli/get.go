@@ -3,71 +3,125 @@ package main
 
 import (
 	"context"
-	"io"
+	"errors"
 	"log"
-	"os"
+	"path/filepath"
 	"time"
 
 	pb "github.com/Yashh56/p2p-storage/api/v1"
 	"github.com/spf13/cobra"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 var getCmd = &cobra.Command{
-	Use:   "get [cid] [output_filepath]",
-	Short: "Retrieves a file from the P2P network using its CID",
-	Args:  cobra.ExactArgs(2),
+	Use:   "get [cid] [output_path]",
+	Short: "Retrieves a file or directory from the P2P network using its CID",
+	Long: "Retrieves a file or directory from the P2P network using its CID, or using a name\n" +
+		"published with \"name publish\" given as /name/<name>. Without an output path it is\n" +
+		"saved in the current directory under the name it was added with.",
+	Args: cobra.RangeArgs(1, 2),
 	Run: func(cmd *cobra.Command, args []string) {
 		cid := args[0]
-		outputFilepath := args[1]
-
-		// 1. Connect to the gRPC server.
-		conn, err := grpc.NewClient("localhost:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
-		if err != nil {
-			log.Fatalf("did not connect: %v", err)
+		var outputFilepath string
+		if len(args) > 1 {
+			outputFilepath = args[1]
 		}
-		defer conn.Close()
-		client := pb.NewStorageServiceClient(conn)
+		key, _ := cmd.Flags().GetString("key")
+		offset, _ := cmd.Flags().GetUint64("offset")
+		length, _ := cmd.Flags().GetUint64("length")
+		ranged := offset > 0 || length > 0
 
-		// 2. Create the output file.
-		file, err := os.Create(outputFilepath)
-		if err != nil {
-			log.Fatalf("failed to create output file: %v", err)
-		}
-		defer file.Close()
+		client, conn := dial()
+		defer conn.Close()
 
-		// 3. Call the GetFile RPC.
+		// 1. Look the file up, which also tells us its name.
 		ctx, cancel := context.WithTimeout(context.Background(), time.Minute*1) // 1 minute timeout
-		defer cancel()
-		stream, err := client.GetFile(ctx, &pb.GetFileRequest{Cid: cid})
+		info, err := client.StatFile(ctx, &pb.StatFileRequest{Cid: cid, Key: key})
+		cancel()
 		if err != nil {
-			log.Fatalf("failed to call GetFile: %v", err)
+			log.Fatalf("failed to get file info: %v", err)
 		}
-
-		// 4. Receive the file in a stream of chunks.
-		log.Println("Receiving file...")
-		for {
-			res, err := stream.Recv()
-			// io.EOF means the stream has finished successfully.
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				log.Fatalf("failed to receive chunk: %v", err)
+		// A name is only resolved once, so that the whole get reads the same
+		// version of the file.
+		if cid != info.GetCid() {
+			log.Printf("%s points to %s", cid, info.GetCid())
+			cid = info.GetCid()
+		}
+		if info.GetDirectory() {
+			if ranged {
+				log.Fatalf("%s is a directory; --offset and --length only apply to files", cid)
 			}
+			getTree(client, info, outputFilepath)
+			return
+		}
+		if info.GetEncrypted() && key == "" {
+			log.Fatalf("%s is encrypted; pass the key printed by add with --key", cid)
+		}
+		printFileInfo(info)
+		if outputFilepath == "" {
+			outputFilepath = outputName(info, cid)
+		}
 
-			// Write the received chunk to the output file.
-			if _, err := file.Write(res.GetChunkData()); err != nil {
-				log.Fatalf("failed to write chunk to file: %v", err)
+		// 2. Receive it, keeping whatever an interrupted get left in the
+		// output file.
+		total := info.GetSize()
+		if total >= 0 && ranged {
+			total = max(total-int64(offset), 0)
+			if length > 0 {
+				total = min(total, int64(length))
 			}
 		}
+		bar := newProgressBar(total, 0)
+		err = downloadFile(client, cid, key, outputFilepath, offset, length, bar)
+		bar.finish()
+		if errors.Is(err, errDownloadInterrupted) {
+			log.Fatalf("%v\nRun the same get again to resume it.", err)
+		} else if err != nil {
+			log.Fatalf("failed to receive file: %v", err)
+		}
 
 		log.Printf("File successfully retrieved and saved to: %s", outputFilepath)
 	},
 }
 
+// getTree saves the directory described by info, by default under the name
+// it was added with.
+func getTree(client pb.StorageServiceClient, info *pb.FileInfo, path string) {
+	if path == "" {
+		path = outputName(info, info.GetCid())
+	}
+	bar := newProgressBar(info.GetSize(), 0)
+	err := getDirectory(client, info.GetCid(), path, bar)
+	bar.finish()
+	if errors.Is(err, errDownloadInterrupted) {
+		log.Fatalf("failed to retrieve directory: %v\nRun the same get again to resume it.", err)
+	} else if err != nil {
+		log.Fatalf("failed to retrieve directory: %v", err)
+	}
+	log.Printf("Directory successfully retrieved and saved to: %s", path)
+}
+
+// outputName picks a file name in the current directory for a file saved
+// without an explicit output path.
+func outputName(info *pb.FileInfo, cid string) string {
+	name := filepath.Base(info.GetName())
+	if name == "." || name == ".." || name == "/" || name == "" {
+		return cid
+	}
+	return name
+}
+
+func printFileInfo(info *pb.FileInfo) {
+	if info.GetManifestVersion() == 0 {
+		return
+	}
+	log.Printf("File: %q, %d bytes, %s, added %s", info.GetName(), info.GetSize(),
+		info.GetContentType(), time.Unix(info.GetCreatedAt(), 0).Format(time.RFC3339))
+}
+
 // init registers the get command with the root command.
 func init() {
+	getCmd.Flags().String("key", "", "key printed when an encrypted file was added")
+	getCmd.Flags().Uint64("offset", 0, "first byte of the file to retrieve")
+	getCmd.Flags().Uint64("length", 0, "number of bytes to retrieve (default to the end of the file)")
 	rootCmd.AddCommand(getCmd)
 }
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/Yashh56/p2p-storage/internal/p2p"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/spf13/cobra"
+)
+
+var keyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Manages the node identity and named keys stored in the server's data directory",
+}
+
+var keyShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Prints the node's PeerID and key type",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+
+		priv, err := p2p.LoadIdentity(dataDir)
+		if err != nil {
+			log.Fatalf("Failed to load identity: %v", err)
+		}
+		printIdentity(dataDir, priv)
+	},
+}
+
+var keyRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Replaces the node identity with a newly generated key",
+	Long: "Replaces the node identity with a newly generated key. The server must be stopped first,\n" +
+		"and it will announce its content again under the new PeerID when it restarts.",
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		keyType, _ := cmd.Flags().GetString("type")
+
+		priv, err := p2p.GenerateIdentity(dataDir, keyType)
+		if err != nil {
+			log.Fatalf("Failed to rotate identity: %v", err)
+		}
+		printIdentity(dataDir, priv)
+	},
+}
+
+var keyGenCmd = &cobra.Command{
+	Use:   "gen [name]",
+	Short: "Creates a named key to publish names under",
+	Long: "Creates a named key to publish names under with \"name publish --key\". Its PeerID is\n" +
+		"the name. The key can be created while the server is running.",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		keyType, _ := cmd.Flags().GetString("type")
+
+		priv, err := p2p.GenerateNamedKey(dataDir, args[0], keyType)
+		if err != nil {
+			log.Fatalf("Failed to create key: %v", err)
+		}
+		id, err := peer.IDFromPrivateKey(priv)
+		if err != nil {
+			log.Fatalf("Failed to derive PeerID: %v", err)
+		}
+		fmt.Printf("%s\t%s\n", args[0], id)
+	},
+}
+
+var keyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the node identity and the named keys with their PeerIDs",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+
+		names, err := p2p.ListNamedKeys(dataDir)
+		if err != nil {
+			log.Fatalf("Failed to list keys: %v", err)
+		}
+		for _, name := range append([]string{p2p.SelfKey}, names...) {
+			priv, err := p2p.LoadNamedKey(dataDir, name)
+			if err != nil {
+				log.Fatalf("Failed to load key %s: %v", name, err)
+			}
+			id, err := peer.IDFromPrivateKey(priv)
+			if err != nil {
+				log.Fatalf("Failed to derive PeerID: %v", err)
+			}
+			fmt.Printf("%s\t%s\n", name, id)
+		}
+	},
+}
+
+func printIdentity(dataDir string, priv crypto.PrivKey) {
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		log.Fatalf("Failed to derive PeerID: %v", err)
+	}
+	fmt.Printf("PeerID:   %s\n", id)
+	fmt.Printf("Key type: %s\n", p2p.KeyTypeName(priv))
+	fmt.Printf("Key file: %s\n", filepath.Join(dataDir, p2p.IdentityFile))
+}
+
+func init() {
+	keyCmd.PersistentFlags().String("data-dir", ".", "server data directory")
+	keyRotateCmd.Flags().String("type", p2p.DefaultKeyType, "key type to generate (ed25519, secp256k1, ecdsa, rsa)")
+	keyGenCmd.Flags().String("type", p2p.DefaultKeyType, "key type to generate (ed25519, secp256k1, ecdsa, rsa)")
+	keyCmd.AddCommand(keyShowCmd, keyRotateCmd, keyGenCmd, keyListCmd)
+	rootCmd.AddCommand(keyCmd)
+}
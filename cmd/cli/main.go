@@ -3,9 +3,13 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"os"
 
+	pb "github.com/Yashh56/p2p-storage/api/v1"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 var rootCmd = &cobra.Command{
@@ -13,6 +17,16 @@ var rootCmd = &cobra.Command{
 	Short: "A CLI to interact with the P2P Storage Node",
 }
 
+// dial connects to the local node's gRPC API. The caller closes the returned
+// connection.
+func dial() (pb.StorageServiceClient, *grpc.ClientConn) {
+	conn, err := grpc.NewClient("localhost:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("did not connect: %v", err)
+	}
+	return pb.NewStorageServiceClient(conn), conn
+}
+
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	pb "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/spf13/cobra"
+)
+
+var nameCmd = &cobra.Command{
+	Use:   "name",
+	Short: "Publishes and resolves names that point to a CID",
+}
+
+var namePublishCmd = &cobra.Command{
+	Use:   "publish [cid]",
+	Short: "Points the name of a key at a CID",
+	Long: "Points the name of a key at a CID, replacing what it pointed to before. The name is the\n" +
+		"PeerID of the key: the node identity by default, or a key created with \"key gen\".\n" +
+		"Files can then be retrieved with \"get /name/<name>\".",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		key, _ := cmd.Flags().GetString("key")
+		lifetime, _ := cmd.Flags().GetDuration("lifetime")
+
+		client, conn := dial()
+		defer conn.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute*2)
+		defer cancel()
+		res, err := client.PublishName(ctx, &pb.PublishNameRequest{Key: key, Cid: args[0], Lifetime: int64(lifetime / time.Second)})
+		if err != nil {
+			log.Fatalf("failed to publish name: %v", err)
+		}
+		printNameEntry(res)
+	},
+}
+
+var nameResolveCmd = &cobra.Command{
+	Use:   "resolve [name]",
+	Short: "Prints the CID a name points to",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, conn := dial()
+		defer conn.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute*1)
+		defer cancel()
+		res, err := client.ResolveName(ctx, &pb.ResolveNameRequest{Name: args[0]})
+		if err != nil {
+			log.Fatalf("failed to resolve name: %v", err)
+		}
+		printNameEntry(res)
+	},
+}
+
+func printNameEntry(e *pb.NameEntry) {
+	fmt.Printf("Name:     /name/%s\n", e.GetName())
+	fmt.Printf("CID:      %s\n", e.GetCid())
+	fmt.Printf("Sequence: %d\n", e.GetSequence())
+	fmt.Printf("Expires:  %s\n", time.Unix(e.GetExpiresAt(), 0).Format(time.RFC3339))
+}
+
+func init() {
+	namePublishCmd.Flags().String("key", "self", "key to publish under: self for the node identity, or a key created with \"key gen\"")
+	namePublishCmd.Flags().Duration("lifetime", 48*time.Hour, "how long the record stays valid if the node stops republishing it")
+	nameCmd.AddCommand(namePublishCmd, nameResolveCmd)
+	rootCmd.AddCommand(nameCmd)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	pb "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/spf13/cobra"
+)
+
+var pinCmd = &cobra.Command{
+	Use:   "pin",
+	Short: "Manages the pins that protect content from garbage collection",
+}
+
+var pinAddCmd = &cobra.Command{
+	Use:   "add [cid]",
+	Short: "Pins a file, fetching it from the network if needed",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		direct, _ := cmd.Flags().GetBool("direct")
+		typ := pb.PinType_PIN_TYPE_RECURSIVE
+		if direct {
+			typ = pb.PinType_PIN_TYPE_DIRECT
+		}
+
+		client, conn := dial()
+		defer conn.Close()
+
+		// Pinning remote content downloads the whole file.
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute*10)
+		defer cancel()
+		if _, err := client.Pin(ctx, &pb.PinRequest{Cid: args[0], Type: typ}); err != nil {
+			log.Fatalf("failed to pin: %v", err)
+		}
+		log.Printf("Pinned %s", args[0])
+	},
+}
+
+var pinRmCmd = &cobra.Command{
+	Use:   "rm [cid]",
+	Short: "Removes a pin; the content is deleted by the next gc",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, conn := dial()
+		defer conn.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer cancel()
+		if _, err := client.Unpin(ctx, &pb.UnpinRequest{Cid: args[0]}); err != nil {
+			log.Fatalf("failed to unpin: %v", err)
+		}
+		log.Printf("Unpinned %s", args[0])
+	},
+}
+
+var pinLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "Lists pinned content",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		client, conn := dial()
+		defer conn.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer cancel()
+		res, err := client.ListPins(ctx, &pb.ListPinsRequest{})
+		if err != nil {
+			log.Fatalf("failed to list pins: %v", err)
+		}
+		for _, p := range res.GetPins() {
+			typ := "recursive"
+			if p.GetType() == pb.PinType_PIN_TYPE_DIRECT {
+				typ = "direct"
+			}
+			fmt.Printf("%s %s\n", p.GetCid(), typ)
+		}
+	},
+}
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Deletes every block that is not pinned",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		client, conn := dial()
+		defer conn.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute*10)
+		defer cancel()
+		res, err := client.GC(ctx, &pb.GCRequest{})
+		if err != nil {
+			log.Fatalf("failed to run gc: %v", err)
+		}
+		log.Printf("Removed %d blocks, freed %d bytes", res.GetRemovedBlocks(), res.GetFreedBytes())
+	},
+}
+
+func init() {
+	pinAddCmd.Flags().Bool("direct", false, "pin only the given block instead of the whole file")
+	pinCmd.AddCommand(pinAddCmd, pinRmCmd, pinLsCmd)
+	rootCmd.AddCommand(pinCmd, gcCmd)
+}
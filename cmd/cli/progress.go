@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// progressInterval is how often the progress bar is redrawn.
+	progressInterval = 200 * time.Millisecond
+	progressWidth    = 30
+)
+
+// progressBar draws the progress of a transfer on stderr, when stderr is a
+// terminal.
+type progressBar struct {
+	// total is -1 when the size is unknown.
+	total int64
+	done  int64
+	// base is what was done before the transfer started, which does not
+	// count towards the rate.
+	base    int64
+	started time.Time
+	drawn   time.Time
+	enabled bool
+}
+
+func newProgressBar(total, done int64) *progressBar {
+	fi, err := os.Stderr.Stat()
+	return &progressBar{
+		total:   total,
+		done:    done,
+		base:    done,
+		started: time.Now(),
+		enabled: err == nil && fi.Mode()&os.ModeCharDevice != 0,
+	}
+}
+
+// skip counts n bytes that were done before, without counting them
+// towards the rate.
+func (p *progressBar) skip(n int64) {
+	p.done += n
+	p.base += n
+}
+
+func (p *progressBar) add(n int) {
+	p.done += int64(n)
+	if time.Since(p.drawn) >= progressInterval {
+		p.draw()
+	}
+}
+
+func (p *progressBar) draw() {
+	if !p.enabled {
+		return
+	}
+	p.drawn = time.Now()
+	var rate float64
+	if elapsed := p.drawn.Sub(p.started).Seconds(); elapsed > 0 {
+		rate = float64(p.done-p.base) / elapsed
+	}
+	var line string
+	if p.total >= 0 {
+		frac := 1.0
+		if p.total > 0 {
+			frac = float64(p.done) / float64(p.total)
+		}
+		filled := int(frac * progressWidth)
+		if filled > progressWidth {
+			filled = progressWidth
+		}
+		eta := "--:--"
+		if rate > 0 {
+			eta = formatDuration(time.Duration(float64(p.total-p.done) / rate * float64(time.Second)))
+		}
+		line = fmt.Sprintf("[%s%s] %3.0f%% %s/%s %s/s ETA %s",
+			strings.Repeat("=", filled), strings.Repeat(" ", progressWidth-filled),
+			frac*100, formatBytes(p.done), formatBytes(p.total), formatBytes(int64(rate)), eta)
+	} else {
+		line = fmt.Sprintf("%s %s/s", formatBytes(p.done), formatBytes(int64(rate)))
+	}
+	fmt.Fprintf(os.Stderr, "\r\033[K%s", line)
+}
+
+// finish draws the final state and ends the line, so that log output does
+// not run into the bar. The bar may be drawn again afterwards.
+func (p *progressBar) finish() {
+	if !p.enabled {
+		return
+	}
+	p.draw()
+	fmt.Fprintln(os.Stderr)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
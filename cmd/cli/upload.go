@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	pb "github.com/Yashh56/p2p-storage/api/v1"
+)
+
+const (
+	// uploadMessageSize is the content sent per Upload message.
+	uploadMessageSize = 256 * 1024
+	// maxUploadRetries is how many attempts in a row may fail without
+	// progress before add gives up.
+	maxUploadRetries = 5
+)
+
+// uploadFile sends the part of f the node has not committed yet to an upload
+// session. It returns the offset the attempt started from.
+func uploadFile(client pb.StorageServiceClient, id string, f *os.File, size uint64) (uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	res, err := client.GetUpload(ctx, &pb.GetUploadRequest{UploadId: id})
+	cancel()
+	if err != nil {
+		return 0, err
+	}
+	start := res.GetOffset()
+	if start >= size {
+		return start, nil
+	}
+	if _, err := f.Seek(int64(start), io.SeekStart); err != nil {
+		return start, err
+	}
+
+	// The stream has no deadline: a large file may take long to send, and
+	// a broken connection is resumed anyway.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.Upload(ctx)
+	if err != nil {
+		return start, err
+	}
+	req := &pb.UploadRequest{UploadId: id, Offset: start}
+	buf := make([]byte, uploadMessageSize)
+	for {
+		n, err := f.Read(buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return start, err
+		}
+		req.ChunkData = buf[:n]
+		if err := stream.Send(req); err != nil {
+			// The real error is reported by CloseAndRecv.
+			break
+		}
+		req = &pb.UploadRequest{}
+	}
+	if _, err := stream.CloseAndRecv(); err != nil {
+		return start, err
+	}
+	return start, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Yashh56/p2p-storage/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspects the server configuration",
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Prints the effective configuration after applying the file, environment and flags",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			log.Fatalf("Invalid configuration: %v", err)
+		}
+		out, err := cfg.Marshal()
+		if err != nil {
+			log.Fatalf("Failed to render configuration: %v", err)
+		}
+		fmt.Print(string(out))
+	},
+}
+
+// loadConfig builds the configuration from, in increasing order of
+// precedence, the defaults, the config file, the environment and the flags
+// that were set explicitly, and validates the result.
+func loadConfig(cmd *cobra.Command) (config.Config, error) {
+	flags := cmd.Flags()
+
+	path, _ := flags.GetString("config")
+	if path == "" {
+		path = os.Getenv(config.EnvPrefix + "CONFIG")
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := cfg.ApplyEnv(os.Getenv); err != nil {
+		return cfg, err
+	}
+
+	if flags.Changed("data-dir") {
+		cfg.DataDir, _ = flags.GetString("data-dir")
+	}
+	if flags.Changed("api-addr") {
+		cfg.API.Addr, _ = flags.GetString("api-addr")
+	}
+	if flags.Changed("gateway-addr") {
+		cfg.Gateway.Addr, _ = flags.GetString("gateway-addr")
+	}
+	if flags.Changed("s3-addr") {
+		cfg.S3.Addr, _ = flags.GetString("s3-addr")
+	}
+	if flags.Changed("listen") {
+		cfg.P2P.ListenAddrs, _ = flags.GetStringSlice("listen")
+	}
+	if flags.Changed("key-type") {
+		cfg.P2P.KeyType, _ = flags.GetString("key-type")
+	}
+	if flags.Changed("bootstrap") {
+		cfg.P2P.Bootstrap.Peers, _ = flags.GetStringSlice("bootstrap")
+	}
+	if flags.Changed("bootstrap-file") {
+		cfg.P2P.Bootstrap.File, _ = flags.GetString("bootstrap-file")
+	}
+	if flags.Changed("bootstrap-min-peers") {
+		cfg.P2P.Bootstrap.MinPeers, _ = flags.GetInt("bootstrap-min-peers")
+	}
+	if flags.Changed("mdns") {
+		cfg.P2P.MDNS.Enabled, _ = flags.GetBool("mdns")
+	}
+	if flags.Changed("mdns-tag") {
+		cfg.P2P.MDNS.ServiceTag, _ = flags.GetString("mdns-tag")
+	}
+	if flags.Changed("replication-interval") {
+		d, _ := flags.GetDuration("replication-interval")
+		cfg.Replication.Interval = config.Duration(d)
+	}
+	if flags.Changed("accept-replicas") {
+		cfg.Replication.Accept, _ = flags.GetBool("accept-replicas")
+	}
+	if flags.Changed("max-size") {
+		v, _ := flags.GetString("max-size")
+		size, err := config.ParseByteSize(v)
+		if err != nil {
+			return cfg, fmt.Errorf("--max-size: %w", err)
+		}
+		cfg.Storage.MaxSize = size
+	}
+	if flags.Changed("evict-cached") {
+		cfg.Storage.EvictCached, _ = flags.GetBool("evict-cached")
+	}
+
+	if err := cfg.ResolveBootstrapFile(); err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Validate()
+}
+
+func init() {
+	// Defaults are shown in the config file format by `config show`; the
+	// flags only override settings that are passed explicitly.
+	f := rootCmd.PersistentFlags()
+	f.String("config", "", "YAML config file (env "+config.EnvPrefix+"CONFIG)")
+	f.String("data-dir", "", "directory holding the block store and node identity (default \".\")")
+	f.String("api-addr", "", "gRPC API listen address (default \":50051\")")
+	f.String("gateway-addr", "", "HTTP gateway listen address, e.g. :8080 (default off)")
+	f.String("s3-addr", "", "S3-compatible API listen address, e.g. :9000 (default off)")
+	f.StringSlice("listen", nil, "libp2p listen multiaddrs (default port 4001 over TCP and QUIC)")
+	f.String("key-type", "", "key type generated for a new node identity: ed25519, secp256k1, ecdsa, rsa")
+	f.StringSlice("bootstrap", nil, "bootstrap peer multiaddrs")
+	f.String("bootstrap-file", "", "file listing bootstrap multiaddrs, one per line (default <data-dir>/bootstrap.txt)")
+	f.Int("bootstrap-min-peers", 0, "re-dial bootstrap peers when the routing table has fewer peers than this")
+	f.Bool("mdns", false, "discover and connect to other nodes on the local network")
+	f.String("mdns-tag", "", "mDNS service tag; only nodes sharing a tag find each other")
+	f.Duration("replication-interval", 0, "how often replica counts of replicated files are checked (default 10m)")
+	f.Bool("accept-replicas", false, "store replicas pushed by other nodes")
+	f.String("max-size", "", "maximum size of the stored blocks, e.g. 10GiB (default unlimited)")
+	f.Bool("evict-cached", false, "evict least recently used blocks fetched from peers to stay under --max-size")
+
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -6,25 +6,82 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
+	"time"
 
 	pb "github.com/Yashh56/p2p-storage/api/v1"
 	"github.com/Yashh56/p2p-storage/internal/api"
+	"github.com/Yashh56/p2p-storage/internal/config"
+	"github.com/Yashh56/p2p-storage/internal/gateway"
 	"github.com/Yashh56/p2p-storage/internal/node"
+	"github.com/Yashh56/p2p-storage/internal/p2p"
 	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
 )
 
+var rootCmd = &cobra.Command{
+	Use:   "p2p-storage-server",
+	Short: "Runs a P2P storage node",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			log.Fatalf("Invalid configuration: %v", err)
+		}
+		run(cfg)
+	},
+}
+
 func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func run(cfg config.Config) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	store, err := storage.NewBlockStore("./db")
+	store, err := storage.NewBlockStore(filepath.Join(cfg.DataDir, "db"),
+		storage.WithMaxSize(int64(cfg.Storage.MaxSize)),
+		storage.WithEviction(cfg.Storage.EvictCached))
 	if err != nil {
 		log.Fatalf("Failed to create blockstore: %v", err)
 	}
-	n, err := node.NewNode(ctx, store)
+	if cfg.Storage.MaxSize > 0 {
+		log.Printf("Block store holds %d bytes of its %s limit", store.Size(), cfg.Storage.MaxSize)
+	} else {
+		log.Printf("Block store holds %d bytes", store.Size())
+	}
+	privKey, err := p2p.LoadOrCreateIdentity(cfg.DataDir, cfg.P2P.KeyType)
+	if err != nil {
+		log.Fatalf("Failed to load node identity: %v", err)
+	}
+	n, err := node.NewNode(ctx, store, node.Config{
+		Host: p2p.HostConfig{
+			PrivKey:     privKey,
+			ListenAddrs: cfg.P2P.ListenAddrs,
+		},
+		Bootstrap: p2p.BootstrapConfig{
+			Peers:    cfg.P2P.Bootstrap.Peers,
+			MinPeers: cfg.P2P.Bootstrap.MinPeers,
+			Interval: time.Duration(cfg.P2P.Bootstrap.Interval),
+		},
+		MDNS: p2p.MDNSConfig{
+			Enabled:    cfg.P2P.MDNS.Enabled,
+			ServiceTag: cfg.P2P.MDNS.ServiceTag,
+		},
+		Replication: node.ReplicationConfig{
+			Interval: time.Duration(cfg.Replication.Interval),
+			Accept:   cfg.Replication.Accept,
+		},
+		DataDir: cfg.DataDir,
+	})
 	if err != nil {
 		log.Fatalf("Failed to create P2P node: %v", err)
 	}
@@ -32,6 +89,9 @@ func main() {
 
 	fmt.Printf("Node is online with PeerId: %s\n", n.Host.ID())
 	fmt.Println("Listen addresses:", n.Host.Addrs())
+	for _, addr := range n.Host.Addrs() {
+		fmt.Printf("Bootstrap address: %s/p2p/%s\n", addr, n.Host.ID())
+	}
 
 	go func() {
 		apiServer := api.NewServer(n)
@@ -40,18 +100,48 @@ func main() {
 
 		pb.RegisterStorageServiceServer(grpcServer, apiServer)
 
-		lis, err := net.Listen("tcp", ":50051")
+		lis, err := net.Listen("tcp", cfg.API.Addr)
 
 		if err != nil {
 			log.Fatalf("Failed to listen on gRPC port: %s\n", err)
 		}
-		log.Println("gRPC server listening on :50051")
+		log.Printf("gRPC server listening on %s", cfg.API.Addr)
 
 		if err := grpcServer.Serve(lis); err != nil {
 			log.Printf("gRPC server shut down: %v", err)
 		}
 	}()
 
+	if cfg.Gateway.Addr != "" {
+		go func() {
+			lis, err := net.Listen("tcp", cfg.Gateway.Addr)
+			if err != nil {
+				log.Fatalf("Failed to listen on gateway port: %s\n", err)
+			}
+			log.Printf("HTTP gateway listening on %s", cfg.Gateway.Addr)
+
+			srv := &http.Server{Handler: gateway.NewServer(n), ReadHeaderTimeout: 10 * time.Second}
+			if err := srv.Serve(lis); err != nil {
+				log.Printf("HTTP gateway shut down: %v", err)
+			}
+		}()
+	}
+
+	if cfg.S3.Addr != "" {
+		go func() {
+			lis, err := net.Listen("tcp", cfg.S3.Addr)
+			if err != nil {
+				log.Fatalf("Failed to listen on S3 port: %s\n", err)
+			}
+			log.Printf("S3 API listening on %s", cfg.S3.Addr)
+
+			srv := &http.Server{Handler: gateway.NewS3Server(n), ReadHeaderTimeout: 10 * time.Second}
+			if err := srv.Serve(lis); err != nil {
+				log.Printf("S3 API shut down: %v", err)
+			}
+		}()
+	}
+
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
 	<-sig
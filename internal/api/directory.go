@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/file"
+	"github.com/Yashh56/p2p-storage/internal/node"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) AddDirectory(stream api.StorageService_AddDirectoryServer) error {
+	log.Println("Received AddDirectory Request")
+
+	first, err := stream.Recv()
+	done := err == io.EOF
+	if err != nil && !done {
+		return err
+	}
+	chunker, err := file.ParseParams(first.GetChunker())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	opts := node.AddOptions{
+		Chunker:     chunker,
+		Replication: int(first.GetReplication()),
+		Name:        first.GetName(),
+	}
+	if err := node.ValidateName(opts.Name); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	t := &treeStream{stream: stream, pending: first, eof: done, fileDone: true}
+	result, err := s.node.AddDirectory(stream.Context(), t.next, opts)
+	if t.err != nil {
+		return t.err
+	}
+	if errors.Is(err, storage.ErrQuotaExceeded) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if errors.Is(err, node.ErrInvalidTree) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(&api.AddDirectoryResponse{RootCid: result.Root.String()})
+}
+
+// treeStream turns an AddDirectory stream into tree entries. The content of
+// a file is read from the stream as the node consumes it.
+type treeStream struct {
+	stream api.StorageService_AddDirectoryServer
+	// pending is the message starting the next entry, if already received.
+	pending *api.AddDirectoryRequest
+	eof     bool
+	// data is what is left of the current file's content in the last
+	// message; fileDone is set once the file has been read to its end.
+	data     []byte
+	fileDone bool
+	// err is set if the client sent a malformed stream.
+	err error
+}
+
+func (t *treeStream) next() (node.TreeEntry, error) {
+	// Skip whatever the node left unread of the previous file.
+	for !t.fileDone {
+		if _, err := t.Read(make([]byte, 64*1024)); err != nil && err != io.EOF {
+			return node.TreeEntry{}, err
+		}
+	}
+	req := t.pending
+	t.pending = nil
+	if req == nil {
+		if t.eof {
+			return node.TreeEntry{}, io.EOF
+		}
+		var err error
+		if req, err = t.stream.Recv(); err == io.EOF {
+			return node.TreeEntry{}, io.EOF
+		} else if err != nil {
+			return node.TreeEntry{}, err
+		}
+	}
+	e := req.GetEntry()
+	if e == nil {
+		if len(req.GetChunkData()) == 0 {
+			// The first message may only carry options.
+			return t.next()
+		}
+		return node.TreeEntry{}, t.fail("file data sent outside of a file")
+	}
+	if e.GetDirectory() && len(req.GetChunkData()) > 0 {
+		return node.TreeEntry{}, t.fail(e.GetPath() + ": file data sent for a directory")
+	}
+
+	entry := node.TreeEntry{
+		Path: e.GetPath(),
+		Dir:  e.GetDirectory(),
+		Mode: os.FileMode(e.GetMode()).Perm(),
+	}
+	if e.GetMtime() != 0 {
+		entry.ModTime = time.Unix(e.GetMtime(), 0)
+	}
+	if !entry.Dir {
+		t.data, t.fileDone = req.GetChunkData(), false
+		entry.Content = t
+	}
+	return entry, nil
+}
+
+// Read reads the content of the current file.
+func (t *treeStream) Read(p []byte) (int, error) {
+	for len(t.data) == 0 {
+		if t.fileDone {
+			return 0, io.EOF
+		}
+		req, err := t.stream.Recv()
+		if err == io.EOF {
+			t.eof, t.fileDone = true, true
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if req.GetEntry() != nil {
+			t.pending, t.fileDone = req, true
+			continue
+		}
+		t.data = req.GetChunkData()
+	}
+	n := copy(p, t.data)
+	t.data = t.data[n:]
+	return n, nil
+}
+
+func (t *treeStream) fail(msg string) error {
+	t.err = status.Error(codes.InvalidArgument, msg)
+	return t.err
+}
+
+func (s *Server) ListDirectory(ctx context.Context, req *api.ListDirectoryRequest) (*api.ListDirectoryResponse, error) {
+	log.Printf("Received ListDirectory request for CID: %s", req.GetCid())
+	c, err := cid.Decode(req.GetCid())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid CID: %v", err)
+	}
+	info, entries, err := s.node.ListDirectory(ctx, c)
+	if errors.Is(err, node.ErrNotDirectory) {
+		return nil, status.Errorf(codes.FailedPrecondition, "%s is not a directory", c)
+	} else if err != nil {
+		return nil, err
+	}
+	res := &api.ListDirectoryResponse{
+		Info:    fileInfoToAPI(info),
+		Entries: make([]*api.DirectoryEntry, len(entries)),
+	}
+	for i, e := range entries {
+		res.Entries[i] = &api.DirectoryEntry{
+			Name:      e.Name,
+			Cid:       e.Cid.String(),
+			Directory: e.Dir,
+			Mode:      uint32(e.Mode),
+			Size:      uint64(e.Size),
+		}
+		if !e.ModTime.IsZero() {
+			res.Entries[i].Mtime = e.ModTime.Unix()
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/node"
+	"github.com/ipfs/go-cid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) PublishName(ctx context.Context, req *api.PublishNameRequest) (*api.NameEntry, error) {
+	log.Printf("Received PublishName request for key %q: %s", req.GetKey(), req.GetCid())
+	c, err := cid.Decode(req.GetCid())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid CID: %v", err)
+	}
+	if req.GetLifetime() < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "negative lifetime %d", req.GetLifetime())
+	}
+	e, err := s.node.PublishName(ctx, req.GetKey(), c, time.Duration(req.GetLifetime())*time.Second)
+	if errors.Is(err, node.ErrNoNameKey) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if errors.Is(err, node.ErrNamePublish) {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	} else if err != nil {
+		return nil, err
+	}
+	return nameEntryToAPI(e), nil
+}
+
+func (s *Server) ResolveName(ctx context.Context, req *api.ResolveNameRequest) (*api.NameEntry, error) {
+	log.Printf("Received ResolveName request for %s", req.GetName())
+	e, err := s.node.ResolveName(ctx, req.GetName())
+	if errors.Is(err, node.ErrNameNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if err != nil {
+		return nil, err
+	}
+	return nameEntryToAPI(e), nil
+}
+
+func nameEntryToAPI(e node.NameEntry) *api.NameEntry {
+	return &api.NameEntry{
+		Name:      e.Name.String(),
+		Cid:       e.Root.String(),
+		Sequence:  e.Sequence,
+		ExpiresAt: e.Expires.Unix(),
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) Pin(ctx context.Context, req *api.PinRequest) (*api.PinResponse, error) {
+	log.Printf("Received Pin request for CID: %s", req.GetCid())
+	c, err := cid.Decode(req.GetCid())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid CID: %v", err)
+	}
+	mode := storage.PinRecursive
+	switch req.GetType() {
+	case api.PinType_PIN_TYPE_UNSPECIFIED, api.PinType_PIN_TYPE_RECURSIVE:
+	case api.PinType_PIN_TYPE_DIRECT:
+		mode = storage.PinDirect
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown pin type %v", req.GetType())
+	}
+	if err := s.node.Pin(ctx, c, mode); errors.Is(err, storage.ErrQuotaExceeded) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	} else if err != nil {
+		return nil, err
+	}
+	return &api.PinResponse{}, nil
+}
+
+func (s *Server) Unpin(ctx context.Context, req *api.UnpinRequest) (*api.UnpinResponse, error) {
+	log.Printf("Received Unpin request for CID: %s", req.GetCid())
+	c, err := cid.Decode(req.GetCid())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid CID: %v", err)
+	}
+	if err := s.node.Unpin(c); errors.Is(err, storage.ErrNotPinned) {
+		return nil, status.Errorf(codes.NotFound, "%s is not pinned", c)
+	} else if err != nil {
+		return nil, err
+	}
+	return &api.UnpinResponse{}, nil
+}
+
+func (s *Server) ListPins(ctx context.Context, req *api.ListPinsRequest) (*api.ListPinsResponse, error) {
+	pins, err := s.node.ListPins()
+	if err != nil {
+		return nil, err
+	}
+	res := &api.ListPinsResponse{Pins: make([]*api.PinInfo, len(pins))}
+	for i, p := range pins {
+		typ := api.PinType_PIN_TYPE_RECURSIVE
+		if p.Mode == storage.PinDirect {
+			typ = api.PinType_PIN_TYPE_DIRECT
+		}
+		res.Pins[i] = &api.PinInfo{Cid: p.Cid.String(), Type: typ}
+	}
+	return res, nil
+}
+
+func (s *Server) GC(ctx context.Context, req *api.GCRequest) (*api.GCResponse, error) {
+	log.Println("Received GC request")
+	result, err := s.node.GC(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &api.GCResponse{
+		RemovedBlocks: int64(result.RemovedBlocks),
+		FreedBytes:    result.FreedBytes,
+	}, nil
+}
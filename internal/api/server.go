@@ -1,11 +1,18 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"io"
 	"log"
+	"math"
 
 	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/file"
 	"github.com/Yashh56/p2p-storage/internal/node"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Server struct {
@@ -22,17 +29,35 @@ func NewServer(node *node.Node) *Server {
 func (s *Server) AddFile(stream api.StorageService_AddFileServer) error {
 	log.Println("Received AddFile Request")
 
+	// The first message carries the upload options alongside the first chunk.
+	// An empty stream is an empty file.
+	first, err := stream.Recv()
+	done := err == io.EOF
+	if err != nil && !done {
+		return err
+	}
+	opts, err := addOptions(first)
+	if err != nil {
+		return err
+	}
+
 	pr, pw := io.Pipe()
+	defer pr.Close()
 
 	go func() {
 		defer pw.Close()
-		for {
+		if _, err := pw.Write(first.GetChunkData()); err != nil {
+			log.Printf("Error Writing to Pipe: %v", err)
+			return
+		}
+		for !done {
 			req, err := stream.Recv()
 			if err == io.EOF {
 				break
 			}
 			if err != nil {
 				log.Printf("Error receiving from stream: %v", err)
+				pw.CloseWithError(err)
 				return
 			}
 
@@ -43,24 +68,99 @@ func (s *Server) AddFile(stream api.StorageService_AddFileServer) error {
 		}
 	}()
 
-	rootCID, err := s.node.AddFile(stream.Context(), pr)
+	result, err := s.node.AddFile(stream.Context(), pr, opts)
+	if errors.Is(err, storage.ErrQuotaExceeded) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if errors.Is(err, node.ErrErasureTooLarge) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
 	if err != nil {
 		return err
 	}
-	return stream.SendAndClose(&api.AddFileResponse{
-		RootCid: rootCID.String(),
-	})
+	res := &api.AddFileResponse{RootCid: result.Root.String()}
+	if result.Key != nil {
+		res.Key = storage.EncodeKey(result.Key)
+	}
+	return stream.SendAndClose(res)
+}
+
+// addOptionsRequest is a request carrying the options of a new file.
+type addOptionsRequest interface {
+	GetChunker() string
+	GetReplication() uint32
+	GetDataShards() uint32
+	GetParityShards() uint32
+	GetEncryption() string
+	GetCipher() string
+	GetName() string
+	GetContentType() string
+}
+
+// addOptions reads and validates the options of a new file.
+func addOptions(req addOptionsRequest) (node.AddOptions, error) {
+	chunker, err := file.ParseParams(req.GetChunker())
+	if err != nil {
+		return node.AddOptions{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	opts := node.AddOptions{
+		Chunker:     chunker,
+		Replication: int(req.GetReplication()),
+		Erasure: node.ErasureParams{
+			DataShards:   int(req.GetDataShards()),
+			ParityShards: int(req.GetParityShards()),
+		},
+		Encryption: node.EncryptOptions{
+			Mode:   node.KeyMode(req.GetEncryption()),
+			Cipher: storage.Cipher(req.GetCipher()),
+		},
+		Name:        req.GetName(),
+		ContentType: req.GetContentType(),
+	}
+	if err := node.ValidateName(opts.Name); err != nil {
+		return opts, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := opts.Encryption.Validate(); err != nil {
+		return opts, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if opts.Erasure.Enabled() {
+		if err := opts.Erasure.Validate(); err != nil {
+			return opts, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if err := opts.Erasure.ValidateChunker(opts.Chunker); err != nil {
+			return opts, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if opts.Replication > 1 {
+			return opts, status.Error(codes.InvalidArgument, "replication and erasure coding cannot be combined")
+		}
+	}
+	return opts, nil
 }
 
 func (s *Server) GetFile(req *api.GetFileRequest, stream api.StorageService_GetFileServer) error {
 	log.Printf("Received GetFile request for CID: %s", req.GetCid())
-	reader, err := s.node.GetFile(stream.Context(), req.GetCid())
+	if req.GetOffset() > math.MaxInt64 || req.GetLength() > math.MaxInt64 {
+		return status.Error(codes.OutOfRange, "range is too large")
+	}
+	opts := node.GetOptions{Offset: int64(req.GetOffset()), Length: int64(req.GetLength())}
+	if req.GetKey() != "" {
+		key, err := storage.DecodeKey(req.GetKey())
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		opts.Key = key
+	}
+	reader, info, err := s.node.GetFile(stream.Context(), req.GetCid(), opts)
 	if err != nil {
-		return err
+		return getError(err, req.GetCid())
 	}
+	defer reader.Close()
 
 	buf := make([]byte, 1024*64)
 
+	// The file info rides along with the first chunk, or alone for an
+	// empty file.
+	res := &api.GetFileResponse{Info: fileInfoToAPI(info)}
 	for {
 		n, err := reader.Read(buf)
 		if err == io.EOF {
@@ -72,12 +172,122 @@ func (s *Server) GetFile(req *api.GetFileRequest, stream api.StorageService_GetF
 		}
 
 		// Send the chunk to the client via the stream.
-		if err := stream.Send(&api.GetFileResponse{ChunkData: buf[:n]}); err != nil {
+		res.ChunkData = buf[:n]
+		if err := stream.Send(res); err != nil {
 			log.Printf("Error sending data to stream: %v", err)
 			return err
 		}
+		res.Info = nil
+	}
+	if res.Info != nil {
+		if err := stream.Send(res); err != nil {
+			return err
+		}
 	}
 
 	log.Println("Finished streaming file.")
 	return nil
 }
+
+func (s *Server) StatFile(ctx context.Context, req *api.StatFileRequest) (*api.FileInfo, error) {
+	log.Printf("Received StatFile request for CID: %s", req.GetCid())
+	var opts node.GetOptions
+	if req.GetKey() != "" {
+		key, err := storage.DecodeKey(req.GetKey())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		opts.Key = key
+	}
+	info, err := s.node.StatFile(ctx, req.GetCid(), opts)
+	if err != nil {
+		return nil, getError(err, req.GetCid())
+	}
+	return fileInfoToAPI(info), nil
+}
+
+// listChunksBatch is the number of chunks sent per ListChunks message.
+const listChunksBatch = 1000
+
+func (s *Server) ListChunks(req *api.ListChunksRequest, stream api.StorageService_ListChunksServer) error {
+	log.Printf("Received ListChunks request for CID: %s", req.GetCid())
+	if req.GetOffset() > math.MaxInt64 || req.GetLength() > math.MaxInt64 {
+		return status.Error(codes.OutOfRange, "range is too large")
+	}
+	opts := node.GetOptions{Offset: int64(req.GetOffset()), Length: int64(req.GetLength())}
+	if req.GetKey() != "" {
+		key, err := storage.DecodeKey(req.GetKey())
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		opts.Key = key
+	}
+
+	info, chunks, err := s.node.FileChunks(stream.Context(), req.GetCid(), opts)
+	if err != nil {
+		return getError(err, req.GetCid())
+	}
+	// The file info rides along with the first batch, or alone for an
+	// empty file.
+	res := &api.ListChunksResponse{Info: fileInfoToAPI(info)}
+	for {
+		c, err := chunks(stream.Context())
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return getError(err, req.GetCid())
+		}
+		res.Chunks = append(res.Chunks, &api.ChunkInfo{
+			Offset: uint64(c.Offset),
+			Size:   c.Size,
+			Cid:    c.Cid.String(),
+			Sha256: c.Digest,
+		})
+		if len(res.Chunks) == listChunksBatch {
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+			res = &api.ListChunksResponse{}
+		}
+	}
+	if res.Info != nil || len(res.Chunks) > 0 {
+		return stream.Send(res)
+	}
+	return nil
+}
+
+// getError maps the errors of reading a file to status codes.
+func getError(err error, cid string) error {
+	switch {
+	case errors.Is(err, node.ErrKeyRequired):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, storage.ErrDecrypt):
+		return status.Error(codes.PermissionDenied, "wrong key for this file")
+	case errors.Is(err, node.ErrInvalidRange):
+		return status.Error(codes.OutOfRange, err.Error())
+	case errors.Is(err, node.ErrIsDirectory):
+		return status.Errorf(codes.FailedPrecondition, "%s is a directory", cid)
+	case errors.Is(err, node.ErrNameNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return err
+}
+
+func fileInfoToAPI(info node.FileInfo) *api.FileInfo {
+	res := &api.FileInfo{
+		Cid:             info.Root.String(),
+		ManifestVersion: uint32(info.Version),
+		Size:            info.Size,
+		Chunks:          uint32(info.Chunks),
+		Encrypted:       info.Encrypted,
+		Directory:       info.Directory,
+		Name:            info.Name,
+		ContentType:     info.ContentType,
+		Chunker:         info.Chunker,
+	}
+	if !info.Created.IsZero() {
+		res.CreatedAt = info.Created.Unix()
+	}
+	return res
+}
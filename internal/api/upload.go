@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/node"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// uploadPartSize is how much content Upload collects before committing it,
+// so that small messages do not each become a block.
+const uploadPartSize = 1024 * 1024
+
+func (s *Server) StartUpload(ctx context.Context, req *api.StartUploadRequest) (*api.StartUploadResponse, error) {
+	log.Println("Received StartUpload request")
+	opts, err := addOptions(req)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.node.StartUpload(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &api.StartUploadResponse{UploadId: id}, nil
+}
+
+func (s *Server) Upload(stream api.StorageService_UploadServer) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return status.Error(codes.InvalidArgument, "missing upload ID")
+	}
+	if err != nil {
+		return err
+	}
+	id := first.GetUploadId()
+	log.Printf("Received Upload request for session %s at offset %d", id, first.GetOffset())
+	if first.GetOffset() > math.MaxInt64 {
+		return status.Error(codes.InvalidArgument, "offset is too large")
+	}
+	committed, err := s.node.UploadOffset(id)
+	if err != nil {
+		return uploadError(err)
+	}
+
+	// Content is committed in parts of uploadPartSize, and whatever was
+	// received is committed when the stream breaks, so the client can
+	// resume from the last byte that reached the node.
+	offset := int64(first.GetOffset())
+	buf := append([]byte(nil), first.GetChunkData()...)
+	commit := func() error {
+		if len(buf) == 0 {
+			return nil
+		}
+		var err error
+		if committed, err = s.node.WriteUpload(id, offset, buf); err != nil {
+			return uploadError(err)
+		}
+		offset += int64(len(buf))
+		buf = nil
+		return nil
+	}
+	for {
+		if len(buf) >= uploadPartSize {
+			if err := commit(); err != nil {
+				return err
+			}
+		}
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if cerr := commit(); cerr != nil {
+				log.Printf("Error committing upload %s: %v", id, cerr)
+			}
+			return err
+		}
+		buf = append(buf, req.GetChunkData()...)
+	}
+	if err := commit(); err != nil {
+		return err
+	}
+	return stream.SendAndClose(&api.UploadResponse{Offset: uint64(committed)})
+}
+
+func (s *Server) GetUpload(ctx context.Context, req *api.GetUploadRequest) (*api.GetUploadResponse, error) {
+	offset, err := s.node.UploadOffset(req.GetUploadId())
+	if err != nil {
+		return nil, uploadError(err)
+	}
+	return &api.GetUploadResponse{Offset: uint64(offset)}, nil
+}
+
+func (s *Server) FinishUpload(ctx context.Context, req *api.FinishUploadRequest) (*api.AddFileResponse, error) {
+	log.Printf("Received FinishUpload request for session %s", req.GetUploadId())
+	if req.GetSize() > math.MaxInt64 {
+		return nil, status.Error(codes.InvalidArgument, "size is too large")
+	}
+	result, err := s.node.FinishUpload(ctx, req.GetUploadId(), int64(req.GetSize()))
+	if err != nil {
+		return nil, uploadError(err)
+	}
+	res := &api.AddFileResponse{RootCid: result.Root.String()}
+	if result.Key != nil {
+		res.Key = storage.EncodeKey(result.Key)
+	}
+	return res, nil
+}
+
+// uploadError maps the errors of upload sessions to status codes.
+func uploadError(err error) error {
+	switch {
+	case errors.Is(err, node.ErrUploadNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, node.ErrUploadOffset), errors.Is(err, node.ErrUploadIncomplete):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, storage.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, node.ErrErasureTooLarge):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,377 @@
+// Package config defines the server configuration: defaults, the YAML
+// config file, environment overrides and validation.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Yashh56/p2p-storage/internal/p2p"
+	"github.com/multiformats/go-multiaddr"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// EnvPrefix is prepended to the names of all configuration environment variables.
+	EnvPrefix = "P2P_STORAGE_"
+	// DefaultReplicationInterval is how often replica counts are checked.
+	DefaultReplicationInterval = 10 * time.Minute
+)
+
+// Config is the complete server configuration.
+type Config struct {
+	// DataDir holds the block store, the node identity and the default
+	// bootstrap file.
+	DataDir     string      `yaml:"data_dir"`
+	API         API         `yaml:"api"`
+	Gateway     Gateway     `yaml:"gateway"`
+	S3          S3          `yaml:"s3"`
+	P2P         P2P         `yaml:"p2p"`
+	Storage     Storage     `yaml:"storage"`
+	Replication Replication `yaml:"replication"`
+}
+
+// API configures the gRPC API server.
+type API struct {
+	Addr string `yaml:"addr"`
+}
+
+// Gateway configures the HTTP gateway serving stored content.
+type Gateway struct {
+	// Addr is the HTTP listen address; the gateway is off when it is empty.
+	Addr string `yaml:"addr"`
+}
+
+// S3 configures the S3-compatible object API.
+type S3 struct {
+	// Addr is the HTTP listen address; the API is off when it is empty.
+	Addr string `yaml:"addr"`
+}
+
+// P2P configures the libp2p host and how it joins the network.
+type P2P struct {
+	ListenAddrs []string  `yaml:"listen_addrs"`
+	KeyType     string    `yaml:"key_type"`
+	Bootstrap   Bootstrap `yaml:"bootstrap"`
+	MDNS        MDNS      `yaml:"mdns"`
+}
+
+// Bootstrap lists the peers dialled to join the network.
+type Bootstrap struct {
+	Peers []string `yaml:"peers"`
+	// File lists additional bootstrap multiaddrs, one per line. It is only
+	// read when Peers is empty, and defaults to bootstrap.txt in DataDir.
+	File     string   `yaml:"file,omitempty"`
+	MinPeers int      `yaml:"min_peers"`
+	Interval Duration `yaml:"interval"`
+}
+
+// MDNS configures local network discovery.
+type MDNS struct {
+	Enabled    bool   `yaml:"enabled"`
+	ServiceTag string `yaml:"service_tag"`
+}
+
+// Storage limits how much the block store may hold.
+type Storage struct {
+	// MaxSize is the maximum total size of stored blocks; 0 means unlimited.
+	MaxSize ByteSize `yaml:"max_size"`
+	// EvictCached lets the node delete the least recently used blocks it
+	// fetched from other peers, unless they are pinned, to stay under MaxSize.
+	EvictCached bool `yaml:"evict_cached"`
+}
+
+// Replication configures how files added with a replication factor are
+// kept replicated.
+type Replication struct {
+	// Interval is how often replica counts are checked and repaired.
+	Interval Duration `yaml:"interval"`
+	// Accept lets other nodes push replicas to this node, which pins them.
+	// It is off by default, since any peer could then use the node's disk.
+	Accept bool `yaml:"accept"`
+}
+
+// Duration is a time.Duration written as a string such as "1m30s".
+type Duration time.Duration
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	v, err := time.ParseDuration(value.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", value.Line, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// ByteSize is a size in bytes written with an optional unit, such as "512MiB"
+// or "10GB".
+type ByteSize int64
+
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"TiB", 1 << 40}, {"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10},
+	{"TB", 1e12}, {"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3},
+	{"B", 1},
+}
+
+// ParseByteSize parses a size such as "1024", "512MiB" or "10GB".
+func ParseByteSize(s string) (ByteSize, error) {
+	num, mult := strings.TrimSpace(s), int64(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(strings.ToUpper(num), strings.ToUpper(u.suffix)) {
+			num, mult = strings.TrimSpace(num[:len(num)-len(u.suffix)]), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	if n > math.MaxInt64/mult {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return ByteSize(n * mult), nil
+}
+
+func (b ByteSize) String() string {
+	for _, u := range byteUnits[:4] {
+		if b != 0 && int64(b)%u.size == 0 {
+			return strconv.FormatInt(int64(b)/u.size, 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(int64(b), 10)
+}
+
+func (b ByteSize) MarshalYAML() (interface{}, error) {
+	return b.String(), nil
+}
+
+func (b *ByteSize) UnmarshalYAML(value *yaml.Node) error {
+	v, err := ParseByteSize(value.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", value.Line, err)
+	}
+	*b = v
+	return nil
+}
+
+// Default returns the configuration used when nothing else is specified.
+// The P2P port matches the 4001 exposed by the Dockerfile.
+func Default() Config {
+	return Config{
+		DataDir: ".",
+		API:     API{Addr: ":50051"},
+		P2P: P2P{
+			ListenAddrs: []string{
+				"/ip4/0.0.0.0/tcp/4001",
+				"/ip4/0.0.0.0/udp/4001/quic-v1",
+			},
+			KeyType: p2p.DefaultKeyType,
+			Bootstrap: Bootstrap{
+				MinPeers: p2p.DefaultBootstrapMinPeers,
+				Interval: Duration(p2p.DefaultBootstrapInterval),
+			},
+			MDNS: MDNS{ServiceTag: p2p.DefaultMDNSServiceTag},
+		},
+		Replication: Replication{
+			Interval: Duration(DefaultReplicationInterval),
+			Accept:   false,
+		},
+	}
+}
+
+// Load returns the defaults overlaid with the YAML file at path. Unknown
+// keys are rejected so that typos do not go unnoticed. An empty path
+// returns the defaults.
+func Load(path string) (Config, error) {
+	cfg := Default()
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return cfg, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ApplyEnv overrides settings from P2P_STORAGE_* environment variables.
+// List values are comma-separated.
+func (c *Config) ApplyEnv(getenv func(string) string) error {
+	str := func(name string, dst *string) {
+		if v := getenv(EnvPrefix + name); v != "" {
+			*dst = v
+		}
+	}
+	list := func(name string, dst *[]string) {
+		if v := getenv(EnvPrefix + name); v != "" {
+			*dst = SplitList(v)
+		}
+	}
+
+	str("DATA_DIR", &c.DataDir)
+	str("API_ADDR", &c.API.Addr)
+	str("GATEWAY_ADDR", &c.Gateway.Addr)
+	str("S3_ADDR", &c.S3.Addr)
+	list("LISTEN_ADDRS", &c.P2P.ListenAddrs)
+	str("KEY_TYPE", &c.P2P.KeyType)
+	list("BOOTSTRAP", &c.P2P.Bootstrap.Peers)
+	str("BOOTSTRAP_FILE", &c.P2P.Bootstrap.File)
+	str("MDNS_TAG", &c.P2P.MDNS.ServiceTag)
+
+	if v := getenv(EnvPrefix + "REPLICATION_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("%sREPLICATION_INTERVAL: %w", EnvPrefix, err)
+		}
+		c.Replication.Interval = Duration(d)
+	}
+	if v := getenv(EnvPrefix + "ACCEPT_REPLICAS"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%sACCEPT_REPLICAS: %w", EnvPrefix, err)
+		}
+		c.Replication.Accept = b
+	}
+	if v := getenv(EnvPrefix + "MAX_SIZE"); v != "" {
+		size, err := ParseByteSize(v)
+		if err != nil {
+			return fmt.Errorf("%sMAX_SIZE: %w", EnvPrefix, err)
+		}
+		c.Storage.MaxSize = size
+	}
+	if v := getenv(EnvPrefix + "EVICT_CACHED"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%sEVICT_CACHED: %w", EnvPrefix, err)
+		}
+		c.Storage.EvictCached = b
+	}
+
+	if v := getenv(EnvPrefix + "BOOTSTRAP_MIN_PEERS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%sBOOTSTRAP_MIN_PEERS: %w", EnvPrefix, err)
+		}
+		c.P2P.Bootstrap.MinPeers = n
+	}
+	if v := getenv(EnvPrefix + "MDNS"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%sMDNS: %w", EnvPrefix, err)
+		}
+		c.P2P.MDNS.Enabled = b
+	}
+	return nil
+}
+
+// ResolveBootstrapFile fills Bootstrap.Peers from the bootstrap file when
+// no peers were configured directly. A missing default file is not an error.
+func (c *Config) ResolveBootstrapFile() error {
+	if len(c.P2P.Bootstrap.Peers) > 0 {
+		return nil
+	}
+	file := c.P2P.Bootstrap.File
+	explicit := file != ""
+	if !explicit {
+		file = filepath.Join(c.DataDir, "bootstrap.txt")
+	}
+
+	peers, err := p2p.LoadBootstrapFile(file)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.P2P.Bootstrap.Peers = peers
+	return nil
+}
+
+// Validate checks that every setting is usable before the node starts.
+func (c Config) Validate() error {
+	var errs []error
+	if c.DataDir == "" {
+		errs = append(errs, errors.New("data_dir must not be empty"))
+	}
+	if _, _, err := net.SplitHostPort(c.API.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("api.addr: %w", err))
+	}
+	if c.Gateway.Addr != "" {
+		if _, _, err := net.SplitHostPort(c.Gateway.Addr); err != nil {
+			errs = append(errs, fmt.Errorf("gateway.addr: %w", err))
+		}
+	}
+	if c.S3.Addr != "" {
+		if _, _, err := net.SplitHostPort(c.S3.Addr); err != nil {
+			errs = append(errs, fmt.Errorf("s3.addr: %w", err))
+		}
+	}
+	if len(c.P2P.ListenAddrs) == 0 {
+		errs = append(errs, errors.New("p2p.listen_addrs must not be empty"))
+	}
+	for _, a := range c.P2P.ListenAddrs {
+		if _, err := multiaddr.NewMultiaddr(a); err != nil {
+			errs = append(errs, fmt.Errorf("p2p.listen_addrs: %q: %w", a, err))
+		}
+	}
+	if err := p2p.ValidateKeyType(c.P2P.KeyType); err != nil {
+		errs = append(errs, fmt.Errorf("p2p.key_type: %w", err))
+	}
+	if _, err := p2p.ParseBootstrapPeers(c.P2P.Bootstrap.Peers); err != nil {
+		errs = append(errs, fmt.Errorf("p2p.bootstrap.peers: %w", err))
+	}
+	if c.P2P.Bootstrap.MinPeers < 0 {
+		errs = append(errs, errors.New("p2p.bootstrap.min_peers must not be negative"))
+	}
+	if c.P2P.Bootstrap.Interval <= 0 {
+		errs = append(errs, errors.New("p2p.bootstrap.interval must be positive"))
+	}
+	if c.P2P.MDNS.Enabled && c.P2P.MDNS.ServiceTag == "" {
+		errs = append(errs, errors.New("p2p.mdns.service_tag must not be empty when mDNS is enabled"))
+	}
+	if c.Replication.Interval <= 0 {
+		errs = append(errs, errors.New("replication.interval must be positive"))
+	}
+	if c.Storage.EvictCached && c.Storage.MaxSize == 0 {
+		errs = append(errs, errors.New("storage.evict_cached requires storage.max_size"))
+	}
+	return errors.Join(errs...)
+}
+
+// Marshal renders the configuration as YAML, in the same format Load reads.
+func (c Config) Marshal() ([]byte, error) {
+	return yaml.Marshal(c)
+}
+
+// SplitList splits a comma-separated list, dropping empty items.
+func SplitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_FileOverridesDefaults(t *testing.T) {
+	path := writeConfig(t, `
+data_dir: /var/lib/p2p-storage
+api:
+  addr: 127.0.0.1:6000
+p2p:
+  bootstrap:
+    interval: 30s
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DataDir != "/var/lib/p2p-storage" || cfg.API.Addr != "127.0.0.1:6000" {
+		t.Fatalf("file values not applied: %+v", cfg)
+	}
+	if time.Duration(cfg.P2P.Bootstrap.Interval) != 30*time.Second {
+		t.Fatalf("expected 30s interval, got %v", time.Duration(cfg.P2P.Bootstrap.Interval))
+	}
+	if cfg.P2P.KeyType != Default().P2P.KeyType {
+		t.Fatal("unset values should keep their defaults")
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The rendered config must load back to the same settings.
+	out, err := cfg.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := Load(writeConfig(t, string(out)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.API.Addr != cfg.API.Addr || again.P2P.Bootstrap.Interval != cfg.P2P.Bootstrap.Interval {
+		t.Fatal("marshalled config did not round-trip")
+	}
+}
+
+func TestLoad_RejectsUnknownKeys(t *testing.T) {
+	if _, err := Load(writeConfig(t, "api:\n  adress: :6000\n")); err == nil {
+		t.Fatal("expected an error for a misspelt key")
+	}
+}
+
+func TestApplyEnvAndValidate(t *testing.T) {
+	cfg := Default()
+	env := map[string]string{
+		"P2P_STORAGE_API_ADDR":     "not-an-address",
+		"P2P_STORAGE_LISTEN_ADDRS": "/ip4/0.0.0.0/tcp/5001, /ip4/0.0.0.0/udp/5001/quic-v1",
+		"P2P_STORAGE_MDNS":         "true",
+		"P2P_STORAGE_GATEWAY_ADDR": "127.0.0.1:8080",
+		"P2P_STORAGE_S3_ADDR":      "9000",
+	}
+	if err := cfg.ApplyEnv(func(k string) string { return env[k] }); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.P2P.ListenAddrs) != 2 || !cfg.P2P.MDNS.Enabled || cfg.Gateway.Addr != "127.0.0.1:8080" {
+		t.Fatalf("environment not applied: %+v", cfg.P2P)
+	}
+
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "api.addr") || !strings.Contains(err.Error(), "s3.addr") {
+		t.Fatalf("expected api.addr and s3.addr validation errors, got %v", err)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	for in, want := range map[string]ByteSize{
+		"0":      0,
+		"1024":   1024,
+		"512MiB": 512 << 20,
+		"10GB":   10e9,
+		"2 kib":  2048,
+	} {
+		got, err := ParseByteSize(in)
+		if err != nil || got != want {
+			t.Errorf("ParseByteSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "-1", "1PB", "12XB"} {
+		if _, err := ParseByteSize(in); err == nil {
+			t.Errorf("ParseByteSize(%q) succeeded", in)
+		}
+	}
+	if s := ByteSize(3 << 30).String(); s != "3GiB" {
+		t.Errorf("String() = %q", s)
+	}
+}
@@ -1,21 +1,232 @@
 package file
 
-import "io"
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"strconv"
+	"strings"
+)
 
 const chunkSize = 1024 * 1024
 
-func Chunk(r io.Reader) ([][]byte, error) {
-	var chunks [][]byte
-	buf := make([]byte, chunkSize)
-	for {
-		n, err := r.Read(buf)
-		if err != nil && err != io.EOF {
+// maxChunkSize caps the size of any single chunk so a bad parameter set
+// cannot make the chunker buffer arbitrarily large amounts of memory.
+const maxChunkSize = 16 * 1024 * 1024
+
+// Strategy selects how input is split into chunks.
+type Strategy string
+
+const (
+	// FixedSize splits input into equally sized pieces.
+	FixedSize Strategy = "fixed"
+	// ContentDefined places chunk boundaries with a FastCDC rolling hash, so
+	// an insertion or deletion only changes the chunks around the edit.
+	ContentDefined Strategy = "cdc"
+)
+
+// Params describes a chunking strategy and its size parameters.
+// Size is used by FixedSize; MinSize, AvgSize and MaxSize by ContentDefined.
+type Params struct {
+	Strategy Strategy
+	Size     int
+	MinSize  int
+	AvgSize  int
+	MaxSize  int
+}
+
+// DefaultParams are the fixed 1 MiB chunks used when nothing else is asked for.
+var DefaultParams = Params{Strategy: FixedSize, Size: chunkSize}
+
+// DefaultCDCParams are sensible content-defined parameters averaging 1 MiB.
+var DefaultCDCParams = Params{
+	Strategy: ContentDefined,
+	MinSize:  256 * 1024,
+	AvgSize:  chunkSize,
+	MaxSize:  4 * chunkSize,
+}
+
+// ParseParams parses a chunker spec of the form "fixed-<size>" or
+// "cdc-<min>-<avg>-<max>". The bare names "fixed" and "cdc" select the
+// defaults, and an empty spec selects DefaultParams.
+func ParseParams(spec string) (Params, error) {
+	switch spec {
+	case "":
+		return DefaultParams, nil
+	case string(FixedSize):
+		return DefaultParams, nil
+	case string(ContentDefined):
+		return DefaultCDCParams, nil
+	}
+
+	parts := strings.Split(spec, "-")
+	sizes := make([]int, len(parts)-1)
+	for i, s := range parts[1:] {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return Params{}, fmt.Errorf("invalid chunker spec %q: %w", spec, err)
+		}
+		sizes[i] = v
+	}
+
+	var p Params
+	switch {
+	case parts[0] == string(FixedSize) && len(sizes) == 1:
+		p = Params{Strategy: FixedSize, Size: sizes[0]}
+	case parts[0] == string(ContentDefined) && len(sizes) == 3:
+		p = Params{Strategy: ContentDefined, MinSize: sizes[0], AvgSize: sizes[1], MaxSize: sizes[2]}
+	default:
+		return Params{}, fmt.Errorf("invalid chunker spec %q", spec)
+	}
+	return p, p.Validate()
+}
+
+// String returns the spec form of p accepted by ParseParams.
+func (p Params) String() string {
+	if p.Strategy == ContentDefined {
+		return fmt.Sprintf("%s-%d-%d-%d", p.Strategy, p.MinSize, p.AvgSize, p.MaxSize)
+	}
+	return fmt.Sprintf("%s-%d", p.Strategy, p.Size)
+}
+
+// ChunkLimit returns the size of the largest chunk p produces.
+func (p Params) ChunkLimit() int {
+	if p.Strategy == ContentDefined {
+		return p.MaxSize
+	}
+	return p.Size
+}
+
+// Validate reports whether p describes a usable chunker.
+func (p Params) Validate() error {
+	switch p.Strategy {
+	case FixedSize:
+		if p.Size <= 0 || p.Size > maxChunkSize {
+			return fmt.Errorf("fixed chunk size must be between 1 and %d bytes", maxChunkSize)
+		}
+	case ContentDefined:
+		if p.MinSize <= 0 || p.MinSize > p.AvgSize || p.AvgSize > p.MaxSize {
+			return errors.New("content-defined chunk sizes must satisfy 0 < min <= avg <= max")
+		}
+		if p.MaxSize > maxChunkSize {
+			return fmt.Errorf("maximum chunk size must not exceed %d bytes", maxChunkSize)
+		}
+		if p.AvgSize < 64 {
+			return errors.New("average chunk size must be at least 64 bytes")
+		}
+	default:
+		return fmt.Errorf("unknown chunking strategy %q", p.Strategy)
+	}
+	return nil
+}
+
+// Chunker splits a stream into chunks one at a time, holding at most one
+// maximum-sized chunk in memory regardless of the length of the input.
+type Chunker struct {
+	r    io.Reader
+	p    Params
+	buf  []byte
+	cut  int // length of the chunk returned by the previous call to Next
+	n    int // number of buffered bytes, including the previous chunk
+	done bool
+}
+
+// NewChunker returns a Chunker that splits r according to p.
+func NewChunker(r io.Reader, p Params) (*Chunker, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	bufSize := p.Size
+	if p.Strategy == ContentDefined {
+		bufSize = p.MaxSize
+	}
+	return &Chunker{r: r, p: p, buf: make([]byte, bufSize)}, nil
+}
+
+// Next returns the next chunk, or io.EOF once the input is exhausted. The
+// returned slice is only valid until the following call to Next.
+func (c *Chunker) Next() ([]byte, error) {
+	c.n = copy(c.buf, c.buf[c.cut:c.n])
+	c.cut = 0
+
+	if !c.done {
+		m, err := io.ReadFull(c.r, c.buf[c.n:])
+		c.n += m
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			c.done = true
+		} else if err != nil {
 			return nil, err
 		}
-		if n == 0 {
-			break
+	}
+	if c.n == 0 {
+		return nil, io.EOF
+	}
+
+	c.cut = c.n
+	if c.p.Strategy == ContentDefined {
+		c.cut = c.p.cutPoint(c.buf[:c.n])
+	}
+	return c.buf[:c.cut], nil
+}
+
+// cutPoint returns the length of the next content-defined chunk at the start
+// of data, which must hold at most MaxSize bytes. It uses FastCDC's normalized
+// chunking: a stricter mask below AvgSize and a looser one above it keeps
+// chunk sizes clustered around the average.
+func (p Params) cutPoint(data []byte) int {
+	n := len(data)
+	if n <= p.MinSize {
+		return n
+	}
+
+	avgBits := bits.Len(uint(p.AvgSize)) - 1
+	maskS := highBitsMask(avgBits + 2)
+	maskL := highBitsMask(avgBits - 2)
+
+	avg := p.AvgSize
+	if avg > n {
+		avg = n
+	}
+
+	var fp uint64
+	i := p.MinSize
+	for ; i < avg; i++ {
+		fp = (fp << 1) + gear[data[i]]
+		if fp&maskS == 0 {
+			return i + 1
 		}
-		chunks = append(chunks, append([]byte(nil), buf[:n]...))
 	}
-	return chunks, nil
+	for ; i < n; i++ {
+		fp = (fp << 1) + gear[data[i]]
+		if fp&maskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
+
+// highBitsMask returns a mask of the top n bits of a uint64. The gear hash
+// shifts left, so its high bits depend on the widest window of input bytes.
+func highBitsMask(n int) uint64 {
+	if n <= 0 {
+		return 0
+	}
+	return ^uint64(0) << (64 - n)
 }
+
+// gear is the FastCDC lookup table. It is generated from a fixed seed so that
+// every node computes the same chunk boundaries for the same content.
+var gear = func() [256]uint64 {
+	var t [256]uint64
+	state := uint64(0x5032502d53544f52) // "P2P-STOR"
+	for i := range t {
+		// splitmix64
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		t[i] = z ^ (z >> 31)
+	}
+	return t
+}()
@@ -0,0 +1,88 @@
+package file
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func chunkAll(t *testing.T, r io.Reader, p Params) [][]byte {
+	t.Helper()
+	c, err := NewChunker(r, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chunks [][]byte
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			return chunks
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunks = append(chunks, append([]byte(nil), chunk...))
+	}
+}
+
+func TestChunker_FixedSize(t *testing.T) {
+	data := make([]byte, 2500)
+	chunks := chunkAll(t, bytes.NewReader(data), Params{Strategy: FixedSize, Size: 1000})
+	if len(chunks) != 3 || len(chunks[0]) != 1000 || len(chunks[2]) != 500 {
+		t.Fatalf("unexpected chunk layout: %d chunks", len(chunks))
+	}
+}
+
+func TestChunker_ContentDefinedSharesChunksAfterInsert(t *testing.T) {
+	p := Params{Strategy: ContentDefined, MinSize: 2 * 1024, AvgSize: 8 * 1024, MaxSize: 32 * 1024}
+
+	data := make([]byte, 1024*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+	edited := append([]byte{0x42}, data...)
+
+	original := chunkAll(t, bytes.NewReader(data), p)
+	shifted := chunkAll(t, bytes.NewReader(edited), p)
+
+	var joined []byte
+	for i, c := range original {
+		if len(c) > p.MaxSize || (len(c) < p.MinSize && i != len(original)-1) {
+			t.Fatalf("chunk %d has size %d outside [%d, %d]", i, len(c), p.MinSize, p.MaxSize)
+		}
+		joined = append(joined, c...)
+	}
+	if !bytes.Equal(joined, data) {
+		t.Fatal("chunks do not reassemble to the input")
+	}
+
+	seen := make(map[string]bool)
+	for _, c := range original {
+		seen[string(c)] = true
+	}
+	shared := 0
+	for _, c := range shifted {
+		if seen[string(c)] {
+			shared++
+		}
+	}
+	if shared < len(original)-2 {
+		t.Fatalf("expected all but the first chunk to be shared, got %d of %d", shared, len(original))
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	for _, spec := range []string{"fixed-4096", "cdc-1024-4096-16384"} {
+		p, err := ParseParams(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.String() != spec {
+			t.Fatalf("expected %s, got %s", spec, p)
+		}
+	}
+	for _, spec := range []string{"cdc-10-5-20", "rabin-1-2-3", "fixed-x"} {
+		if _, err := ParseParams(spec); err == nil {
+			t.Fatalf("expected error for %q", spec)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+// Package gateway serves stored content over HTTP, so that browsers and
+// tools such as curl can read it without a gRPC client.
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/Yashh56/p2p-storage/internal/node"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+)
+
+// lookupTimeout bounds how long a request may wait for a block or a
+// manifest to be found before the response starts.
+const lookupTimeout = 2 * time.Minute
+
+// Server serves GET and HEAD requests for
+//
+//	/blocks/{cid}  the raw block
+//	/files/{cid}   the content of a file, with ?key= for an encrypted one
+//
+// Both honour Range and conditional requests, with the CID as the ETag.
+type Server struct {
+	node *node.Node
+	mux  *http.ServeMux
+}
+
+func NewServer(n *node.Node) *Server {
+	s := &Server{node: n, mux: http.NewServeMux()}
+	// GET patterns match HEAD requests too.
+	s.mux.HandleFunc("GET /blocks/{cid}", s.getBlock)
+	s.mux.HandleFunc("GET /files/{cid}", s.getFile)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) getBlock(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Gateway %s request for block %s", r.Method, r.PathValue("cid"))
+	c, err := cid.Decode(r.PathValue("cid"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), lookupTimeout)
+	defer cancel()
+	data, err := s.node.GetBlock(ctx, c)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	setContentHeaders(w, r.PathValue("cid"), "application/octet-stream", false)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+func (s *Server) getFile(w http.ResponseWriter, r *http.Request) {
+	cidStr := r.PathValue("cid")
+	log.Printf("Gateway %s request for file %s", r.Method, cidStr)
+	if _, err := cid.Decode(cidStr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var opts node.GetOptions
+	if k := r.URL.Query().Get("key"); k != "" {
+		key, err := storage.DecodeKey(k)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts.Key = key
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), lookupTimeout)
+	info, err := s.node.StatFile(ctx, cidStr, opts)
+	cancel()
+	if err == nil && info.Encrypted && opts.Key == nil {
+		err = node.ErrKeyRequired
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if info.Directory {
+		http.Error(w, cidStr+" is a directory", http.StatusBadRequest)
+		return
+	}
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	setContentHeaders(w, cidStr, contentType, opts.Key != nil)
+	if info.Name != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": info.Name}))
+	}
+
+	if info.Size < 0 {
+		// Version 0 manifests do not record sizes, so the file can only be
+		// streamed whole.
+		w.Header().Set("Accept-Ranges", "none")
+		if r.Header.Get("If-None-Match") == w.Header().Get("Etag") {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if r.Method == http.MethodHead {
+			return
+		}
+		rc, _, err := s.node.GetFile(r.Context(), cidStr, opts)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		defer rc.Close()
+		if _, err := io.Copy(w, rc); err != nil {
+			log.Printf("Gateway error streaming %s: %v", cidStr, err)
+		}
+		return
+	}
+
+	f := &fileReader{size: info.Size, open: func(offset int64) (io.ReadCloser, error) {
+		rc, _, err := s.node.GetFile(r.Context(), cidStr, node.GetOptions{Key: opts.Key, Offset: offset})
+		return rc, err
+	}}
+	defer f.Close()
+	http.ServeContent(w, r, "", info.Created, f)
+}
+
+// setContentHeaders sets the headers shared by blocks and files. Content
+// under a CID never changes, so it may be cached for good, unless it was
+// read with a key: the key is in the URL, and shared caches must not serve
+// the decrypted content to anyone else.
+func setContentHeaders(w http.ResponseWriter, c, contentType string, keyed bool) {
+	h := w.Header()
+	h.Set("Etag", fmt.Sprintf("%q", c))
+	h.Set("Content-Type", contentType)
+	if keyed {
+		h.Set("Cache-Control", "private, no-store")
+	} else {
+		h.Set("Cache-Control", "public, max-age=31536000, immutable")
+	}
+	h.Set("X-Content-Type-Options", "nosniff")
+}
+
+// writeError maps the errors of looking content up to HTTP status codes.
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusBadGateway
+	switch {
+	case errors.Is(err, node.ErrKeyRequired):
+		code = http.StatusBadRequest
+	case errors.Is(err, storage.ErrDecrypt):
+		code = http.StatusForbidden
+		err = errors.New("wrong key for this file")
+	case errors.Is(err, context.DeadlineExceeded):
+		code = http.StatusGatewayTimeout
+	}
+	http.Error(w, err.Error(), code)
+}
+
+// fileReader presents a file as the io.ReadSeeker http.ServeContent needs.
+// Seeking only records the position; the next read opens the file there,
+// so that only the chunks covering the requested ranges are fetched.
+type fileReader struct {
+	open func(offset int64) (io.ReadCloser, error)
+	size int64
+	pos  int64
+	r    io.ReadCloser
+}
+
+func (f *fileReader) Read(p []byte) (int, error) {
+	if f.r == nil {
+		if f.pos >= f.size {
+			return 0, io.EOF
+		}
+		r, err := f.open(f.pos)
+		if err != nil {
+			return 0, err
+		}
+		f.r = r
+	}
+	n, err := f.r.Read(p)
+	f.pos += int64(n)
+	return n, err
+}
+
+func (f *fileReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		offset += f.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	if offset != f.pos {
+		f.Close()
+		f.pos = offset
+	}
+	return offset, nil
+}
+
+func (f *fileReader) Close() error {
+	if f.r == nil {
+		return nil
+	}
+	err := f.r.Close()
+	f.r = nil
+	return err
+}
@@ -0,0 +1,240 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/node"
+	"github.com/Yashh56/p2p-storage/internal/p2p"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestFileReader_ServesRanges(t *testing.T) {
+	content := []byte("the quick brown fox jumps over the lazy dog")
+	var opened []int64
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f := &fileReader{size: int64(len(content)), open: func(offset int64) (io.ReadCloser, error) {
+			opened = append(opened, offset)
+			return io.NopCloser(bytes.NewReader(content[offset:])), nil
+		}}
+		defer f.Close()
+		setContentHeaders(w, "bafytest", "text/plain", false)
+		http.ServeContent(w, r, "", time.Time{}, f)
+	})
+	get := func(method string, header map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/files/bafytest", nil)
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get(http.MethodGet, nil)
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), content) {
+		t.Fatalf("got %d %q for the whole file", w.Code, w.Body)
+	}
+	if w.Header().Get("Content-Type") != "text/plain" || w.Header().Get("Etag") != `"bafytest"` {
+		t.Fatalf("unexpected headers %v", w.Header())
+	}
+
+	// A range opens the file at its start.
+	opened = nil
+	w = get(http.MethodGet, map[string]string{"Range": "bytes=4-8"})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "quick" {
+		t.Fatalf("got %d %q for a range", w.Code, w.Body)
+	}
+	if len(opened) != 1 || opened[0] != 4 {
+		t.Fatalf("opened the file at %v", opened)
+	}
+	w = get(http.MethodGet, map[string]string{"Range": "bytes=-3"})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "dog" {
+		t.Fatalf("got %d %q for a suffix range", w.Code, w.Body)
+	}
+	w = get(http.MethodGet, map[string]string{"Range": "bytes=100-"})
+	if w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("got %d for a range past the end", w.Code)
+	}
+
+	// HEAD and conditional requests do not read the file.
+	opened = nil
+	w = get(http.MethodHead, nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Length") != "43" || w.Body.Len() != 0 {
+		t.Fatalf("got %d %v for HEAD", w.Code, w.Header())
+	}
+	w = get(http.MethodGet, map[string]string{"If-None-Match": `"bafytest"`})
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("got %d for a matching ETag", w.Code)
+	}
+	if len(opened) != 0 {
+		t.Fatalf("opened the file at %v", opened)
+	}
+}
+
+// newTestServer returns a gateway over a node with no peers, and the store
+// behind it.
+func newTestServer(t *testing.T) (*Server, *node.Node, *storage.BlockStore) {
+	t.Helper()
+	store, err := storage.NewBlockStore(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(store.Close)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	n, err := node.NewNode(ctx, store, node.Config{
+		Host:        p2p.HostConfig{ListenAddrs: []string{"/ip4/127.0.0.1/tcp/0"}},
+		Replication: node.ReplicationConfig{Interval: time.Hour},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { n.Host.Close() })
+	return NewServer(n), n, store
+}
+
+func TestServer_Files(t *testing.T) {
+	s, n, store := newTestServer(t)
+	put := func(data []byte) cid.Cid {
+		t.Helper()
+		c, err := store.Put(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	putManifest := func(m *api.Manifest) cid.Cid {
+		t.Helper()
+		data, err := proto.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return put(data)
+	}
+	get := func(method, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(method, url, nil))
+		return w
+	}
+
+	content := "the quick brown fox jumps over the lazy dog"
+	plain, err := n.AddFile(context.Background(), strings.NewReader(content), node.AddOptions{Name: "fox.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := n.AddFile(context.Background(), strings.NewReader(content), node.AddOptions{
+		Encryption: node.EncryptOptions{Mode: node.FileKey, Cipher: storage.DefaultCipher},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrongKey, err := storage.NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := putManifest(&api.Manifest{
+		Version: 2,
+		Size:    uint64(len(content)),
+		Directory: &api.Directory{Entries: []*api.DirectoryEntry{
+			{Name: "fox.txt", Cid: plain.Root.String(), Size: uint64(len(content))},
+		}},
+	})
+	v0 := putManifest(&api.Manifest{BlockCids: []string{put([]byte("hello ")).String(), put([]byte("world")).String()}})
+
+	w := get(http.MethodGet, "/files/"+plain.Root.String())
+	if w.Code != http.StatusOK || w.Body.String() != content {
+		t.Fatalf("got %d %q for a file", w.Code, w.Body)
+	}
+	if cc := w.Header().Get("Cache-Control"); !strings.HasPrefix(cc, "public") {
+		t.Fatalf("got Cache-Control %q for a plain file", cc)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `inline; filename=fox.txt` {
+		t.Fatalf("got Content-Disposition %q", cd)
+	}
+	w = get(http.MethodGet, "/files/"+encrypted.Root.String()+"?key="+storage.EncodeKey(encrypted.Key))
+	if w.Code != http.StatusOK || w.Body.String() != content {
+		t.Fatalf("got %d %q for an encrypted file", w.Code, w.Body)
+	}
+	// The key is in the URL, so the content must not land in shared caches.
+	if cc := w.Header().Get("Cache-Control"); cc != "private, no-store" {
+		t.Fatalf("got Cache-Control %q for an encrypted file", cc)
+	}
+
+	// Version 0 manifests can only be streamed whole.
+	w = get(http.MethodGet, "/files/"+v0.String())
+	if w.Code != http.StatusOK || w.Body.String() != "hello world" {
+		t.Fatalf("got %d %q for a version 0 manifest", w.Code, w.Body)
+	}
+	if ar := w.Header().Get("Accept-Ranges"); ar != "none" {
+		t.Fatalf("got Accept-Ranges %q for a version 0 manifest", ar)
+	}
+	w = get(http.MethodHead, "/files/"+v0.String())
+	if w.Code != http.StatusOK || w.Body.Len() != 0 || w.Header().Get("Accept-Ranges") != "none" {
+		t.Fatalf("got %d %v for HEAD of a version 0 manifest", w.Code, w.Header())
+	}
+
+	for _, tc := range []struct {
+		name string
+		url  string
+		code int
+	}{
+		{"invalid CID", "/files/nonsense", http.StatusBadRequest},
+		{"directory", "/files/" + dir.String(), http.StatusBadRequest},
+		{"no key", "/files/" + encrypted.Root.String(), http.StatusBadRequest},
+		{"invalid key", "/files/" + encrypted.Root.String() + "?key=nonsense", http.StatusBadRequest},
+		{"wrong key", "/files/" + encrypted.Root.String() + "?key=" + storage.EncodeKey(wrongKey), http.StatusForbidden},
+	} {
+		if w := get(http.MethodGet, tc.url); w.Code != tc.code {
+			t.Errorf("%s: got %d %q, want %d", tc.name, w.Code, w.Body, tc.code)
+		}
+	}
+}
+
+func TestServer_Blocks(t *testing.T) {
+	s, _, store := newTestServer(t)
+	data := []byte("a raw block")
+	c, err := store.Put(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(url string, header map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, url, nil)
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get("/blocks/"+c.String(), nil)
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), data) {
+		t.Fatalf("got %d %q for a block", w.Code, w.Body)
+	}
+	h := w.Header()
+	if h.Get("Content-Type") != "application/octet-stream" || h.Get("Etag") != `"`+c.String()+`"` || !strings.HasPrefix(h.Get("Cache-Control"), "public") {
+		t.Fatalf("unexpected headers %v", h)
+	}
+	w = get("/blocks/"+c.String(), map[string]string{"Range": "bytes=2-4"})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "raw" {
+		t.Fatalf("got %d %q for a range", w.Code, w.Body)
+	}
+	w = get("/blocks/"+c.String(), map[string]string{"If-None-Match": `"` + c.String() + `"`})
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("got %d for a matching ETag", w.Code)
+	}
+	if w := get("/blocks/nonsense", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("got %d for an invalid CID", w.Code)
+	}
+}
@@ -0,0 +1,569 @@
+package gateway
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Yashh56/p2p-storage/internal/node"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+)
+
+const (
+	// s3Namespace is the XML namespace of S3 responses.
+	s3Namespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+	// maxListKeys caps the keys returned by one listing.
+	maxListKeys = 1000
+	// maxCompleteBody caps the size of a CompleteMultipartUpload request,
+	// which lists at most maxPartNumber parts.
+	maxCompleteBody = 4 << 20
+	// s3TimeFormat is the format of timestamps in S3 listings.
+	s3TimeFormat = "2006-01-02T15:04:05.000Z"
+)
+
+// S3Server serves a subset of the Amazon S3 REST API over the node's
+// buckets, with path-style addressing (/{bucket}/{key}):
+//
+//	ListBuckets, CreateBucket, HeadBucket, DeleteBucket, GetBucketLocation
+//	ListObjects and ListObjectsV2
+//	PutObject, GetObject, HeadObject, DeleteObject
+//	CreateMultipartUpload, UploadPart, CompleteMultipartUpload,
+//	AbortMultipartUpload
+//
+// Requests are not authenticated: signatures are accepted without being
+// checked, so the server must only be reachable by trusted clients.
+type S3Server struct {
+	node *node.Node
+}
+
+func NewS3Server(n *node.Node) *S3Server {
+	return &S3Server{node: n}
+}
+
+// ServeHTTP routes requests by hand rather than through http.ServeMux,
+// which would redirect keys such as "a//b" or "a/../b" to a cleaned path.
+func (s *S3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	log.Printf("S3 %s request for /%s", r.Method, strings.TrimPrefix(r.URL.Path, "/"))
+	switch {
+	case bucket == "":
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeS3Error(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource.")
+			return
+		}
+		s.listBuckets(w, r)
+	case key == "":
+		s.serveBucket(w, r, bucket)
+	default:
+		s.serveObject(w, r, bucket, key)
+	}
+}
+
+func (s *S3Server) serveBucket(w http.ResponseWriter, r *http.Request, bucket string) {
+	q := r.URL.Query()
+	switch r.Method {
+	case http.MethodPut:
+		if err := s.node.CreateBucket(bucket); err != nil {
+			s3Error(w, r, err)
+			return
+		}
+		w.Header().Set("Location", "/"+bucket)
+	case http.MethodHead:
+		if _, err := s.node.StatBucket(bucket); err != nil {
+			s3Error(w, r, err)
+		}
+	case http.MethodDelete:
+		if err := s.node.DeleteBucket(bucket); err != nil {
+			s3Error(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodGet:
+		switch {
+		case q.Has("location"):
+			if _, err := s.node.StatBucket(bucket); err != nil {
+				s3Error(w, r, err)
+				return
+			}
+			writeXML(w, struct {
+				XMLName xml.Name `xml:"LocationConstraint"`
+				Xmlns   string   `xml:"xmlns,attr"`
+			}{Xmlns: s3Namespace})
+		case q.Has("uploads"), q.Has("versions"), q.Has("policy"), q.Has("acl"):
+			writeS3Error(w, r, http.StatusNotImplemented, "NotImplemented", "A header or query you provided implies functionality that is not implemented.")
+		default:
+			s.listObjects(w, r, bucket)
+		}
+	default:
+		writeS3Error(w, r, http.StatusNotImplemented, "NotImplemented", "A header or query you provided implies functionality that is not implemented.")
+	}
+}
+
+func (s *S3Server) serveObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	q := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPut && q.Has("uploadId"):
+		s.putPart(w, r, bucket, key)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") == "":
+		s.putObject(w, r, bucket, key)
+	case (r.Method == http.MethodGet || r.Method == http.MethodHead) && !q.Has("uploadId"):
+		s.getObject(w, r, bucket, key)
+	case r.Method == http.MethodDelete && q.Has("uploadId"):
+		if err := s.node.AbortMultipart(bucket, key, q.Get("uploadId")); err != nil {
+			s3Error(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		if err := s.node.DeleteObject(bucket, key); err != nil {
+			s3Error(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		s.startMultipart(w, r, bucket, key)
+	case r.Method == http.MethodPost && q.Has("uploadId"):
+		s.completeMultipart(w, r, bucket, key)
+	default:
+		writeS3Error(w, r, http.StatusNotImplemented, "NotImplemented", "A header or query you provided implies functionality that is not implemented.")
+	}
+}
+
+func (s *S3Server) listBuckets(w http.ResponseWriter, r *http.Request) {
+	buckets, err := s.node.ListBuckets()
+	if err != nil {
+		s3Error(w, r, err)
+		return
+	}
+	type bucketXML struct {
+		Name         string
+		CreationDate string
+	}
+	res := struct {
+		XMLName xml.Name `xml:"ListAllMyBucketsResult"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		Owner   struct {
+			ID          string
+			DisplayName string
+		}
+		Buckets []bucketXML `xml:"Buckets>Bucket"`
+	}{Xmlns: s3Namespace}
+	if s.node.Host != nil {
+		res.Owner.ID = s.node.Host.ID().String()
+		res.Owner.DisplayName = res.Owner.ID
+	}
+	for _, b := range buckets {
+		res.Buckets = append(res.Buckets, bucketXML{Name: b.Name, CreationDate: b.Created.UTC().Format(s3TimeFormat)})
+	}
+	writeXML(w, res)
+}
+
+type listBucketResult struct {
+	XMLName      xml.Name `xml:"ListBucketResult"`
+	Xmlns        string   `xml:"xmlns,attr"`
+	Name         string
+	Prefix       string
+	Delimiter    string `xml:",omitempty"`
+	MaxKeys      int
+	EncodingType string `xml:",omitempty"`
+	IsTruncated  bool
+	// ListObjects
+	Marker     *string `xml:",omitempty"`
+	NextMarker string  `xml:",omitempty"`
+	// ListObjectsV2
+	KeyCount              *int   `xml:",omitempty"`
+	ContinuationToken     string `xml:",omitempty"`
+	NextContinuationToken string `xml:",omitempty"`
+	StartAfter            string `xml:",omitempty"`
+
+	Contents       []objectXML
+	CommonPrefixes []commonPrefixXML
+}
+
+type objectXML struct {
+	Key          string
+	LastModified string
+	ETag         string
+	Size         int64
+	StorageClass string
+}
+
+type commonPrefixXML struct {
+	Prefix string
+}
+
+// listObjects serves ListObjectsV2 when list-type=2 is given, and the
+// original ListObjects otherwise.
+func (s *S3Server) listObjects(w http.ResponseWriter, r *http.Request, bucket string) {
+	q := r.URL.Query()
+	v2 := q.Get("list-type") == "2"
+	prefix, delimiter := q.Get("prefix"), q.Get("delimiter")
+	maxKeys := maxListKeys
+	if v := q.Get("max-keys"); v != "" {
+		k, err := strconv.Atoi(v)
+		if err != nil || k < 0 {
+			writeS3Error(w, r, http.StatusBadRequest, "InvalidArgument", "Invalid max-keys "+v)
+			return
+		}
+		maxKeys = min(k, maxListKeys)
+	}
+	encode := func(s string) string { return s }
+	if et := q.Get("encoding-type"); et == "url" {
+		encode = func(s string) string { return strings.ReplaceAll(url.QueryEscape(s), "+", "%20") }
+	} else if et != "" {
+		writeS3Error(w, r, http.StatusBadRequest, "InvalidArgument", "Invalid encoding-type "+et)
+		return
+	}
+
+	res := listBucketResult{
+		Xmlns:        s3Namespace,
+		Name:         bucket,
+		Prefix:       encode(prefix),
+		Delimiter:    encode(delimiter),
+		MaxKeys:      maxKeys,
+		EncodingType: q.Get("encoding-type"),
+	}
+	after := q.Get("marker")
+	if v2 {
+		after = q.Get("start-after")
+		res.StartAfter = encode(after)
+		if token := q.Get("continuation-token"); token != "" {
+			last, err := base64.RawURLEncoding.DecodeString(token)
+			if err != nil {
+				writeS3Error(w, r, http.StatusBadRequest, "InvalidArgument", "The continuation token provided is incorrect")
+				return
+			}
+			after = string(last)
+			res.ContinuationToken = token
+		}
+	} else {
+		marker := encode(after)
+		res.Marker = &marker
+	}
+
+	contents, prefixes, last, truncated, err := s.list(bucket, prefix, delimiter, after, maxKeys)
+	if err != nil {
+		s3Error(w, r, err)
+		return
+	}
+	for _, o := range contents {
+		res.Contents = append(res.Contents, objectXML{
+			Key:          encode(o.Key),
+			LastModified: o.Modified.UTC().Format(s3TimeFormat),
+			ETag:         strconv.Quote(o.ETag),
+			Size:         o.Size,
+			StorageClass: "STANDARD",
+		})
+	}
+	for _, p := range prefixes {
+		res.CommonPrefixes = append(res.CommonPrefixes, commonPrefixXML{Prefix: encode(p)})
+	}
+	// A listing with max-keys=0 is truncated as soon as any key matches,
+	// but returns nothing to continue from.
+	res.IsTruncated = truncated
+	if truncated && last != "" {
+		if v2 {
+			res.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(last))
+		} else {
+			res.NextMarker = encode(last)
+		}
+	}
+	if v2 {
+		count := len(contents) + len(prefixes)
+		res.KeyCount = &count
+	}
+	writeXML(w, res)
+}
+
+// errListFull stops a listing once it has collected enough keys.
+var errListFull = errors.New("listing is full")
+
+// list returns up to maxKeys objects and common prefixes of the keys in
+// bucket with prefix that sort after after. Keys with delimiter past the
+// prefix are rolled up into common prefixes. If more remain, truncated is
+// set and last is the last key or common prefix returned, from which the
+// listing continues.
+func (s *S3Server) list(bucket, prefix, delimiter, after string, maxKeys int) (contents []node.Object, prefixes []string, last string, truncated bool, err error) {
+	// A listing continuing after a common prefix skips the keys rolled up
+	// in it.
+	var rolledUp string
+	if delimiter != "" && strings.HasPrefix(after, prefix) && strings.HasSuffix(after, delimiter) &&
+		strings.Contains(after[len(prefix):], delimiter) {
+		rolledUp = after
+	}
+	count := 0
+	err = s.node.ListObjects(bucket, prefix, after, func(o node.Object) error {
+		if rolledUp != "" && strings.HasPrefix(o.Key, rolledUp) {
+			return nil
+		}
+		if count == maxKeys {
+			return errListFull
+		}
+		count++
+		if delimiter != "" {
+			if i := strings.Index(o.Key[len(prefix):], delimiter); i >= 0 {
+				rolledUp = o.Key[:len(prefix)+i+len(delimiter)]
+				prefixes = append(prefixes, rolledUp)
+				last = rolledUp
+				return nil
+			}
+		}
+		contents = append(contents, o)
+		last = o.Key
+		return nil
+	})
+	if errors.Is(err, errListFull) {
+		return contents, prefixes, last, true, nil
+	}
+	return contents, prefixes, "", false, err
+}
+
+func (s *S3Server) putObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	opts, ok := objectOptions(w, r)
+	if !ok {
+		return
+	}
+	o, err := s.node.PutObject(r.Context(), bucket, key, requestBody(r), opts)
+	if err != nil {
+		s3Error(w, r, err)
+		return
+	}
+	w.Header().Set("Etag", strconv.Quote(o.ETag))
+}
+
+func (s *S3Server) getObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	o, err := s.node.GetObject(bucket, key)
+	if err != nil {
+		s3Error(w, r, err)
+		return
+	}
+	h := w.Header()
+	h.Set("Etag", strconv.Quote(o.ETag))
+	contentType := o.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h.Set("Content-Type", contentType)
+	for k, v := range o.Metadata {
+		h.Set("X-Amz-Meta-"+k, v)
+	}
+	f := &fileReader{size: o.Size, open: func(offset int64) (io.ReadCloser, error) {
+		rc, _, err := s.node.GetFile(r.Context(), o.Root.String(), node.GetOptions{Offset: offset})
+		return rc, err
+	}}
+	defer f.Close()
+	http.ServeContent(w, r, "", o.Modified, f)
+}
+
+func (s *S3Server) startMultipart(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	opts, ok := objectOptions(w, r)
+	if !ok {
+		return
+	}
+	id, err := s.node.StartMultipart(bucket, key, opts)
+	if err != nil {
+		s3Error(w, r, err)
+		return
+	}
+	writeXML(w, struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		Xmlns    string   `xml:"xmlns,attr"`
+		Bucket   string
+		Key      string
+		UploadId string
+	}{Xmlns: s3Namespace, Bucket: bucket, Key: key, UploadId: id})
+}
+
+func (s *S3Server) putPart(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	q := r.URL.Query()
+	number, err := strconv.Atoi(q.Get("partNumber"))
+	if err != nil {
+		writeS3Error(w, r, http.StatusBadRequest, "InvalidArgument", "Invalid partNumber "+q.Get("partNumber"))
+		return
+	}
+	if r.Header.Get("X-Amz-Copy-Source") != "" {
+		writeS3Error(w, r, http.StatusNotImplemented, "NotImplemented", "UploadPartCopy is not implemented.")
+		return
+	}
+	opts, ok := objectOptions(w, r)
+	if !ok {
+		return
+	}
+	etag, err := s.node.PutPart(bucket, key, q.Get("uploadId"), number, requestBody(r), opts.ContentMD5)
+	if err != nil {
+		s3Error(w, r, err)
+		return
+	}
+	w.Header().Set("Etag", strconv.Quote(etag))
+}
+
+func (s *S3Server) completeMultipart(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	var req struct {
+		Parts []struct {
+			PartNumber int
+			ETag       string
+		} `xml:"Part"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(r.Body, maxCompleteBody)).Decode(&req); err != nil {
+		writeS3Error(w, r, http.StatusBadRequest, "MalformedXML", "The XML you provided was not well-formed or did not validate against our published schema.")
+		return
+	}
+	parts := make([]node.CompletedPart, len(req.Parts))
+	for i, p := range req.Parts {
+		parts[i] = node.CompletedPart{Number: p.PartNumber, ETag: p.ETag}
+	}
+	o, err := s.node.CompleteMultipart(r.Context(), bucket, key, r.URL.Query().Get("uploadId"), parts)
+	if err != nil {
+		s3Error(w, r, err)
+		return
+	}
+	writeXML(w, struct {
+		XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
+		Xmlns    string   `xml:"xmlns,attr"`
+		Location string
+		Bucket   string
+		Key      string
+		ETag     string
+	}{Xmlns: s3Namespace, Location: "/" + bucket + "/" + key, Bucket: bucket, Key: key, ETag: strconv.Quote(o.ETag)})
+}
+
+// objectOptions reads the Content-Type, Content-MD5 and x-amz-meta-*
+// headers of a request. It writes an error and returns false if they are
+// invalid.
+func objectOptions(w http.ResponseWriter, r *http.Request) (node.ObjectOptions, bool) {
+	opts := node.ObjectOptions{ContentType: r.Header.Get("Content-Type")}
+	if v := r.Header.Get("Content-Md5"); v != "" {
+		sum, err := base64.StdEncoding.DecodeString(v)
+		if err != nil || len(sum) != 16 {
+			writeS3Error(w, r, http.StatusBadRequest, "InvalidDigest", "The Content-MD5 you specified was invalid.")
+			return opts, false
+		}
+		opts.ContentMD5 = sum
+	}
+	for k, v := range r.Header {
+		if name, ok := strings.CutPrefix(k, "X-Amz-Meta-"); ok && len(v) > 0 {
+			if opts.Metadata == nil {
+				opts.Metadata = make(map[string]string)
+			}
+			opts.Metadata[strings.ToLower(name)] = v[0]
+		}
+	}
+	return opts, true
+}
+
+// requestBody returns the content of an upload, decoding the aws-chunked
+// encoding of SigV4 streaming uploads.
+func requestBody(r *http.Request) io.Reader {
+	if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") ||
+		strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked") {
+		return &chunkedReader{r: bufio.NewReader(r.Body)}
+	}
+	return r.Body
+}
+
+// chunkedReader decodes the aws-chunked encoding: chunks of
+// "<hex size>[;chunk-signature=<sig>]\r\n<data>\r\n", ending with a chunk
+// of size zero followed by optional trailers. Signatures and trailing
+// checksums are not checked.
+type chunkedReader struct {
+	r    *bufio.Reader
+	left int64
+	done bool
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for c.left == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return 0, fmt.Errorf("malformed aws-chunked body: %w", io.ErrUnexpectedEOF)
+		}
+		size, _, _ := strings.Cut(strings.TrimRight(line, "\r\n"), ";")
+		c.left, err = strconv.ParseInt(strings.TrimSpace(size), 16, 64)
+		if err != nil || c.left < 0 {
+			return 0, fmt.Errorf("malformed aws-chunked body: chunk size %q", size)
+		}
+		// Trailers after the last chunk are ignored.
+		c.done = c.left == 0
+	}
+	if int64(len(p)) > c.left {
+		p = p[:c.left]
+	}
+	n, err := c.r.Read(p)
+	c.left -= int64(n)
+	if err == io.EOF {
+		return n, fmt.Errorf("malformed aws-chunked body: %w", io.ErrUnexpectedEOF)
+	}
+	if err == nil && c.left == 0 {
+		// Each chunk ends with CRLF.
+		_, err = c.r.Discard(2)
+	}
+	return n, err
+}
+
+// writeXML writes v as an XML response.
+func writeXML(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	io.WriteString(w, xml.Header)
+	if err := xml.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("S3 error writing response: %v", err)
+	}
+}
+
+// writeS3Error writes an S3 error response.
+func writeS3Error(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(struct {
+		XMLName  xml.Name `xml:"Error"`
+		Code     string
+		Message  string
+		Resource string
+	}{Code: code, Message: message, Resource: r.URL.Path})
+}
+
+// s3Error maps the errors of the node's object API to S3 error responses.
+func s3Error(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := http.StatusBadRequest, ""
+	switch {
+	case errors.Is(err, node.ErrNoSuchBucket):
+		status, code = http.StatusNotFound, "NoSuchBucket"
+	case errors.Is(err, node.ErrNoSuchKey):
+		status, code = http.StatusNotFound, "NoSuchKey"
+	case errors.Is(err, node.ErrNoSuchUpload):
+		status, code = http.StatusNotFound, "NoSuchUpload"
+	case errors.Is(err, node.ErrBucketNotEmpty):
+		status, code = http.StatusConflict, "BucketNotEmpty"
+	case errors.Is(err, node.ErrInvalidName):
+		code = "InvalidBucketName"
+		if _, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/"); key != "" {
+			code = "InvalidArgument"
+		}
+	case errors.Is(err, node.ErrInvalidPart):
+		code = "InvalidPart"
+	case errors.Is(err, node.ErrInvalidPartOrder):
+		code = "InvalidPartOrder"
+	case errors.Is(err, node.ErrBadDigest):
+		code = "BadDigest"
+	case errors.Is(err, storage.ErrQuotaExceeded):
+		status, code = http.StatusInsufficientStorage, "InsufficientStorage"
+	default:
+		log.Printf("S3 error serving %s %s: %v", r.Method, r.URL.Path, err)
+		status, code = http.StatusInternalServerError, "InternalError"
+	}
+	writeS3Error(w, r, status, code, err.Error())
+}
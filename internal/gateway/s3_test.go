@@ -0,0 +1,312 @@
+package gateway
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestChunkedReader_DecodesStreamingBody(t *testing.T) {
+	body := "5;chunk-signature=aa\r\nhello\r\n" +
+		"6;chunk-signature=bb\r\n world\r\n" +
+		"0;chunk-signature=cc\r\n" +
+		"x-amz-checksum-crc32:AAAAAA==\r\n\r\n"
+	got, err := io.ReadAll(&chunkedReader{r: bufio.NewReader(strings.NewReader(body))})
+	if err != nil || string(got) != "hello world" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+
+	// Unsigned chunks carry no extension.
+	got, err = io.ReadAll(&chunkedReader{r: bufio.NewReader(strings.NewReader("3\r\nabc\r\n0\r\n\r\n"))})
+	if err != nil || string(got) != "abc" {
+		t.Fatalf("got %q, %v for unsigned chunks", got, err)
+	}
+
+	for _, body := range []string{"5\r\nhel", "zz\r\nhello\r\n", "5\r\nhello\r\n"} {
+		_, err := io.ReadAll(&chunkedReader{r: bufio.NewReader(strings.NewReader(body))})
+		if err == nil {
+			t.Fatalf("no error for malformed body %q", body)
+		}
+		if strings.HasPrefix(body, "5") && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("got %v for truncated body %q, want io.ErrUnexpectedEOF", err, body)
+		}
+	}
+}
+
+// newTestS3Server returns an S3 gateway over a node with no peers, and a
+// function making requests to it.
+func newTestS3Server(t *testing.T) func(method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	_, n, _ := newTestServer(t)
+	s := NewS3Server(n)
+	return func(method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		return w
+	}
+}
+
+// decodeXML decodes the body of a response into v.
+func decodeXML(t *testing.T, w *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := xml.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding %q: %v", w.Body, err)
+	}
+}
+
+func TestS3Server_ListObjectsV2(t *testing.T) {
+	do := newTestS3Server(t)
+	if w := do(http.MethodPut, "/bucket", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("got %d %q creating the bucket", w.Code, w.Body)
+	}
+	// max-keys=0 on an empty bucket has nothing to truncate.
+	var res listBucketResult
+	decodeXML(t, do(http.MethodGet, "/bucket?list-type=2&max-keys=0", "", nil), &res)
+	if res.IsTruncated {
+		t.Fatal("empty listing is truncated")
+	}
+	for _, key := range []string{"a.txt", "dir/one", "dir/sub/three", "dir/two", "z.txt"} {
+		if w := do(http.MethodPut, "/bucket/"+key, key, nil); w.Code != http.StatusOK {
+			t.Fatalf("got %d %q putting %s", w.Code, w.Body, key)
+		}
+	}
+	list := func(query string) listBucketResult {
+		t.Helper()
+		w := do(http.MethodGet, "/bucket?list-type=2&"+query, "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("got %d %q listing %s", w.Code, w.Body, query)
+		}
+		var res listBucketResult
+		decodeXML(t, w, &res)
+		return res
+	}
+	names := func(res listBucketResult) string {
+		var out []string
+		for _, o := range res.Contents {
+			out = append(out, o.Key)
+		}
+		for _, p := range res.CommonPrefixes {
+			out = append(out, p.Prefix+"*")
+		}
+		return strings.Join(out, ",")
+	}
+
+	// Keys below a delimiter are rolled up into one common prefix.
+	res = list("delimiter=/")
+	if got := names(res); got != "a.txt,z.txt,dir/*" || res.IsTruncated || *res.KeyCount != 3 {
+		t.Fatalf("got %s, truncated %v, count %d", got, res.IsTruncated, *res.KeyCount)
+	}
+	res = list("delimiter=/&prefix=dir/")
+	if got := names(res); got != "dir/one,dir/two,dir/sub/*" {
+		t.Fatalf("got %s under dir/", got)
+	}
+	if res.Contents[0].Size != int64(len("dir/one")) || res.Contents[0].ETag == "" {
+		t.Fatalf("unexpected object %+v", res.Contents[0])
+	}
+
+	// Pages continue after the last key or common prefix, which counts as
+	// one key.
+	var pages []string
+	token := ""
+	for {
+		res = list("delimiter=/&max-keys=1&continuation-token=" + token)
+		pages = append(pages, names(res))
+		if !res.IsTruncated {
+			break
+		}
+		if res.NextContinuationToken == "" || len(pages) > 5 {
+			t.Fatalf("truncated listing without a usable token after %v", pages)
+		}
+		token = res.NextContinuationToken
+	}
+	if got := strings.Join(pages, " "); got != "a.txt dir/* z.txt" {
+		t.Fatalf("got pages %s", got)
+	}
+
+	// max-keys=0 returns nothing, but says that more keys exist.
+	res = list("max-keys=0")
+	if len(res.Contents) != 0 || *res.KeyCount != 0 || !res.IsTruncated {
+		t.Fatalf("got %s, truncated %v for max-keys=0", names(res), res.IsTruncated)
+	}
+
+	res = list("start-after=dir/two")
+	if got := names(res); got != "z.txt" {
+		t.Fatalf("got %s after dir/two", got)
+	}
+	if w := do(http.MethodGet, "/bucket?list-type=2&continuation-token=!", "", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("got %d for a malformed continuation token", w.Code)
+	}
+}
+
+func TestS3Server_GetObjectRange(t *testing.T) {
+	do := newTestS3Server(t)
+	do(http.MethodPut, "/bucket", "", nil)
+	content := "the quick brown fox jumps over the lazy dog"
+	w := do(http.MethodPut, "/bucket/fox.txt", content, map[string]string{
+		"Content-Type":    "text/plain",
+		"X-Amz-Meta-Kind": "pangram",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %q putting the object", w.Code, w.Body)
+	}
+	etag := w.Header().Get("Etag")
+
+	w = do(http.MethodGet, "/bucket/fox.txt", "", map[string]string{"Range": "bytes=4-8"})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "quick" {
+		t.Fatalf("got %d %q for a range", w.Code, w.Body)
+	}
+	h := w.Header()
+	if h.Get("Content-Range") != "bytes 4-8/43" || h.Get("Etag") != etag || h.Get("Content-Type") != "text/plain" || h.Get("X-Amz-Meta-Kind") != "pangram" {
+		t.Fatalf("unexpected headers %v", h)
+	}
+	w = do(http.MethodGet, "/bucket/fox.txt", "", map[string]string{"Range": "bytes=-3"})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "dog" {
+		t.Fatalf("got %d %q for a suffix range", w.Code, w.Body)
+	}
+	w = do(http.MethodGet, "/bucket/fox.txt", "", map[string]string{"Range": "bytes=100-"})
+	if w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("got %d for a range past the end", w.Code)
+	}
+	w = do(http.MethodHead, "/bucket/fox.txt", "", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Length") != "43" || w.Body.Len() != 0 {
+		t.Fatalf("got %d %v for HEAD", w.Code, w.Header())
+	}
+}
+
+func TestS3Server_Multipart(t *testing.T) {
+	do := newTestS3Server(t)
+	do(http.MethodPut, "/bucket", "", nil)
+
+	var started struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		Bucket   string
+		Key      string
+		UploadId string
+	}
+	w := do(http.MethodPost, "/bucket/dir/big.bin?uploads", "", map[string]string{"Content-Type": "application/x-test"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %q starting the upload", w.Code, w.Body)
+	}
+	decodeXML(t, w, &started)
+	if started.Bucket != "bucket" || started.Key != "dir/big.bin" || started.UploadId == "" {
+		t.Fatalf("unexpected upload %+v", started)
+	}
+	base := "/bucket/dir/big.bin?uploadId=" + started.UploadId
+
+	// Parts may arrive in any order.
+	etags := map[int]string{}
+	for _, p := range []struct {
+		number int
+		data   string
+	}{{2, "second part"}, {1, "first part, "}} {
+		w := do(http.MethodPut, base+"&partNumber="+strconv.Itoa(p.number), p.data, nil)
+		if w.Code != http.StatusOK || w.Header().Get("Etag") == "" {
+			t.Fatalf("got %d %q uploading part %d", w.Code, w.Body, p.number)
+		}
+		etags[p.number] = w.Header().Get("Etag")
+	}
+	complete := func(numbers ...int) *httptest.ResponseRecorder {
+		body := "<CompleteMultipartUpload>"
+		for _, n := range numbers {
+			body += "<Part><PartNumber>" + strconv.Itoa(n) + "</PartNumber><ETag>" + etags[n] + "</ETag></Part>"
+		}
+		return do(http.MethodPost, base, body+"</CompleteMultipartUpload>", nil)
+	}
+	var failed s3ErrorXML
+	decodeXML(t, complete(2, 1), &failed)
+	if failed.Code != "InvalidPartOrder" {
+		t.Fatalf("got %q for parts out of order", failed.Code)
+	}
+
+	var done struct {
+		XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
+		Location string
+		Bucket   string
+		Key      string
+		ETag     string
+	}
+	w = complete(1, 2)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %q completing the upload", w.Code, w.Body)
+	}
+	decodeXML(t, w, &done)
+	if done.Location != "/bucket/dir/big.bin" || done.Key != "dir/big.bin" || !strings.HasSuffix(done.ETag, `-2"`) {
+		t.Fatalf("unexpected result %+v", done)
+	}
+	w = do(http.MethodGet, "/bucket/dir/big.bin", "", nil)
+	if w.Body.String() != "first part, second part" || w.Header().Get("Etag") != done.ETag || w.Header().Get("Content-Type") != "application/x-test" {
+		t.Fatalf("got %q %v for the completed object", w.Body, w.Header())
+	}
+
+	// The upload is gone once completed.
+	decodeXML(t, complete(1, 2), &failed)
+	if failed.Code != "NoSuchUpload" {
+		t.Fatalf("got %q completing a finished upload", failed.Code)
+	}
+}
+
+// s3ErrorXML is the body of an S3 error response.
+type s3ErrorXML struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string
+	Resource string
+}
+
+func TestS3Server_Errors(t *testing.T) {
+	do := newTestS3Server(t)
+	do(http.MethodPut, "/bucket", "", nil)
+	do(http.MethodPut, "/bucket/key", "content", nil)
+	w := do(http.MethodPost, "/bucket/upload?uploads", "", nil)
+	var started struct{ UploadId string }
+	decodeXML(t, w, &started)
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		target string
+		body   string
+		header map[string]string
+		status int
+		code   string
+	}{
+		{"missing bucket", http.MethodGet, "/missing?list-type=2", "", nil, http.StatusNotFound, "NoSuchBucket"},
+		{"missing key", http.MethodGet, "/bucket/missing", "", nil, http.StatusNotFound, "NoSuchKey"},
+		{"missing upload", http.MethodPut, "/bucket/upload?uploadId=0000&partNumber=1", "x", nil, http.StatusNotFound, "NoSuchUpload"},
+		{"bucket not empty", http.MethodDelete, "/bucket", "", nil, http.StatusConflict, "BucketNotEmpty"},
+		{"invalid bucket name", http.MethodPut, "/Not_A_Bucket", "", nil, http.StatusBadRequest, "InvalidBucketName"},
+		{"invalid part number", http.MethodPut, "/bucket/upload?uploadId=" + started.UploadId + "&partNumber=0", "x", nil, http.StatusBadRequest, "InvalidPart"},
+		{"unparsable part number", http.MethodPut, "/bucket/upload?uploadId=" + started.UploadId + "&partNumber=one", "x", nil, http.StatusBadRequest, "InvalidArgument"},
+		{"part not uploaded", http.MethodPost, "/bucket/upload?uploadId=" + started.UploadId,
+			"<CompleteMultipartUpload><Part><PartNumber>1</PartNumber><ETag>x</ETag></Part></CompleteMultipartUpload>", nil, http.StatusBadRequest, "InvalidPart"},
+		{"malformed XML", http.MethodPost, "/bucket/upload?uploadId=" + started.UploadId, "<Complete", nil, http.StatusBadRequest, "MalformedXML"},
+		{"bad digest", http.MethodPut, "/bucket/key", "content", map[string]string{"Content-Md5": "AAAAAAAAAAAAAAAAAAAAAA=="}, http.StatusBadRequest, "BadDigest"},
+		{"invalid digest", http.MethodPut, "/bucket/key", "content", map[string]string{"Content-Md5": "nonsense"}, http.StatusBadRequest, "InvalidDigest"},
+		{"invalid max-keys", http.MethodGet, "/bucket?max-keys=-1", "", nil, http.StatusBadRequest, "InvalidArgument"},
+		{"invalid encoding", http.MethodGet, "/bucket?encoding-type=xml", "", nil, http.StatusBadRequest, "InvalidArgument"},
+		{"not implemented", http.MethodGet, "/bucket?acl", "", nil, http.StatusNotImplemented, "NotImplemented"},
+		{"copy not implemented", http.MethodPut, "/bucket/copy", "", map[string]string{"X-Amz-Copy-Source": "/bucket/key"}, http.StatusNotImplemented, "NotImplemented"},
+		{"method not allowed", http.MethodPost, "/", "", nil, http.StatusMethodNotAllowed, "MethodNotAllowed"},
+	} {
+		w := do(tc.method, tc.target, tc.body, tc.header)
+		var res s3ErrorXML
+		if err := xml.Unmarshal(w.Body.Bytes(), &res); err != nil || w.Code != tc.status || res.Code != tc.code {
+			t.Errorf("%s: got %d %q, want %d %s", tc.name, w.Code, w.Body, tc.status, tc.code)
+		}
+	}
+
+	// HEAD errors carry no body.
+	w = do(http.MethodHead, "/bucket/missing", "", nil)
+	if w.Code != http.StatusNotFound || w.Body.Len() != 0 {
+		t.Fatalf("got %d %q for HEAD of a missing key", w.Code, w.Body)
+	}
+}
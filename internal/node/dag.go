@@ -0,0 +1,259 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/p2p"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// dagFanout bounds the links of a DagNode and of a root manifest. As in
+	// UnixFS, it keeps a node of CIDv1 links to around 10 KiB.
+	dagFanout = 174
+	// maxDagDepth bounds the depth of a DAG read from the network. 174^8
+	// chunks is far more than any file needs.
+	maxDagDepth = 8
+)
+
+// dagLink is a decoded DagLink.
+type dagLink struct {
+	cid cid.Cid
+	// size is the content below the link, before encryption.
+	size int64
+	// key is the sealed key of a convergently encrypted chunk.
+	key []byte
+}
+
+// decodeLinks decodes the links of a DagNode or root manifest and returns
+// the total size below them. leaves says whether the links point to chunks.
+func decodeLinks(links []*api.DagLink, leaves bool) ([]dagLink, int64, error) {
+	decoded := make([]dagLink, len(links))
+	var total int64
+	for i, l := range links {
+		c, err := cid.Decode(l.GetCid())
+		if err != nil {
+			return nil, 0, err
+		}
+		if leaves && l.GetSize() > p2p.MaxMessageSize {
+			return nil, 0, fmt.Errorf("chunk %s is too large", c)
+		}
+		if !leaves && len(l.GetKey()) > 0 {
+			return nil, 0, fmt.Errorf("link to DAG node %s carries a key", c)
+		}
+		if l.GetSize() > 1<<62-uint64(total) {
+			return nil, 0, errors.New("DAG links overflow the file size")
+		}
+		decoded[i] = dagLink{cid: c, size: int64(l.GetSize()), key: l.GetKey()}
+		total += decoded[i].size
+	}
+	return decoded, total, nil
+}
+
+// dagBuilder builds a balanced Merkle DAG over the chunks of a file as they
+// are added, storing a DagNode whenever a level fills up. A node only
+// depends on the chunks below it, so runs of chunks that line up in two
+// files share their subtrees.
+type dagBuilder struct {
+	n      *Node
+	fanout int
+	// levels holds the links not yet stored in a node; levels[0] links to
+	// chunks.
+	levels [][]*api.DagLink
+	// nodes lists the DagNodes stored so far.
+	nodes []cid.Cid
+	// chunks counts the chunks added.
+	chunks int
+}
+
+func (n *Node) newDagBuilder(fanout int) *dagBuilder {
+	return &dagBuilder{n: n, fanout: fanout}
+}
+
+// add appends the link to the next chunk.
+func (b *dagBuilder) add(l *api.DagLink) error {
+	b.chunks++
+	return b.addAt(0, l)
+}
+
+func (b *dagBuilder) addAt(level int, l *api.DagLink) error {
+	if level == len(b.levels) {
+		b.levels = append(b.levels, nil)
+	}
+	// A full level is only stored once it overflows, so that a file of up
+	// to fanout chunks links them all from the root.
+	if len(b.levels[level]) == b.fanout {
+		if err := b.flush(level); err != nil {
+			return err
+		}
+	}
+	b.levels[level] = append(b.levels[level], l)
+	return nil
+}
+
+// flush stores the pending links of level as a DagNode and links it from
+// the level above.
+func (b *dagBuilder) flush(level int) error {
+	links := b.levels[level]
+	b.levels[level] = nil
+	var size uint64
+	for _, l := range links {
+		size += l.Size
+	}
+	data, err := proto.Marshal(&api.DagNode{Links: links})
+	if err != nil {
+		return err
+	}
+	c, err := b.n.store.Put(data)
+	if err != nil {
+		return err
+	}
+	b.nodes = append(b.nodes, c)
+	return b.addAt(level+1, &api.DagLink{Cid: c.String(), Size: size})
+}
+
+// finish stores the partially filled nodes and returns the links of the
+// root and the depth of the chunks below them.
+func (b *dagBuilder) finish() ([]*api.DagLink, int, error) {
+	for level := 0; level < len(b.levels)-1; level++ {
+		if len(b.levels[level]) > 0 {
+			if err := b.flush(level); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+	if len(b.levels) == 0 {
+		return nil, 0, nil
+	}
+	top := len(b.levels) - 1
+	return b.levels[top], top, nil
+}
+
+// chunkRef is a chunk of a file.
+type chunkRef struct {
+	cid cid.Cid
+	// size is the chunk's size before encryption, or -1 if the manifest
+	// does not record it.
+	size int64
+	// key is the sealed key of a convergently encrypted chunk.
+	key []byte
+}
+
+// chunkIter yields the chunks of a file in order, then io.EOF.
+type chunkIter func(ctx context.Context) (chunkRef, error)
+
+// cidIter iterates over chunks of unknown size.
+func cidIter(cids []cid.Cid) chunkIter {
+	return func(ctx context.Context) (chunkRef, error) {
+		if len(cids) == 0 {
+			return chunkRef{}, io.EOF
+		}
+		ref := chunkRef{cid: cids[0], size: -1}
+		cids = cids[1:]
+		return ref, nil
+	}
+}
+
+// dagWalker visits the DAG below a file's root links depth first, fetching
+// DagNodes only as the walk reaches them.
+type dagWalker struct {
+	fetch blockFetchFunc
+	// discover, if set, is told the links of every node fetched, in the
+	// order they will be visited.
+	discover func(ctx context.Context, cids []cid.Cid)
+	// node, if set, is called with every DagNode fetched.
+	node  func(c cid.Cid)
+	depth int
+	// stack[i] holds the links still to visit at depth i below the root.
+	stack [][]dagLink
+}
+
+func newDagWalker(links []dagLink, depth int, fetch blockFetchFunc) *dagWalker {
+	return &dagWalker{fetch: fetch, depth: depth, stack: [][]dagLink{links}}
+}
+
+// next returns the next chunk, or io.EOF after the last.
+func (w *dagWalker) next(ctx context.Context) (chunkRef, error) {
+	for len(w.stack) > 0 {
+		top := len(w.stack) - 1
+		if len(w.stack[top]) == 0 {
+			w.stack = w.stack[:top]
+			continue
+		}
+		l := w.stack[top][0]
+		w.stack[top] = w.stack[top][1:]
+		if top == w.depth {
+			return chunkRef{cid: l.cid, size: l.size, key: l.key}, nil
+		}
+		children, err := w.expand(ctx, l, top+1 == w.depth)
+		if err != nil {
+			return chunkRef{}, err
+		}
+		w.stack = append(w.stack, children)
+	}
+	return chunkRef{}, io.EOF
+}
+
+// seek skips the chunks that end at or before offset, fetching only the
+// DagNodes on the path to the chunk holding it, and returns the position of
+// offset within that chunk.
+func (w *dagWalker) seek(ctx context.Context, offset int64) (int64, error) {
+	for len(w.stack) > 0 {
+		top := len(w.stack) - 1
+		if len(w.stack[top]) == 0 {
+			w.stack = w.stack[:top]
+			continue
+		}
+		l := w.stack[top][0]
+		if offset >= l.size {
+			offset -= l.size
+			w.stack[top] = w.stack[top][1:]
+			continue
+		}
+		if top == w.depth {
+			return offset, nil
+		}
+		w.stack[top] = w.stack[top][1:]
+		children, err := w.expand(ctx, l, top+1 == w.depth)
+		if err != nil {
+			return 0, err
+		}
+		w.stack = append(w.stack, children)
+	}
+	return offset, nil
+}
+
+// expand fetches the DagNode l points to and checks it against l.
+func (w *dagWalker) expand(ctx context.Context, l dagLink, leaves bool) ([]dagLink, error) {
+	data, err := w.fetch(ctx, l.cid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DAG node %s: %w", l.cid, err)
+	}
+	node := &api.DagNode{}
+	if err := proto.Unmarshal(data, node); err != nil {
+		return nil, fmt.Errorf("invalid DAG node %s: %w", l.cid, err)
+	}
+	children, size, err := decodeLinks(node.Links, leaves)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DAG node %s: %w", l.cid, err)
+	}
+	if len(children) == 0 || size != l.size {
+		return nil, fmt.Errorf("invalid DAG node %s: its links do not add up to %d bytes", l.cid, l.size)
+	}
+	if w.node != nil {
+		w.node(l.cid)
+	}
+	if w.discover != nil {
+		cids := make([]cid.Cid, len(children))
+		for i, c := range children {
+			cids[i] = c.cid
+		}
+		w.discover(ctx, cids)
+	}
+	return children, nil
+}
@@ -0,0 +1,170 @@
+package node
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/net/context"
+)
+
+func TestDag_BalancedLazyAndShared(t *testing.T) {
+	n := newTestNode(t)
+
+	build := func(chunks [][]byte) *fileManifest {
+		return buildDag(t, n, 3, chunks)
+	}
+
+	var chunks [][]byte
+	for i := 0; i < 10; i++ {
+		chunks = append(chunks, []byte(fmt.Sprintf("chunk %d", i)))
+	}
+	// 10 chunks in nodes of 3 need two levels of DagNodes.
+	m := build(chunks)
+	if m.depth != 2 || m.dagSize != int64(len(bytes.Join(chunks, nil))) {
+		t.Fatalf("got depth %d and size %d", m.depth, m.dagSize)
+	}
+
+	var fetched []cid.Cid
+	fetch := func(ctx context.Context, c cid.Cid) ([]byte, error) {
+		fetched = append(fetched, c)
+		return n.store.Get(c)
+	}
+	iter, _, err := m.chunkIter(context.Background(), fetch, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newFileReader(context.Background(), iter, 1, fetchChunks(fetch))
+	defer r.Close()
+	buf := make([]byte, len(chunks[0]))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatal(err)
+	}
+	// Reading the first chunk only needs the DagNodes on its path, not the
+	// whole tree.
+	if len(fetched) > 5 {
+		t.Fatalf("fetched %d blocks to read the first chunk", len(fetched))
+	}
+	rest, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := append(buf, rest...); !bytes.Equal(got, bytes.Join(chunks, nil)) {
+		t.Fatal("DAG returned the chunks out of order")
+	}
+
+	// A file starting with the same chunks shares the subtrees over them.
+	other := build(append(chunks[:9:9], []byte("different tail")))
+	if !other.links[0].cid.Equals(m.links[0].cid) || other.links[1].cid.Equals(m.links[1].cid) {
+		t.Fatal("files with a common prefix do not share its subtree")
+	}
+
+	// A node whose links do not add up to the size of the link to it is rejected.
+	m.links[0].size++
+	if _, err := m.allBlocks(context.Background(), fetch, nil); err == nil {
+		t.Fatal("expected an error for a link with the wrong size")
+	}
+}
+
+func TestDag_Range(t *testing.T) {
+	n := newTestNode(t)
+
+	var chunks [][]byte
+	for i := 0; i < 10; i++ {
+		chunks = append(chunks, []byte(fmt.Sprintf("chunk %d", i)))
+	}
+	content := bytes.Join(chunks, nil)
+	m := buildDag(t, n, 3, chunks)
+	m.info.Size = m.dagSize
+
+	var fetched int
+	fetch := func(ctx context.Context, c cid.Cid) ([]byte, error) {
+		fetched++
+		return n.store.Get(c)
+	}
+	ctx := context.Background()
+	size := int64(len(content))
+	for offset := int64(0); offset <= size; offset++ {
+		for _, length := range []int64{0, 1, 8, size} {
+			r, _, err := n.newReader(ctx, m, fetch, nil, GetOptions{Offset: offset, Length: length})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := content[offset:]
+			if length > 0 && length < int64(len(want)) {
+				want = want[:length]
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("offset %d, length %d: got %q, want %q", offset, length, got, want)
+			}
+		}
+	}
+
+	// Reading the last byte only fetches the DagNodes on the path to the
+	// last chunk.
+	fetched = 0
+	r, _, err := n.newReader(ctx, m, fetch, nil, GetOptions{Offset: size - 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(r); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	if fetched != m.depth+1 {
+		t.Fatalf("fetched %d blocks to read the last byte", fetched)
+	}
+
+	if _, _, err := n.newReader(ctx, m, fetch, nil, GetOptions{Offset: size + 1}); !errors.Is(err, ErrInvalidRange) {
+		t.Fatalf("got %v for an offset past the end, want ErrInvalidRange", err)
+	}
+}
+
+// buildDag stores chunks under a DAG with the given fan-out.
+// newTestNode returns a node with an empty block store and no network.
+func newTestNode(t *testing.T) *Node {
+	t.Helper()
+	store, err := storage.NewBlockStore(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(store.Close)
+	return &Node{store: store}
+}
+
+func buildDag(t *testing.T, n *Node, fanout int, chunks [][]byte) *fileManifest {
+	t.Helper()
+	b := n.newDagBuilder(fanout)
+	for _, chunk := range chunks {
+		c, err := n.store.Put(chunk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.add(&api.DagLink{Cid: c.String(), Size: uint64(len(chunk))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links, depth, err := b.finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) > fanout {
+		t.Fatalf("root has %d links, more than the fan-out", len(links))
+	}
+	m := &fileManifest{info: FileInfo{Version: manifestVersion}}
+	if err := m.decodeDag(links, uint32(depth)); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
@@ -0,0 +1,224 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// ErrIsDirectory is returned by GetFile for the root of a directory.
+	ErrIsDirectory = errors.New("is a directory")
+	// ErrNotDirectory is returned by ListDirectory for the root of a file.
+	ErrNotDirectory = errors.New("not a directory")
+	// ErrInvalidTree is returned by AddDirectory for entries that do not
+	// form a tree.
+	ErrInvalidTree = errors.New("invalid directory tree")
+)
+
+// TreeEntry is an entry of a directory tree passed to AddDirectory.
+type TreeEntry struct {
+	// Path is relative to the root of the tree, with "/" as separator.
+	Path    string
+	Dir     bool
+	Mode    os.FileMode
+	ModTime time.Time
+	// Content is the content of a file.
+	Content io.Reader
+}
+
+// DirEntry is an entry of a stored directory.
+type DirEntry struct {
+	Name    string
+	Cid     cid.Cid
+	Dir     bool
+	Mode    os.FileMode
+	ModTime time.Time
+	// Size is the size of the file, or of all files below the directory.
+	Size int64
+}
+
+// treeDir is a directory of a tree being added.
+type treeDir struct {
+	entry   *api.DirectoryEntry
+	entries []*api.DirectoryEntry
+	subdirs []*treeDir
+}
+
+// AddDirectory stores a directory tree. next returns the entries of the
+// tree one at a time, every directory before the entries in it, and io.EOF
+// after the last. Files are stored as AddFile would, but only the root of
+// the tree is pinned. opts.Name names the root.
+func (n *Node) AddDirectory(ctx context.Context, next func() (TreeEntry, error), opts AddOptions) (AddResult, error) {
+	n.gcLock.RLock()
+	defer n.gcLock.RUnlock()
+
+	if opts.Encryption.Mode != "" || opts.Erasure.Enabled() {
+		return AddResult{}, errors.New("directories cannot be encrypted or erasure-coded")
+	}
+	if err := ValidateName(opts.Name); err != nil {
+		return AddResult{}, fmt.Errorf("%w: %v", ErrInvalidTree, err)
+	}
+
+	root := &treeDir{}
+	dirs := map[string]*treeDir{"": root}
+	for {
+		e, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return AddResult{}, err
+		}
+		parent, name := path.Split(e.Path)
+		dir := dirs[strings.TrimSuffix(parent, "/")]
+		if name == "" || ValidateName(name) != nil || path.Clean(e.Path) != e.Path {
+			return AddResult{}, fmt.Errorf("%w: bad path %q", ErrInvalidTree, e.Path)
+		}
+		if dir == nil {
+			return AddResult{}, fmt.Errorf("%w: the directory of %s was not added before it", ErrInvalidTree, e.Path)
+		}
+		if slices.ContainsFunc(dir.entries, func(other *api.DirectoryEntry) bool { return other.Name == name }) {
+			return AddResult{}, fmt.Errorf("%w: %s was added twice", ErrInvalidTree, e.Path)
+		}
+
+		entry := &api.DirectoryEntry{Name: name, Directory: e.Dir, Mode: uint32(e.Mode.Perm())}
+		if !e.ModTime.IsZero() {
+			entry.Mtime = e.ModTime.Unix()
+		}
+		dir.entries = append(dir.entries, entry)
+		if e.Dir {
+			sub := &treeDir{entry: entry}
+			dir.subdirs = append(dir.subdirs, sub)
+			dirs[e.Path] = sub
+			continue
+		}
+
+		fileOpts := opts
+		fileOpts.Name, fileOpts.ContentType, fileOpts.Replication = name, "", 0
+		res, err := n.addFile(ctx, e.Content, fileOpts)
+		if err != nil {
+			return AddResult{}, fmt.Errorf("%s: %w", e.Path, err)
+		}
+		entry.Cid = res.Root.String()
+		entry.Size = uint64(res.Size)
+	}
+
+	result, err := n.storeDirectory(ctx, root, opts.Name)
+	if err != nil {
+		return AddResult{}, err
+	}
+	if err := n.store.Pin(result.Root, storage.PinRecursive); err != nil {
+		return AddResult{}, err
+	}
+	if opts.Replication > 1 {
+		if err := n.setReplication(result.Root, opts.Replication); err != nil {
+			return AddResult{}, err
+		}
+	}
+	return result, nil
+}
+
+// storeDirectory stores the manifests of d and the directories below it.
+func (n *Node) storeDirectory(ctx context.Context, d *treeDir, name string) (AddResult, error) {
+	for _, sub := range d.subdirs {
+		res, err := n.storeDirectory(ctx, sub, sub.entry.Name)
+		if err != nil {
+			return AddResult{}, err
+		}
+		sub.entry.Cid = res.Root.String()
+		sub.entry.Size = uint64(res.Size)
+	}
+
+	var size uint64
+	for _, e := range d.entries {
+		size += e.Size
+	}
+	slices.SortFunc(d.entries, func(a, b *api.DirectoryEntry) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	data, err := proto.Marshal(&api.Manifest{
+		Version:   manifestVersion,
+		Size:      size,
+		Name:      name,
+		CreatedAt: time.Now().Unix(),
+		Directory: &api.Directory{Entries: d.entries},
+	})
+	if err != nil {
+		return AddResult{}, err
+	}
+	c, err := n.store.Put(data)
+	if err != nil {
+		return AddResult{}, err
+	}
+	if err := n.dht.Provide(ctx, c, true); err != nil {
+		log.Printf("Error providing directory %s: %v", c, err)
+	}
+	return AddResult{Root: c, Size: int64(size)}, nil
+}
+
+// ListDirectory returns the entries of a directory, fetching its root block
+// from the network if it is not stored locally.
+func (n *Node) ListDirectory(ctx context.Context, root cid.Cid) (FileInfo, []DirEntry, error) {
+	m, err := n.localManifest(root)
+	if err != nil {
+		if _, m, err = n.openSession(ctx, root); err != nil {
+			return FileInfo{}, nil, fmt.Errorf("failed to get directory %s: %w", root, err)
+		}
+	}
+	m.info.Root = root
+	if !m.info.Directory {
+		return m.info, nil, ErrNotDirectory
+	}
+	return m.info, m.dir, nil
+}
+
+// decodeDirectory reads the entries of a directory manifest.
+func (m *fileManifest) decodeDirectory(manifest *api.Manifest) error {
+	entries := manifest.Directory.GetEntries()
+	m.dir = make([]DirEntry, len(entries))
+	for i, e := range entries {
+		if e.GetName() == "" || ValidateName(e.GetName()) != nil {
+			return fmt.Errorf("invalid directory: bad entry name %q", e.GetName())
+		}
+		if i > 0 && e.GetName() <= entries[i-1].GetName() {
+			return errors.New("invalid directory: entries are not sorted and unique")
+		}
+		c, err := cid.Decode(e.GetCid())
+		if err != nil {
+			return fmt.Errorf("invalid directory: %w", err)
+		}
+		m.dir[i] = DirEntry{
+			Name: e.GetName(),
+			Cid:  c,
+			Dir:  e.GetDirectory(),
+			Mode: os.FileMode(e.GetMode()).Perm(),
+			Size: int64(e.GetSize()),
+		}
+		if e.GetMtime() != 0 {
+			m.dir[i].ModTime = time.Unix(e.GetMtime(), 0)
+		}
+	}
+	m.info = FileInfo{
+		Version:   int(manifest.Version),
+		Directory: true,
+		Size:      int64(manifest.Size),
+		Name:      manifest.Name,
+	}
+	if manifest.CreatedAt != 0 {
+		m.info.Created = time.Unix(manifest.CreatedAt, 0)
+	}
+	return ValidateName(manifest.Name)
+}
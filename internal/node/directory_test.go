@@ -0,0 +1,103 @@
+package node
+
+import (
+	"errors"
+	"testing"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDirectory_Blocks(t *testing.T) {
+	n := newTestNode(t)
+	put := func(manifest proto.Message) cid.Cid {
+		data, err := proto.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := n.store.Put(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	chunk, err := n.store.Put([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := put(&api.Manifest{
+		Version: manifestVersion,
+		Links:   []*api.DagLink{{Cid: chunk.String(), Size: 5}},
+		Chunks:  1,
+		Size:    5,
+		Name:    "hello.txt",
+	})
+	sub := put(&api.Manifest{
+		Version: manifestVersion,
+		Size:    5,
+		Name:    "sub",
+		Directory: &api.Directory{Entries: []*api.DirectoryEntry{
+			{Name: "hello.txt", Cid: file.String(), Size: 5},
+		}},
+	})
+	// The same file appears twice in the tree; its blocks are listed once.
+	root := &api.Manifest{
+		Version: manifestVersion,
+		Size:    10,
+		Name:    "root",
+		Directory: &api.Directory{Entries: []*api.DirectoryEntry{
+			{Name: "a.txt", Cid: file.String(), Size: 5, Mode: 0o640, Mtime: 1700000000},
+			{Name: "sub", Cid: sub.String(), Directory: true, Size: 5},
+		}},
+	}
+	data, err := proto.Marshal(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := decodeManifest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.info.Directory || m.info.Size != 10 || m.info.Name != "root" || len(m.dir) != 2 {
+		t.Fatalf("unexpected directory info %+v", m.info)
+	}
+	if e := m.dir[0]; e.Mode != 0o640 || e.ModTime.Unix() != 1700000000 || !e.Cid.Equals(file) {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+	if !equalCids(m.blocks(), []cid.Cid{file, sub}) {
+		t.Fatalf("got blocks %v", m.blocks())
+	}
+
+	blocks, err := m.allBlocks(context.Background(), func(ctx context.Context, c cid.Cid) ([]byte, error) {
+		return n.store.Get(c)
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []cid.Cid{file, chunk, sub}; !equalCids(blocks, want) {
+		t.Fatalf("got all blocks %v, want %v", blocks, want)
+	}
+
+	if _, _, err := n.newReader(context.Background(), m, nil, nil, GetOptions{}); !errors.Is(err, ErrIsDirectory) {
+		t.Fatalf("got %v reading a directory, want ErrIsDirectory", err)
+	}
+
+	for name, entries := range map[string][]*api.DirectoryEntry{
+		"unsorted":  {{Name: "b", Cid: file.String()}, {Name: "a", Cid: file.String()}},
+		"duplicate": {{Name: "a", Cid: file.String()}, {Name: "a", Cid: file.String()}},
+		"path":      {{Name: "a/b", Cid: file.String()}},
+		"empty":     {{Name: "", Cid: file.String()}},
+		"bad CID":   {{Name: "a", Cid: "nope"}},
+	} {
+		data, err := proto.Marshal(&api.Manifest{Version: manifestVersion, Directory: &api.Directory{Entries: entries}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := decodeManifest(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package node
+
+import (
+	"crypto/cipher"
+	"errors"
+	"fmt"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+// KeyMode selects how the keys of an encrypted file are chosen.
+type KeyMode string
+
+const (
+	// FileKey encrypts every chunk with one random key per file.
+	FileKey KeyMode = "file"
+	// ConvergentKey encrypts every chunk with a key derived from its
+	// content, so identical chunks are stored only once across files.
+	ConvergentKey KeyMode = "convergent"
+)
+
+// ErrKeyRequired is returned by GetFile for an encrypted file when no key
+// was given.
+var ErrKeyRequired = errors.New("the file is encrypted and needs a key")
+
+// manifestAD binds a sealed manifest to its purpose, so that it can never be
+// mistaken for a chunk encrypted with the same key.
+var manifestAD = []byte("p2p-storage manifest")
+
+// EncryptOptions controls whether and how AddFile encrypts a file.
+type EncryptOptions struct {
+	// Mode is empty for an unencrypted file.
+	Mode KeyMode
+	// Cipher defaults to storage.DefaultCipher.
+	Cipher storage.Cipher
+}
+
+// Validate checks the options, filling in the default cipher.
+func (o *EncryptOptions) Validate() error {
+	switch o.Mode {
+	case "", FileKey, ConvergentKey:
+	default:
+		return fmt.Errorf("unknown encryption mode %q", o.Mode)
+	}
+	if o.Mode == "" && o.Cipher != "" {
+		return errors.New("a cipher needs an encryption mode")
+	}
+	if o.Cipher == "" {
+		o.Cipher = storage.DefaultCipher
+	}
+	_, err := storage.NewAEAD(o.Cipher, make([]byte, storage.KeySize))
+	return err
+}
+
+// chunkEncrypter encrypts the chunks of one file before they are stored.
+// The file key, returned to the uploader, seals the manifest and, with
+// convergent keys, the key of every chunk in the links to it.
+type chunkEncrypter struct {
+	opts EncryptOptions
+	key  []byte
+	aead cipher.AEAD
+}
+
+func newChunkEncrypter(opts EncryptOptions) (*chunkEncrypter, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	key, err := storage.NewKey()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := storage.NewAEAD(opts.Cipher, key)
+	if err != nil {
+		return nil, err
+	}
+	return &chunkEncrypter{opts: opts, key: key, aead: aead}, nil
+}
+
+// encrypt encrypts a chunk. With convergent keys it also returns the
+// chunk's key, to be sealed into the link to it with sealKey.
+func (e *chunkEncrypter) encrypt(plaintext []byte) ([]byte, []byte, error) {
+	if e.opts.Mode != ConvergentKey {
+		data, err := storage.Seal(e.aead, plaintext, nil, false)
+		return data, nil, err
+	}
+	key := storage.ConvergentKey(plaintext)
+	aead, err := storage.NewAEAD(e.opts.Cipher, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := storage.Seal(aead, plaintext, nil, true)
+	return data, key, err
+}
+
+// sealKey encrypts the key of chunk c with the file key.
+func (e *chunkEncrypter) sealKey(c cid.Cid, key []byte) ([]byte, error) {
+	return storage.Seal(e.aead, key, c.Bytes(), false)
+}
+
+// seal wraps the file's manifest. The DAG and any erasure layout stay in
+// the clear so that nodes without the key can still pin, replicate and
+// repair the file; the file metadata is encrypted.
+func (e *chunkEncrypter) seal(manifest *api.Manifest) (*api.Manifest, error) {
+	encrypted := &api.EncryptedManifest{
+		Cipher:   string(e.opts.Cipher),
+		Erasure:  manifest.Erasure,
+		Links:    manifest.Links,
+		DagDepth: manifest.DagDepth,
+	}
+	inner := proto.Clone(manifest).(*api.Manifest)
+	inner.Erasure, inner.Links, inner.DagDepth = nil, nil, 0
+	data, err := proto.Marshal(inner)
+	if err != nil {
+		return nil, err
+	}
+	if encrypted.Sealed, err = storage.Seal(e.aead, data, manifestAD, false); err != nil {
+		return nil, err
+	}
+	return &api.Manifest{Version: manifest.Version, Encrypted: encrypted}, nil
+}
+
+// open decrypts the manifest of an encrypted file with the file key.
+func (m *fileManifest) open(key []byte) (*fileManifest, error) {
+	aead, err := storage.NewAEAD(m.sealed.cipher, key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := storage.Open(aead, m.sealed.data, manifestAD)
+	if err != nil {
+		return nil, err
+	}
+
+	var opened *fileManifest
+	if m.info.Version >= 2 {
+		inner := &api.Manifest{}
+		if err := proto.Unmarshal(data, inner); err != nil {
+			return nil, fmt.Errorf("invalid sealed manifest: %w", err)
+		}
+		if inner.Version != uint32(m.info.Version) || inner.Encrypted != nil ||
+			len(inner.BlockCids) > 0 || len(inner.Links) > 0 {
+			return nil, errors.New("invalid sealed manifest: it does not match its root")
+		}
+		clone := *m
+		opened = &clone
+		opened.sealed = nil
+		if err := opened.decodeInfo(inner); err != nil {
+			return nil, fmt.Errorf("invalid sealed manifest: %w", err)
+		}
+	} else if opened, err = m.openV1(data); err != nil {
+		return nil, err
+	}
+	opened.info.Encrypted = true
+	opened.cipher = m.sealed.cipher
+	opened.key = key
+	return opened, nil
+}
+
+// openV1 decodes the decrypted manifest of a version 0 or 1 encrypted file,
+// which lists the chunks and their keys itself.
+func (m *fileManifest) openV1(data []byte) (*fileManifest, error) {
+	inner, err := decodeManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sealed manifest: %w", err)
+	}
+	if inner.sealed != nil || inner.info.Version >= 2 {
+		return nil, errors.New("invalid sealed manifest: it does not match its root")
+	}
+	if len(inner.chunkKeys) != 0 && len(inner.chunkKeys) != len(inner.chunks) {
+		return nil, errors.New("invalid sealed manifest: chunk keys do not match the chunks")
+	}
+	if m.erasure != nil {
+		if !equalCids(inner.chunks, m.chunks) {
+			return nil, errors.New("invalid sealed manifest: chunks do not match the erasure layout")
+		}
+		inner.erasure = m.erasure
+	}
+	return inner, nil
+}
+
+// decrypter wraps the fetcher of an opened encrypted file so that it
+// returns plaintext chunks.
+func (m *fileManifest) decrypter(fetch blockFetchFunc) (chunkFetchFunc, error) {
+	fileAEAD, err := storage.NewAEAD(m.cipher, m.key)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[cid.Cid][]byte, len(m.chunkKeys))
+	for i, key := range m.chunkKeys {
+		if len(key) != storage.KeySize {
+			return nil, fmt.Errorf("invalid key for chunk %d", i)
+		}
+		keys[m.chunks[i]] = key
+	}
+
+	return func(ctx context.Context, ref chunkRef) ([]byte, error) {
+		data, err := fetch(ctx, ref.cid)
+		if err != nil {
+			return nil, err
+		}
+		key := keys[ref.cid]
+		if ref.key != nil {
+			if key, err = storage.Open(fileAEAD, ref.key, ref.cid.Bytes()); err != nil {
+				return nil, fmt.Errorf("key of chunk %s: %w", ref.cid, err)
+			}
+		}
+		aead := fileAEAD
+		if key != nil {
+			if aead, err = storage.NewAEAD(m.cipher, key); err != nil {
+				return nil, err
+			}
+		}
+		return storage.Open(aead, data, nil)
+	}, nil
+}
+
+func equalCids(a, b []cid.Cid) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equals(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package node
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestEncryption_ConvergentRoundTrip(t *testing.T) {
+	n := newTestNode(t)
+
+	chunks := [][]byte{[]byte("same chunk"), []byte("other chunk"), []byte("same chunk")}
+	enc, err := newChunkEncrypter(EncryptOptions{Mode: ConvergentKey, Cipher: storage.CipherXChaCha20Poly1305})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A fan-out of 2 puts the chunks below a DagNode.
+	dag := n.newDagBuilder(2)
+	var cids []cid.Cid
+	var size uint64
+	for _, chunk := range chunks {
+		data, key, err := enc.encrypt(chunk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(data, chunk) {
+			t.Fatal("chunk stored in the clear")
+		}
+		c, err := n.store.Put(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cids = append(cids, c)
+		link := &api.DagLink{Cid: c.String(), Size: uint64(len(chunk))}
+		if link.Key, err = enc.sealKey(c, key); err != nil {
+			t.Fatal(err)
+		}
+		if err := dag.add(link); err != nil {
+			t.Fatal(err)
+		}
+		size += link.Size
+	}
+	// Identical plaintext chunks deduplicate under convergent keys.
+	if !cids[0].Equals(cids[2]) || cids[0].Equals(cids[1]) {
+		t.Fatal("convergent encryption did not deduplicate identical chunks")
+	}
+
+	links, depth, err := dag.finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := enc.seal(&api.Manifest{
+		Version:  manifestVersion,
+		Links:    links,
+		DagDepth: uint32(depth),
+		Chunks:   uint64(len(chunks)),
+		Size:     size,
+		Name:     "secret.txt",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := proto.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("secret.txt")) {
+		t.Fatal("file name stored in the clear")
+	}
+	m, err := decodeManifest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Nodes without the key can still list every block.
+	blocks, err := m.allBlocks(context.Background(), func(ctx context.Context, c cid.Cid) ([]byte, error) {
+		return n.store.Get(c)
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := append(append([]cid.Cid(nil), dag.nodes...), cids...); len(blocks) != len(want) {
+		t.Fatalf("got %d blocks without the key, want %d", len(blocks), len(want))
+	}
+
+	fetch := func(ctx context.Context, c cid.Cid) ([]byte, error) {
+		return n.store.Get(c)
+	}
+	ctx := context.Background()
+	if _, _, err := n.newReader(ctx, m, fetch, nil, GetOptions{}); !errors.Is(err, ErrKeyRequired) {
+		t.Fatalf("got %v without a key, want ErrKeyRequired", err)
+	}
+	wrong, err := storage.NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := n.newReader(ctx, m, fetch, nil, GetOptions{Key: wrong}); !errors.Is(err, storage.ErrDecrypt) {
+		t.Fatalf("got %v with the wrong key, want ErrDecrypt", err)
+	}
+
+	r, info, err := n.newReader(ctx, m, fetch, nil, GetOptions{Key: enc.key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Encrypted || info.Chunks != len(chunks) || info.Name != "secret.txt" {
+		t.Fatalf("unexpected file info %+v", info)
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := bytes.Join(chunks, nil); !bytes.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,472 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/file"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"github.com/klauspost/reedsolomon"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/net/context"
+)
+
+const (
+	// maxErasureShards bounds data plus parity shards per stripe, the limit
+	// of Reed-Solomon coding over GF(2^8).
+	maxErasureShards = 256
+	// maxStripeBytes bounds the memory a stripe takes while it is encoded:
+	// its data and parity shards at the largest chunk size.
+	maxStripeBytes = 256 * 1024 * 1024
+	// providerLookupWorkers bounds the provider lookups distributeShards
+	// runs at once.
+	providerLookupWorkers = 16
+	// maxErasureManifestSize bounds the manifest of an erasure-coded file.
+	// Its layout refers to chunks by position, so they are all linked from
+	// the root, which like any block must fit in one protocol message.
+	maxErasureManifestSize = 16 * 1024 * 1024
+	// erasureChunkBytes is a generous estimate of what one chunk adds to
+	// the manifest: its link, with the key of an encrypted chunk, and its
+	// size in the layout.
+	erasureChunkBytes = 128
+)
+
+// ErrErasureTooLarge is returned when an erasure-coded file has too many
+// blocks for its manifest to fit in a block.
+var ErrErasureTooLarge = errors.New("file has too many blocks to be erasure-coded")
+
+// ErasureParams selects Reed-Solomon erasure coding for a file. Every
+// stripe of DataShards chunks gets ParityShards parity blocks, and any
+// DataShards of those blocks are enough to recover the stripe.
+type ErasureParams struct {
+	DataShards   int
+	ParityShards int
+}
+
+// Enabled reports whether erasure coding was asked for.
+func (p ErasureParams) Enabled() bool {
+	return p.DataShards != 0 || p.ParityShards != 0
+}
+
+// Validate checks that the parameters describe a usable code.
+func (p ErasureParams) Validate() error {
+	if p.DataShards < 1 || p.ParityShards < 1 {
+		return errors.New("erasure coding needs at least one data and one parity shard")
+	}
+	if p.DataShards+p.ParityShards > maxErasureShards {
+		return fmt.Errorf("erasure coding supports at most %d shards per stripe", maxErasureShards)
+	}
+	return nil
+}
+
+// ValidateChunker checks that stripes of the chunks chunker produces can be
+// encoded within maxStripeBytes.
+func (p ErasureParams) ValidateChunker(chunker file.Params) error {
+	if size := (p.DataShards + p.ParityShards) * chunker.ChunkLimit(); size > maxStripeBytes {
+		return fmt.Errorf("erasure coding %d+%d shards of up to %d bytes needs %d MiB per stripe, more than the %d MiB allowed; use smaller chunks or fewer shards",
+			p.DataShards, p.ParityShards, chunker.ChunkLimit(), size>>20, maxStripeBytes>>20)
+	}
+	return nil
+}
+
+// stripeEncoder collects the chunks of a file into stripes and stores
+// parity blocks for each of them as AddFile goes.
+type stripeEncoder struct {
+	n       *Node
+	enc     reedsolomon.Encoder
+	params  ErasureParams
+	pending [][]byte
+	layout  *api.ErasureLayout
+	// parity lists the parity blocks stored so far.
+	parity []cid.Cid
+	// manifestSize estimates the size of the manifest so far.
+	manifestSize int
+}
+
+func (n *Node) newStripeEncoder(p ErasureParams, chunker file.Params) (*stripeEncoder, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if err := p.ValidateChunker(chunker); err != nil {
+		return nil, err
+	}
+	enc, err := reedsolomon.New(p.DataShards, p.ParityShards)
+	if err != nil {
+		return nil, err
+	}
+	return &stripeEncoder{
+		n:      n,
+		enc:    enc,
+		params: p,
+		layout: &api.ErasureLayout{
+			DataShards:   uint32(p.DataShards),
+			ParityShards: uint32(p.ParityShards),
+		},
+	}, nil
+}
+
+// add appends a chunk to the current stripe, encoding the stripe once it is
+// full. The chunk is copied, so the caller may reuse data.
+func (e *stripeEncoder) add(data []byte) error {
+	e.manifestSize += erasureChunkBytes
+	if e.manifestSize > maxErasureManifestSize {
+		return fmt.Errorf("%w: the manifest of %d chunks in %d+%d stripes would exceed %d MiB; use larger chunks",
+			ErrErasureTooLarge, len(e.layout.ChunkSizes)+1, e.params.DataShards, e.params.ParityShards, maxErasureManifestSize>>20)
+	}
+	e.layout.ChunkSizes = append(e.layout.ChunkSizes, uint64(len(data)))
+	e.pending = append(e.pending, append([]byte(nil), data...))
+	if len(e.pending) < e.params.DataShards {
+		return nil
+	}
+	return e.flush()
+}
+
+// flush encodes the current stripe, padding a short one with zero shards.
+func (e *stripeEncoder) flush() error {
+	if len(e.pending) == 0 {
+		return nil
+	}
+	shardSize := 0
+	for _, d := range e.pending {
+		shardSize = max(shardSize, len(d))
+	}
+
+	// The pending chunks are the encoder's own copies, so they are padded
+	// in place.
+	shards := make([][]byte, e.params.DataShards+e.params.ParityShards)
+	for i := range shards {
+		if i < len(e.pending) {
+			shards[i] = append(e.pending[i], make([]byte, shardSize-len(e.pending[i]))...)
+		} else {
+			shards[i] = make([]byte, shardSize)
+		}
+	}
+	if shardSize > 0 {
+		if err := e.enc.Encode(shards); err != nil {
+			return err
+		}
+	}
+
+	stripe := &api.ErasureStripe{ShardSize: uint64(shardSize)}
+	for _, parity := range shards[e.params.DataShards:] {
+		c, err := e.n.store.Put(parity)
+		if err != nil {
+			return err
+		}
+		e.parity = append(e.parity, c)
+		stripe.ParityCids = append(stripe.ParityCids, c.String())
+		e.manifestSize += len(stripe.ParityCids[len(stripe.ParityCids)-1]) + 2
+	}
+	e.layout.Stripes = append(e.layout.Stripes, stripe)
+	clear(e.pending)
+	e.pending = e.pending[:0]
+	return nil
+}
+
+// stripeRecovery wraps a block fetcher for an erasure-coded file. A chunk
+// that cannot be fetched is rebuilt from any DataShards other blocks of its
+// stripe. Rebuilt stripes are kept briefly so that their other chunks are
+// not rebuilt again.
+type stripeRecovery struct {
+	n      *Node
+	m      *fileManifest
+	direct blockFetchFunc
+	index  map[cid.Cid]int
+
+	mu      sync.Mutex
+	stripes map[int]*recoveredStripe
+}
+
+type recoveredStripe struct {
+	done   chan struct{}
+	chunks [][]byte
+	err    error
+}
+
+// recoveredStripesKept bounds how many rebuilt stripes are held in memory.
+const recoveredStripesKept = 2
+
+func (n *Node) newStripeRecovery(m *fileManifest, fetch blockFetchFunc) *stripeRecovery {
+	index := make(map[cid.Cid]int, len(m.chunks))
+	for i, c := range m.chunks {
+		if _, ok := index[c]; !ok {
+			index[c] = i
+		}
+	}
+	return &stripeRecovery{
+		n:       n,
+		m:       m,
+		direct:  fetch,
+		index:   index,
+		stripes: make(map[int]*recoveredStripe),
+	}
+}
+
+func (r *stripeRecovery) fetch(ctx context.Context, c cid.Cid) ([]byte, error) {
+	data, err := r.direct(ctx, c)
+	if err == nil || ctx.Err() != nil {
+		return data, err
+	}
+	i, ok := r.index[c]
+	if !ok {
+		return nil, err
+	}
+	k := r.m.erasure.dataShards
+	log.Printf("Chunk %s is unavailable (%v), rebuilding stripe %d", c, err, i/k)
+	chunks, rerr := r.recover(ctx, i/k)
+	if rerr != nil {
+		return nil, fmt.Errorf("%w; rebuilding its stripe failed: %v", err, rerr)
+	}
+	return chunks[i%k], nil
+}
+
+// recover rebuilds the data chunks of stripe s, sharing the work between
+// concurrent callers.
+func (r *stripeRecovery) recover(ctx context.Context, s int) ([][]byte, error) {
+	r.mu.Lock()
+	rs, ok := r.stripes[s]
+	if !ok {
+		rs = &recoveredStripe{done: make(chan struct{})}
+		r.stripes[s] = rs
+		for old := range r.stripes {
+			if old <= s-recoveredStripesKept {
+				delete(r.stripes, old)
+			}
+		}
+		go func() {
+			rs.chunks, rs.err = r.rebuild(ctx, s)
+			close(rs.done)
+		}()
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-rs.done:
+		return rs.chunks, rs.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// rebuild fetches enough blocks of stripe s to reconstruct its data chunks,
+// verifies them against their CIDs and caches them.
+func (r *stripeRecovery) rebuild(ctx context.Context, s int) ([][]byte, error) {
+	layout := r.m.erasure
+	k, m := layout.dataShards, layout.parityShards
+	shardSize := layout.stripes[s].shardSize
+	first := s * k
+	numData := min(k, len(r.m.chunks)-first)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The zero shards that complete a short stripe are known without
+	// fetching anything.
+	shards := make([][]byte, k+m)
+	have := 0
+	for i := numData; i < k; i++ {
+		shards[i] = make([]byte, shardSize)
+		have++
+	}
+
+	type result struct {
+		i    int
+		data []byte
+		err  error
+	}
+	results := make(chan result, k+m)
+	wanted := 0
+	for i := 0; i < k+m; i++ {
+		if shards[i] != nil {
+			continue
+		}
+		var c cid.Cid
+		if i < k {
+			c = r.m.chunks[first+i]
+		} else {
+			c = layout.stripes[s].parity[i-k]
+		}
+		wanted++
+		go func(i int, c cid.Cid) {
+			data, err := r.direct(ctx, c)
+			results <- result{i, data, err}
+		}(i, c)
+	}
+
+	for ; wanted > 0 && have < k; wanted-- {
+		res := <-results
+		if res.err != nil || len(res.data) > shardSize {
+			continue
+		}
+		shard := make([]byte, shardSize)
+		copy(shard, res.data)
+		shards[res.i] = shard
+		have++
+	}
+	if have < k {
+		return nil, fmt.Errorf("only %d of the %d blocks needed are available", have, k)
+	}
+	if shardSize > 0 {
+		if err := reconstructData(k, m, shards); err != nil {
+			return nil, err
+		}
+	}
+
+	chunks := make([][]byte, numData)
+	for i := range chunks {
+		c := r.m.chunks[first+i]
+		chunks[i] = shards[i][:layout.chunkSizes[first+i]]
+		if err := storage.Verify(c, chunks[i]); err != nil {
+			return nil, fmt.Errorf("rebuilt chunk %s: %w", c, err)
+		}
+		if err := r.n.store.PutCachedAs(c, chunks[i]); err != nil {
+			log.Printf("Error caching rebuilt chunk %s: %v", c, err)
+		}
+	}
+	return chunks, nil
+}
+
+func reconstructData(k, m int, shards [][]byte) error {
+	enc, err := reedsolomon.New(k, m)
+	if err != nil {
+		return err
+	}
+	return enc.ReconstructData(shards)
+}
+
+// distributeShards spreads the blocks of an erasure-coded file over
+// distinct peers: every block that no other peer provides is pushed to a
+// peer that does not yet hold another block of the same stripe, so that
+// losing one peer costs each stripe at most one block.
+func (n *Node) distributeShards(ctx context.Context, root cid.Cid, m *fileManifest) {
+	candidates := n.replicaCandidates(ctx, root, map[peer.ID]bool{n.Host.ID(): true})
+	if len(candidates) == 0 {
+		log.Printf("No peers to distribute the shards of %s to", root)
+		return
+	}
+
+	// Without the manifest no stripe can be read, so it is kept by as many
+	// peers as a stripe may lose blocks, plus one.
+	have := make(map[peer.ID]bool)
+	for _, p := range n.otherProviders(ctx, root) {
+		have[p] = true
+	}
+	for _, p := range candidates {
+		if len(have) > m.erasure.parityShards {
+			break
+		}
+		if have[p] {
+			continue
+		}
+		if err := n.pushReplica(ctx, p, root, []cid.Cid{root}, true); err != nil {
+			log.Printf("Failed to push manifest %s to %s: %v", root, p, err)
+			n.peers.failed(p)
+			continue
+		}
+		have[p] = true
+	}
+
+	k := m.erasure.dataShards
+	stripeShards := func(s int) []cid.Cid {
+		end := min((s+1)*k, len(m.chunks))
+		return append(append([]cid.Cid(nil), m.chunks[s*k:end]...), m.erasure.stripes[s].parity...)
+	}
+	var all []cid.Cid
+	for s := range m.erasure.stripes {
+		all = append(all, stripeShards(s)...)
+	}
+	providers := n.otherProvidersOf(ctx, all)
+
+	pushed, missing := 0, 0
+	for s := range m.erasure.stripes {
+		used := make(map[peer.ID]bool)
+		var unplaced []cid.Cid
+		for _, c := range stripeShards(s) {
+			holders := providers[c]
+			for _, p := range holders {
+				used[p] = true
+			}
+			if len(holders) == 0 {
+				unplaced = append(unplaced, c)
+			}
+		}
+
+		for i, c := range unplaced {
+			placed := false
+			for j := range candidates {
+				// Rotate the starting peer so that stripes do not all land
+				// on the same few peers.
+				p := candidates[(s+i+j)%len(candidates)]
+				if used[p] {
+					continue
+				}
+				if err := n.pushReplica(ctx, p, c, []cid.Cid{c}, true); err != nil {
+					log.Printf("Failed to push shard %s to %s: %v", c, p, err)
+					n.peers.failed(p)
+					continue
+				}
+				used[p] = true
+				placed = true
+				pushed++
+				break
+			}
+			if !placed {
+				missing++
+			}
+		}
+	}
+	if pushed > 0 || missing > 0 {
+		log.Printf("Distributed %d shards of %s; %d still have no other holder", pushed, root, missing)
+	}
+}
+
+// otherProvidersOf looks up the other providers of every CID in cids,
+// running at most providerLookupWorkers lookups at a time.
+func (n *Node) otherProvidersOf(ctx context.Context, cids []cid.Cid) map[cid.Cid][]peer.ID {
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		out = make(map[cid.Cid][]peer.ID, len(cids))
+	)
+	work := make(chan cid.Cid)
+	for i := 0; i < min(providerLookupWorkers, len(cids)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range work {
+				holders := n.otherProviders(ctx, c)
+				mu.Lock()
+				out[c] = holders
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, c := range cids {
+		work <- c
+	}
+	close(work)
+	wg.Wait()
+	return out
+}
+
+// otherProviders returns the peers other than this node that provide c.
+func (n *Node) otherProviders(ctx context.Context, c cid.Cid) []peer.ID {
+	ctx, cancel := context.WithTimeout(ctx, replicaLookupTimeout)
+	defer cancel()
+	providers, err := n.dht.FindProviders(ctx, c)
+	if err != nil {
+		log.Printf("Error finding providers of %s: %v", c, err)
+		return nil
+	}
+	var others []peer.ID
+	for _, p := range providers {
+		if p.ID != n.Host.ID() {
+			others = append(others, p.ID)
+		}
+	}
+	return others
+}
@@ -0,0 +1,147 @@
+package node
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/Yashh56/p2p-storage/internal/file"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/net/context"
+)
+
+func TestErasure_RebuildsMissingChunks(t *testing.T) {
+	n := newTestNode(t)
+
+	// 10 chunks in stripes of 4 leaves a short last stripe.
+	want := make([]byte, 10*1000-123)
+	rand.New(rand.NewSource(1)).Read(want)
+	params := file.Params{Strategy: file.FixedSize, Size: 1000}
+	chunker, err := file.NewChunker(bytes.NewReader(want), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := n.newStripeEncoder(ErasureParams{DataShards: 4, ParityShards: 2}, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &fileManifest{}
+	for {
+		data, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := n.store.Put(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.chunks = append(m.chunks, c)
+		if err := enc.add(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.flush(); err != nil {
+		t.Fatal(err)
+	}
+	m.erasure, err = decodeErasureLayout(enc.layout, len(m.chunks))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Lose two blocks of every stripe, the most the code can tolerate.
+	lost := map[cid.Cid]bool{
+		m.chunks[0]: true, m.chunks[3]: true,
+		m.chunks[4]: true, m.erasure.stripes[1].parity[0]: true,
+		m.chunks[8]: true, m.chunks[9]: true,
+	}
+	fetch := func(ctx context.Context, c cid.Cid) ([]byte, error) {
+		if lost[c] {
+			return nil, errors.New("lost")
+		}
+		return n.store.Get(c)
+	}
+
+	r := newFileReader(context.Background(), cidIter(m.chunks), prefetchWindow, fetchChunks(n.newStripeRecovery(m, fetch).fetch))
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("rebuilt file differs from the original")
+	}
+
+	// Fetching the file for a pin rebuilds the lost chunks and skips the
+	// lost parity block.
+	pinner := newTestNode(t)
+	// Like a session, the pinner caches every block it receives.
+	fetchAndCache := func(ctx context.Context, c cid.Cid) ([]byte, error) {
+		data, err := fetch(ctx, c)
+		if err == nil {
+			_, err = pinner.store.PutCached(data)
+		}
+		return data, err
+	}
+	if err := pinner.fetchBlocks(context.Background(), m, pinner.localFirst(fetchAndCache), nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range m.chunks {
+		if ok, _ := pinner.store.Has(c); !ok {
+			t.Fatalf("chunk %s was not fetched", c)
+		}
+	}
+	for s, stripe := range m.erasure.stripes {
+		for _, c := range stripe.parity {
+			if ok, _ := pinner.store.Has(c); ok == lost[c] {
+				t.Fatalf("stripe %d: parity block %s stored: %v", s, c, ok)
+			}
+		}
+	}
+
+	// A third lost block in a stripe cannot be recovered.
+	lost[m.chunks[1]] = true
+	r = newFileReader(context.Background(), cidIter(m.chunks), prefetchWindow, fetchChunks(n.newStripeRecovery(m, fetch).fetch))
+	defer r.Close()
+	if _, err := io.ReadAll(r); err == nil {
+		t.Fatal("expected an error with three blocks of a stripe lost")
+	}
+}
+
+func TestErasure_StripeMemoryBound(t *testing.T) {
+	big := file.Params{Strategy: file.FixedSize, Size: 16 * 1024 * 1024}
+	if err := (ErasureParams{DataShards: 12, ParityShards: 4}).ValidateChunker(big); err != nil {
+		t.Fatalf("12+4 stripes of 16 MiB chunks: %v", err)
+	}
+	if err := (ErasureParams{DataShards: 200, ParityShards: 56}).ValidateChunker(big); err == nil {
+		t.Fatal("expected 200+56 stripes of 16 MiB chunks to be rejected")
+	}
+	if err := (ErasureParams{DataShards: 200, ParityShards: 56}).ValidateChunker(file.DefaultCDCParams); err == nil {
+		t.Fatal("expected the maximum content-defined chunk size to count")
+	}
+	if err := (ErasureParams{DataShards: 200, ParityShards: 56}).ValidateChunker(file.DefaultParams); err != nil {
+		t.Fatalf("200+56 stripes of 1 MiB chunks: %v", err)
+	}
+}
+
+func TestErasure_ManifestBound(t *testing.T) {
+	n := newTestNode(t)
+	enc, err := n.newStripeEncoder(ErasureParams{DataShards: 4, ParityShards: 2}, file.DefaultParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.add([]byte("first")); err != nil {
+		t.Fatal(err)
+	}
+	enc.manifestSize = maxErasureManifestSize - erasureChunkBytes
+	if err := enc.add([]byte("fits")); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.add([]byte("too many")); !errors.Is(err, ErrErasureTooLarge) {
+		t.Fatalf("got %v, want %v", err, ErrErasureTooLarge)
+	}
+}
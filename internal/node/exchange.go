@@ -0,0 +1,339 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/p2p"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-msgio/pbio"
+	"golang.org/x/net/context"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// blockServeRate and blockServeBurst limit how many blocks per second a
+	// single peer may request from us over the v2 protocol.
+	blockServeRate  = 200
+	blockServeBurst = 400
+	// maxTrackedLimiters bounds the per-peer limiter table; idle entries are
+	// dropped once it is exceeded.
+	maxTrackedLimiters = 1024
+	// maxBlocksPerRequest bounds how many blocks are asked of a peer in one
+	// BlockRequest.
+	maxBlocksPerRequest = 32
+)
+
+var (
+	errBlockNotFound = errors.New("peer does not have the block")
+	errRateLimited   = errors.New("peer is rate limiting block requests")
+)
+
+// blockLimiter hands out a rate limiter per requesting peer.
+type blockLimiter struct {
+	mu       sync.Mutex
+	limiters map[peer.ID]*rate.Limiter
+}
+
+func newBlockLimiter() *blockLimiter {
+	return &blockLimiter{limiters: make(map[peer.ID]*rate.Limiter)}
+}
+
+func (l *blockLimiter) allow(p peer.ID) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lim, ok := l.limiters[p]
+	if !ok {
+		if len(l.limiters) >= maxTrackedLimiters {
+			// A limiter with a full bucket has been idle long enough to
+			// forget; recreating it later gives the same result.
+			for id, other := range l.limiters {
+				if other.Tokens() >= blockServeBurst {
+					delete(l.limiters, id)
+				}
+			}
+		}
+		lim = rate.NewLimiter(blockServeRate, blockServeBurst)
+		l.limiters[p] = lim
+	}
+	return lim.Allow()
+}
+
+// acceptBlock verifies a block received from a peer and caches it locally.
+// A peer's bytes are never trusted: a block is only accepted if it hashes to
+// the CID we asked for, and a peer that serves one that does not is penalised.
+func (n *Node) acceptBlock(from peer.ID, c cid.Cid, data []byte) error {
+	if err := storage.Verify(c, data); err != nil {
+		log.Printf("Peer %s served a corrupt block: %v", from, err)
+		n.peers.misbehaved(from)
+		return err
+	}
+	if err := n.store.PutCachedAs(c, data); err != nil {
+		log.Printf("Error caching block %s: %v", c, err)
+	}
+	return nil
+}
+
+// blockWant is a block a caller is waiting to receive from a peer.
+type blockWant struct {
+	ctx  context.Context
+	cid  string
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// blockRequester batches the blocks requested from each peer: blocks wanted
+// from a peer while a request to it is in flight are sent together in its
+// next BlockRequest.
+type blockRequester struct {
+	n      *Node
+	nextID atomic.Uint64
+
+	mu sync.Mutex
+	// queues holds the wants not yet sent to each peer. A peer has an
+	// entry while a goroutine is sending its requests.
+	queues map[peer.ID][]*blockWant
+}
+
+func newBlockRequester(n *Node) *blockRequester {
+	return &blockRequester{n: n, queues: make(map[peer.ID][]*blockWant)}
+}
+
+// request fetches a single block from a peer, preferring the v2 protocol
+// and falling back to v1 for peers that do not speak it.
+func (r *blockRequester) request(ctx context.Context, p peer.ID, cidStr string) ([]byte, error) {
+	w := &blockWant{ctx: ctx, cid: cidStr, done: make(chan struct{})}
+
+	r.mu.Lock()
+	q, sending := r.queues[p]
+	r.queues[p] = append(q, w)
+	r.mu.Unlock()
+	if !sending {
+		go r.send(p)
+	}
+
+	select {
+	case <-w.done:
+		return w.data, w.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// send requests the queued wants of p, up to maxBlocksPerRequest at a time,
+// until none are left.
+func (r *blockRequester) send(p peer.ID) {
+	for {
+		r.mu.Lock()
+		q := r.queues[p]
+		if len(q) == 0 {
+			delete(r.queues, p)
+			r.mu.Unlock()
+			return
+		}
+		var batch []*blockWant
+		for len(q) > 0 && len(batch) < maxBlocksPerRequest {
+			// Callers that gave up are not asked for.
+			if w := q[0]; w.ctx.Err() == nil {
+				batch = append(batch, w)
+			}
+			q = q[1:]
+		}
+		r.queues[p] = q
+		r.mu.Unlock()
+
+		if len(batch) > 0 {
+			r.sendBatch(p, batch)
+		}
+	}
+}
+
+// sendBatch requests a batch of blocks from p and hands every want its
+// block or error.
+func (r *blockRequester) sendBatch(p peer.ID, batch []*blockWant) {
+	defer func() {
+		for _, w := range batch {
+			close(w.done)
+		}
+	}()
+	fail := func(err error) {
+		for _, w := range batch {
+			if w.data == nil && w.err == nil {
+				w.err = err
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), blockRequestTimeout)
+	defer cancel()
+	s, err := r.n.Host.NewStream(ctx, p, p2p.BlockProtocolV2ID, p2p.BlockProtocolID)
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer s.Close()
+	s.SetDeadline(time.Now().Add(blockRequestTimeout))
+
+	if s.Protocol() == p2p.BlockProtocolID {
+		// A v1 stream carries a single block.
+		for i, w := range batch {
+			if i > 0 {
+				if s, err = r.n.Host.NewStream(ctx, p, p2p.BlockProtocolID); err != nil {
+					fail(err)
+					return
+				}
+				defer s.Close()
+				s.SetDeadline(time.Now().Add(blockRequestTimeout))
+			}
+			w.data, w.err = requestBlockV1(s, w.cid)
+		}
+		return
+	}
+
+	cids := make([]string, len(batch))
+	for i, w := range batch {
+		cids[i] = w.cid
+	}
+	results, err := requestBlocksV2(s, r.nextID.Add(1), cids)
+	if err != nil {
+		fail(err)
+		return
+	}
+	for i, w := range batch {
+		w.data, w.err = results[i].data, results[i].err
+	}
+}
+
+// requestBlockV1 writes the CID and reads the block until EOF. An empty
+// reply is the only way a v1 peer can signal that it does not have a block.
+func requestBlockV1(s network.Stream, cidStr string) ([]byte, error) {
+	if _, err := s.Write([]byte(cidStr)); err != nil {
+		return nil, err
+	}
+	s.CloseWrite()
+
+	data, err := io.ReadAll(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errBlockNotFound
+	}
+	return data, nil
+}
+
+// blockResult is the block, or the reason the peer did not serve it, for
+// one CID of a BlockRequest.
+type blockResult struct {
+	data []byte
+	err  error
+}
+
+// requestBlocksV2 asks for several blocks in one request on a v2 stream and
+// returns the result of every CID in the order requested. An error means the
+// exchange itself failed.
+func requestBlocksV2(s network.Stream, id uint64, cids []string) ([]blockResult, error) {
+	w := pbio.NewDelimitedWriter(s)
+	if err := w.WriteMsg(&api.BlockRequest{Id: id, Cids: cids}); err != nil {
+		return nil, err
+	}
+	s.CloseWrite()
+
+	r := pbio.NewDelimitedReader(s, p2p.MaxMessageSize)
+	defer r.Close()
+
+	results := make([]blockResult, len(cids))
+	for i, want := range cids {
+		resp := &api.BlockResponse{}
+		if err := r.ReadMsg(resp); err != nil {
+			return nil, err
+		}
+		if resp.GetRequestId() != id || resp.GetCid() != want {
+			return nil, fmt.Errorf("unexpected response for %s in request %d", resp.GetCid(), resp.GetRequestId())
+		}
+		switch resp.GetStatus() {
+		case api.BlockStatus_BLOCK_STATUS_OK:
+			results[i].data = resp.GetData()
+		case api.BlockStatus_BLOCK_STATUS_NOT_FOUND:
+			results[i].err = errBlockNotFound
+		case api.BlockStatus_BLOCK_STATUS_RATE_LIMITED:
+			results[i].err = errRateLimited
+		default:
+			results[i].err = fmt.Errorf("peer failed to serve block %s: %s: %s", want, resp.GetStatus(), resp.GetError())
+		}
+	}
+	return results, nil
+}
+
+// handleBlockStreamV2 serves BlockRequests until the requester closes its
+// side of the stream, answering every CID with a status.
+func (n *Node) handleBlockStreamV2(s network.Stream) {
+	defer s.Close()
+	remote := s.Conn().RemotePeer()
+
+	r := pbio.NewDelimitedReader(s, p2p.MaxMessageSize)
+	defer r.Close()
+	w := pbio.NewDelimitedWriter(s)
+
+	for {
+		req := &api.BlockRequest{}
+		if err := r.ReadMsg(req); err != nil {
+			if err != io.EOF {
+				log.Printf("Error reading block request from %s: %v", remote, err)
+				s.Reset()
+			}
+			return
+		}
+
+		for _, cidStr := range req.GetCids() {
+			resp := n.serveBlock(remote, cidStr)
+			resp.RequestId = req.GetId()
+			if err := w.WriteMsg(resp); err != nil {
+				log.Printf("Error writing block response to %s: %v", remote, err)
+				s.Reset()
+				return
+			}
+		}
+	}
+}
+
+// serveBlock looks up one requested block and builds the response for it.
+func (n *Node) serveBlock(remote peer.ID, cidStr string) *api.BlockResponse {
+	resp := &api.BlockResponse{Cid: cidStr}
+
+	if !n.limiter.allow(remote) {
+		resp.Status = api.BlockStatus_BLOCK_STATUS_RATE_LIMITED
+		return resp
+	}
+
+	c, err := cid.Decode(cidStr)
+	if err != nil {
+		resp.Status = api.BlockStatus_BLOCK_STATUS_BAD_REQUEST
+		resp.Error = err.Error()
+		return resp
+	}
+
+	data, err := n.store.Get(c)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		resp.Status = api.BlockStatus_BLOCK_STATUS_NOT_FOUND
+	case err != nil:
+		log.Printf("Error getting block from store: %v", err)
+		resp.Status = api.BlockStatus_BLOCK_STATUS_INTERNAL_ERROR
+		resp.Error = "failed to read block"
+	default:
+		resp.Data = data
+	}
+	return resp
+}
@@ -0,0 +1,132 @@
+package node
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/Yashh56/p2p-storage/internal/p2p"
+	"github.com/ipfs/go-cid"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/multiformats/go-multihash"
+	"golang.org/x/net/context"
+	"golang.org/x/time/rate"
+)
+
+// newExchangeNode returns a node on mn that serves and requests blocks.
+func newExchangeNode(t *testing.T, mn mocknet.Mocknet) *Node {
+	t.Helper()
+	h, err := mn.GenPeer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := newTestNode(t)
+	n.Host, n.peers, n.limiter = h, newPeerTracker(), newBlockLimiter()
+	n.requests = newBlockRequester(n)
+	n.setupBlockRequestHandler()
+	return n
+}
+
+func TestExchange_BatchesAndFallsBack(t *testing.T) {
+	mn := mocknet.New()
+	defer mn.Close()
+	client := newExchangeNode(t, mn)
+	server := newExchangeNode(t, mn)
+	v1 := newExchangeNode(t, mn)
+	v1.Host.RemoveStreamHandler(p2p.BlockProtocolV2ID)
+	if err := mn.LinkAll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mn.ConnectAllButSelf(); err != nil {
+		t.Fatal(err)
+	}
+
+	var blocks [][]byte
+	var cids []cid.Cid
+	for _, data := range []string{"one", "two", "three"} {
+		blocks = append(blocks, []byte(data))
+		c, err := server.store.Put([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := v1.store.Put([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		cids = append(cids, c)
+	}
+	missing, err := client.store.Put([]byte("missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Wants queued for a peer go out together in one request.
+	fetchAll := func(n *Node) []*blockWant {
+		r := client.requests
+		var wants []*blockWant
+		for _, c := range append(cids, missing) {
+			wants = append(wants, &blockWant{ctx: context.Background(), cid: c.String(), done: make(chan struct{})})
+		}
+		r.mu.Lock()
+		r.queues[n.Host.ID()] = wants
+		r.mu.Unlock()
+		r.send(n.Host.ID())
+		return wants
+	}
+	check := func(name string, wants []*blockWant) {
+		t.Helper()
+		for i, w := range wants[:len(blocks)] {
+			if w.err != nil || !bytes.Equal(w.data, blocks[i]) {
+				t.Fatalf("%s: block %d: got %q, %v", name, i, w.data, w.err)
+			}
+		}
+		if w := wants[len(blocks)]; !errors.Is(w.err, errBlockNotFound) {
+			t.Fatalf("%s: missing block: got %v, want %v", name, w.err, errBlockNotFound)
+		}
+	}
+
+	check("v2", fetchAll(server))
+	if got := client.requests.nextID.Load(); got != 1 {
+		t.Fatalf("sent %d v2 requests, want 1", got)
+	}
+	check("v1", fetchAll(v1))
+	if got := client.requests.nextID.Load(); got != 1 {
+		t.Fatalf("v1 peer was sent v2 requests")
+	}
+
+	// A single request through the public path.
+	data, err := client.requests.request(context.Background(), server.Host.ID(), cids[1].String())
+	if err != nil || !bytes.Equal(data, blocks[1]) {
+		t.Fatalf("got %q, %v", data, err)
+	}
+	if got := client.requests.nextID.Load(); got != 2 {
+		t.Fatalf("request id %d, want 2", got)
+	}
+
+	// A peer whose limiter is exhausted is told to back off.
+	server.limiter.mu.Lock()
+	server.limiter.limiters[client.Host.ID()] = rate.NewLimiter(0, 0)
+	server.limiter.mu.Unlock()
+	if _, err := client.requests.request(context.Background(), server.Host.ID(), cids[0].String()); !errors.Is(err, errRateLimited) {
+		t.Fatalf("got %v, want %v", err, errRateLimited)
+	}
+}
+
+func TestAcceptBlock_StoresUnderItsCID(t *testing.T) {
+	n := newTestNode(t)
+	n.peers = newPeerTracker()
+
+	data := []byte("leaf")
+	c, err := cid.V1Builder{Codec: cid.Raw, MhType: multihash.SHA2_512}.Sum(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.acceptBlock("peer", c, []byte("other")); err == nil {
+		t.Fatal("accepted a block that does not match its CID")
+	}
+	if err := n.acceptBlock("peer", c, data); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := n.store.Has(c); !ok {
+		t.Fatalf("accepted block not stored under %s: %v", c, err)
+	}
+}
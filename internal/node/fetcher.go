@@ -0,0 +1,253 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"golang.org/x/net/context"
+)
+
+const (
+	// blockRequestTimeout bounds a single block request to a single peer.
+	blockRequestTimeout = 30 * time.Second
+	// maxBlockAttempts is the number of peers tried for a block before giving up.
+	maxBlockAttempts = 5
+	// latencySmoothing is the weight of the newest sample in a peer's latency average.
+	latencySmoothing = 0.3
+	// basePenalty is how long a peer is deprioritised after its first failure.
+	// It doubles with each consecutive failure up to maxPenalty.
+	basePenalty = 2 * time.Second
+	maxPenalty  = 2 * time.Minute
+)
+
+// peerStats is what the scheduler remembers about a peer's block service.
+type peerStats struct {
+	latency      time.Duration
+	inFlight     int
+	failures     int
+	penaltyUntil time.Time
+}
+
+// peerTracker records request outcomes per peer so that block requests
+// favour fast, reliable peers. It is shared by all downloads on a node.
+type peerTracker struct {
+	mu    sync.Mutex
+	peers map[peer.ID]*peerStats
+}
+
+func newPeerTracker() *peerTracker {
+	return &peerTracker{peers: make(map[peer.ID]*peerStats)}
+}
+
+func (t *peerTracker) stats(p peer.ID) *peerStats {
+	s, ok := t.peers[p]
+	if !ok {
+		s = &peerStats{}
+		t.peers[p] = s
+	}
+	return s
+}
+
+// rank orders candidates from most to least preferred. Penalised peers go
+// last, the rest by expected wait: smoothed latency scaled by current load.
+// Peers we have never used are tried before slow ones but after fast ones.
+func (t *peerTracker) rank(candidates []peer.AddrInfo) []peer.AddrInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	type scored struct {
+		info      peer.AddrInfo
+		penalised bool
+		cost      time.Duration
+	}
+	ranked := make([]scored, len(candidates))
+	for i, c := range candidates {
+		s := t.stats(c.ID)
+		latency := s.latency
+		if latency == 0 {
+			latency = time.Second
+		}
+		ranked[i] = scored{
+			info:      c,
+			penalised: now.Before(s.penaltyUntil),
+			cost:      latency * time.Duration(1+s.inFlight),
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].penalised != ranked[j].penalised {
+			return !ranked[i].penalised
+		}
+		return ranked[i].cost < ranked[j].cost
+	})
+
+	out := make([]peer.AddrInfo, len(ranked))
+	for i, r := range ranked {
+		out[i] = r.info
+	}
+	return out
+}
+
+func (t *peerTracker) start(p peer.ID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats(p).inFlight++
+}
+
+func (t *peerTracker) succeeded(p peer.ID, took time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.stats(p)
+	s.inFlight--
+	s.failures = 0
+	s.penaltyUntil = time.Time{}
+	if s.latency == 0 {
+		s.latency = took
+	} else {
+		s.latency = time.Duration(latencySmoothing*float64(took) + (1-latencySmoothing)*float64(s.latency))
+	}
+}
+
+func (t *peerTracker) failed(p peer.ID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.stats(p)
+	s.inFlight--
+	s.failures++
+	penalty := basePenalty << (s.failures - 1)
+	if penalty > maxPenalty || penalty <= 0 {
+		penalty = maxPenalty
+	}
+	if until := time.Now().Add(penalty); until.After(s.penaltyUntil) {
+		s.penaltyUntil = until
+	}
+}
+
+// cancelled records that a request to p was abandoned by its caller, which
+// says nothing about the peer.
+func (t *peerTracker) cancelled(p peer.ID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats(p).inFlight--
+}
+
+// misbehaved records that p served data that failed verification. Unlike a
+// network failure this is never an accident, so the peer gets the longest
+// penalty straight away.
+func (t *peerTracker) misbehaved(p peer.ID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats(p).penaltyUntil = time.Now().Add(maxPenalty)
+}
+
+// blockFetcher retrieves blocks for one download. Providers are looked up
+// per block, requests are spread across them by the node's peerTracker, and a
+// failed request is retried on the next best provider.
+type blockFetcher struct {
+	n *Node
+	// hints are peers known to hold related blocks, such as the providers of
+	// the root manifest. They are tried alongside the DHT providers of each block.
+	hints []peer.AddrInfo
+}
+
+func (n *Node) newBlockFetcher(hints []peer.AddrInfo) *blockFetcher {
+	return &blockFetcher{n: n, hints: hints}
+}
+
+// GetBlock returns the raw block c from the local store, or fetches it from
+// the network.
+func (n *Node) GetBlock(ctx context.Context, c cid.Cid) ([]byte, error) {
+	return n.localFirst(n.newBlockFetcher(nil).fetch)(ctx, c)
+}
+
+// fetch retrieves a single block from the network.
+func (f *blockFetcher) fetch(ctx context.Context, c cid.Cid) ([]byte, error) {
+	tried := make(map[peer.ID]bool)
+	var lastErr error
+
+	// Look providers up a second time if every known one failed, in case the
+	// block has been re-provided by peers that were not announced initially.
+	for round := 0; round < 2; round++ {
+		candidates, err := f.providers(ctx, c, tried)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for _, p := range f.n.peers.rank(candidates) {
+			if len(tried) >= maxBlockAttempts {
+				return nil, fmt.Errorf("giving up after %d attempts: %w", len(tried), lastErr)
+			}
+			tried[p.ID] = true
+
+			data, err := f.fetchFrom(ctx, p, c)
+			if err == nil {
+				return data, nil
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Failed to fetch block %s from %s: %v", c, p.ID, err)
+			lastErr = err
+		}
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("no providers found")
+	}
+	return nil, lastErr
+}
+
+// fetchFrom requests a block from one peer and records the outcome. A
+// request the caller gave up on does not count against the peer.
+func (f *blockFetcher) fetchFrom(ctx context.Context, p peer.AddrInfo, c cid.Cid) ([]byte, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, blockRequestTimeout)
+	defer cancel()
+
+	if len(p.Addrs) > 0 {
+		f.n.Host.Peerstore().AddAddrs(p.ID, p.Addrs, peerstore.TempAddrTTL)
+	}
+
+	f.n.peers.start(p.ID)
+	started := time.Now()
+	data, err := f.n.requests.request(reqCtx, p.ID, c.String())
+	if err == nil {
+		err = f.n.acceptBlock(p.ID, c, data)
+	}
+	if err != nil && ctx.Err() != nil {
+		f.n.peers.cancelled(p.ID)
+		return nil, err
+	}
+	if err != nil {
+		f.n.peers.failed(p.ID)
+		return nil, err
+	}
+	f.n.peers.succeeded(p.ID, time.Since(started))
+	return data, nil
+}
+
+// providers returns the untried peers that may hold c: the DHT providers of
+// c followed by the fetcher's hints.
+func (f *blockFetcher) providers(ctx context.Context, c cid.Cid, tried map[peer.ID]bool) ([]peer.AddrInfo, error) {
+	found, err := f.n.dht.FindProviders(ctx, c)
+	if err != nil && len(f.hints) == 0 {
+		return nil, err
+	}
+
+	seen := make(map[peer.ID]bool)
+	var out []peer.AddrInfo
+	for _, p := range append(found, f.hints...) {
+		if p.ID == f.n.Host.ID() || tried[p.ID] || seen[p.ID] {
+			continue
+		}
+		seen[p.ID] = true
+		out = append(out, p)
+	}
+	return out, nil
+}
@@ -0,0 +1,148 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"golang.org/x/net/context"
+)
+
+func TestPeerTracker_Rank(t *testing.T) {
+	a, b, c := peer.ID("a"), peer.ID("b"), peer.ID("c")
+	for _, tc := range []struct {
+		name   string
+		events func(*peerTracker)
+		want   []peer.ID
+	}{
+		{
+			name:   "unknown peers keep their order",
+			events: func(*peerTracker) {},
+			want:   []peer.ID{a, b, c},
+		},
+		{
+			name: "faster peers first",
+			events: func(t *peerTracker) {
+				t.start(a)
+				t.succeeded(a, 3*time.Second)
+				t.start(b)
+				t.succeeded(b, 100*time.Millisecond)
+			},
+			// c has not been used and counts as one second.
+			want: []peer.ID{b, c, a},
+		},
+		{
+			name: "load scales the expected wait",
+			events: func(t *peerTracker) {
+				for _, p := range []peer.ID{a, b} {
+					t.start(p)
+					t.succeeded(p, 400*time.Millisecond)
+				}
+				t.start(a)
+				t.start(a)
+			},
+			want: []peer.ID{b, c, a},
+		},
+		{
+			name: "failed peers last",
+			events: func(t *peerTracker) {
+				t.start(a)
+				t.succeeded(a, time.Millisecond)
+				t.start(a)
+				t.failed(a)
+			},
+			want: []peer.ID{b, c, a},
+		},
+		{
+			name: "misbehaving peers last",
+			events: func(t *peerTracker) {
+				t.start(b)
+				t.succeeded(b, time.Millisecond)
+				t.misbehaved(b)
+			},
+			want: []peer.ID{a, c, b},
+		},
+		{
+			name: "success lifts the penalty",
+			events: func(t *peerTracker) {
+				t.start(a)
+				t.failed(a)
+				t.start(a)
+				t.succeeded(a, time.Millisecond)
+			},
+			want: []peer.ID{a, b, c},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tracker := newPeerTracker()
+			tc.events(tracker)
+			ranked := tracker.rank([]peer.AddrInfo{{ID: a}, {ID: b}, {ID: c}})
+			for i, p := range ranked {
+				if p.ID != tc.want[i] {
+					t.Fatalf("got %v, want %v", ranked, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPeerTracker_PenaltyBackoff(t *testing.T) {
+	tracker := newPeerTracker()
+	p := peer.ID("p")
+	for _, want := range []time.Duration{basePenalty, 2 * basePenalty, 4 * basePenalty} {
+		tracker.start(p)
+		before := time.Now()
+		tracker.failed(p)
+		if got := tracker.peers[p].penaltyUntil.Sub(before); got < want || got > want+time.Second {
+			t.Fatalf("penalty %v, want %v", got, want)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		tracker.start(p)
+		tracker.failed(p)
+	}
+	if got := time.Until(tracker.peers[p].penaltyUntil); got > maxPenalty {
+		t.Fatalf("penalty %v exceeds the maximum %v", got, maxPenalty)
+	}
+	if s := tracker.peers[p]; s.inFlight != 0 {
+		t.Fatalf("%d requests still in flight", s.inFlight)
+	}
+}
+
+func TestFetchFrom_CancelledWithoutPenalty(t *testing.T) {
+	mn := mocknet.New()
+	defer mn.Close()
+	client := newExchangeNode(t, mn)
+	server := newExchangeNode(t, mn)
+	if err := mn.LinkAll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mn.ConnectAllButSelf(); err != nil {
+		t.Fatal(err)
+	}
+	missing, err := cid.V1Builder{Codec: cid.Raw, MhType: 0x12}.Sum([]byte("missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := client.newBlockFetcher(nil)
+	p := peer.AddrInfo{ID: server.Host.ID()}
+
+	// Closing a reader cancels the requests of its prefetch window.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := f.fetchFrom(ctx, p, missing); err == nil {
+		t.Fatal("fetched a block with a cancelled context")
+	}
+	if s := client.peers.peers[p.ID]; s.inFlight != 0 || !s.penaltyUntil.IsZero() {
+		t.Fatalf("cancelled request left %d in flight, penalty until %v", s.inFlight, s.penaltyUntil)
+	}
+
+	if _, err := f.fetchFrom(context.Background(), p, missing); err == nil {
+		t.Fatal("fetched a block the peer does not have")
+	}
+	if s := client.peers.peers[p.ID]; s.inFlight != 0 || s.penaltyUntil.IsZero() {
+		t.Fatalf("failed request left %d in flight, penalty until %v", s.inFlight, s.penaltyUntil)
+	}
+}
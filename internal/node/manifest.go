@@ -0,0 +1,441 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/p2p"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+// manifestVersion is the manifest schema version written by AddFile.
+// Version 0 manifests only list the file's blocks, version 1 adds the file
+// metadata and version 2 links the chunks through a Merkle DAG.
+const manifestVersion = 2
+
+// FileInfo is the metadata recorded in a file's manifest.
+type FileInfo struct {
+	Root cid.Cid
+	// Version is the manifest schema version. Version 0 manifests record
+	// none of the fields below Encrypted.
+	Version   int
+	Chunks    int
+	Encrypted bool
+	Directory bool
+
+	// Size is -1 when the manifest does not record it.
+	Size        int64
+	Name        string
+	ContentType string
+	Created     time.Time
+	Chunker     string
+}
+
+// fileManifest is a decoded root manifest.
+type fileManifest struct {
+	// links and depth are the top of the Merkle DAG of a version 2
+	// manifest; the chunks are depth levels below links. dagSize is the
+	// total size below links.
+	links   []dagLink
+	depth   int
+	dagSize int64
+	// chunks are the file's blocks in order, unless they are below
+	// DagNodes.
+	chunks []cid.Cid
+	// chunkSizes are the plaintext sizes of chunks, nil in version 0
+	// manifests.
+	chunkSizes []int64
+	// info is filled in except for Root.
+	info FileInfo
+	// erasure is set for erasure-coded files.
+	erasure *erasureLayout
+	// dir lists the entries of a directory.
+	dir []DirEntry
+
+	// sealed is set for an encrypted file that has not been opened with its
+	// key. Its blocks are known from links, or from sealed for version 1.
+	sealed *sealedManifest
+	// cipher, key and chunkKeys are set once an encrypted file is opened.
+	// chunkKeys only come from version 1 manifests; later ones seal the
+	// chunk keys into the links.
+	cipher    storage.Cipher
+	key       []byte
+	chunkKeys [][]byte
+}
+
+type sealedManifest struct {
+	cipher storage.Cipher
+	// blocks lists every block of a version 1 file.
+	blocks []cid.Cid
+	data   []byte
+}
+
+type erasureLayout struct {
+	dataShards   int
+	parityShards int
+	chunkSizes   []int
+	stripes      []erasureStripe
+}
+
+type erasureStripe struct {
+	shardSize int
+	parity    []cid.Cid
+}
+
+// decodeManifest parses a root manifest block.
+func decodeManifest(data []byte) (*fileManifest, error) {
+	manifest := &api.Manifest{}
+	if err := proto.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Version > manifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d", manifest.Version)
+	}
+	if manifest.Encrypted != nil {
+		return decodeEncryptedManifest(manifest)
+	}
+
+	m := &fileManifest{}
+	if manifest.Directory != nil {
+		if err := m.decodeDirectory(manifest); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+	if manifest.Version >= 2 {
+		if err := m.decodeDag(manifest.Links, manifest.DagDepth); err != nil {
+			return nil, err
+		}
+	} else {
+		chunks, err := decodeCids(manifest.BlockCids)
+		if err != nil {
+			return nil, err
+		}
+		m.chunks = chunks
+		m.chunkKeys = manifest.ChunkKeys
+	}
+	if err := m.decodeInfo(manifest); err != nil {
+		return nil, err
+	}
+	if err := m.decodeErasure(manifest.Erasure); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// decodeEncryptedManifest parses the root manifest of an encrypted file.
+// Only what is needed to pin, replicate and repair the file without its key
+// is available until the manifest is opened.
+func decodeEncryptedManifest(manifest *api.Manifest) (*fileManifest, error) {
+	e := manifest.Encrypted
+	m := &fileManifest{sealed: &sealedManifest{
+		cipher: storage.Cipher(e.GetCipher()),
+		data:   e.GetSealed(),
+	}}
+	m.info = FileInfo{Version: int(manifest.Version), Encrypted: true, Size: -1}
+	if manifest.Version >= 2 {
+		if err := m.decodeDag(e.Links, e.DagDepth); err != nil {
+			return nil, err
+		}
+		if err := m.decodeErasure(e.Erasure); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+
+	blocks, err := decodeCids(e.GetBlockCids())
+	if err != nil {
+		return nil, err
+	}
+	m.sealed.blocks = blocks
+	if e.Erasure != nil {
+		numChunks := len(e.Erasure.GetChunkSizes())
+		if numChunks > len(blocks) {
+			return nil, errors.New("invalid erasure layout: more chunks than blocks")
+		}
+		m.chunks = blocks[:numChunks]
+		if err := m.decodeErasure(e.Erasure); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// decodeDag reads the root links of a version 2 manifest. With depth 0 they
+// are the chunks themselves.
+func (m *fileManifest) decodeDag(links []*api.DagLink, depth uint32) error {
+	if depth > maxDagDepth {
+		return fmt.Errorf("invalid manifest: DAG depth %d exceeds %d", depth, maxDagDepth)
+	}
+	var err error
+	m.depth = int(depth)
+	if m.links, m.dagSize, err = decodeLinks(links, depth == 0); err != nil {
+		return fmt.Errorf("invalid manifest: %w", err)
+	}
+	if m.depth > 0 {
+		return nil
+	}
+	m.chunks = make([]cid.Cid, len(m.links))
+	m.chunkSizes = make([]int64, len(m.links))
+	for i, l := range m.links {
+		m.chunks[i] = l.cid
+		m.chunkSizes[i] = l.size
+	}
+	return nil
+}
+
+func (m *fileManifest) decodeErasure(l *api.ErasureLayout) error {
+	if l == nil {
+		return nil
+	}
+	if m.depth > 0 {
+		return errors.New("invalid erasure layout: the chunks are below DAG nodes")
+	}
+	var err error
+	if m.erasure, err = decodeErasureLayout(l, len(m.chunks)); err != nil {
+		return fmt.Errorf("invalid erasure layout: %w", err)
+	}
+	return nil
+}
+
+// decodeInfo reads the file metadata of a manifest whose chunks or DAG have
+// been decoded, and checks the sizes it records against them.
+func (m *fileManifest) decodeInfo(manifest *api.Manifest) error {
+	m.info = FileInfo{
+		Version: int(manifest.Version),
+		Chunks:  len(m.chunks),
+		Size:    -1,
+	}
+	switch manifest.Version {
+	case 0:
+		return nil
+	case 1:
+		if len(manifest.ChunkSizes) != len(m.chunks) {
+			return errors.New("invalid manifest: chunk sizes do not match the chunks")
+		}
+		m.chunkSizes = make([]int64, len(m.chunks))
+		var size uint64
+		for i, s := range manifest.ChunkSizes {
+			if s > p2p.MaxMessageSize {
+				return fmt.Errorf("invalid manifest: chunk %d is too large", i)
+			}
+			m.chunkSizes[i] = int64(s)
+			size += s
+		}
+		if size != manifest.Size {
+			return errors.New("invalid manifest: chunk sizes do not add up to the file size")
+		}
+	default:
+		if uint64(m.dagSize) != manifest.Size {
+			return errors.New("invalid manifest: the DAG does not add up to the file size")
+		}
+		if m.depth == 0 && uint64(len(m.chunks)) != manifest.Chunks {
+			return errors.New("invalid manifest: the chunk count does not match the chunks")
+		}
+		if manifest.Chunks > uint64(m.dagSize) {
+			return errors.New("invalid manifest: more chunks than bytes")
+		}
+		m.info.Chunks = int(manifest.Chunks)
+	}
+	if err := ValidateName(manifest.Name); err != nil {
+		return fmt.Errorf("invalid manifest: %w", err)
+	}
+	m.info.Size = int64(manifest.Size)
+	m.info.Name = manifest.Name
+	m.info.ContentType = manifest.ContentType
+	if manifest.CreatedAt != 0 {
+		m.info.Created = time.Unix(manifest.CreatedAt, 0)
+	}
+	m.info.Chunker = manifest.Chunker
+	return nil
+}
+
+// ValidateName checks a file name recorded in a manifest. Names may be
+// empty but never contain a path.
+func ValidateName(name string) error {
+	if name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+		return fmt.Errorf("invalid file name %q", name)
+	}
+	return nil
+}
+
+func decodeErasureLayout(l *api.ErasureLayout, numChunks int) (*erasureLayout, error) {
+	k, m := int(l.GetDataShards()), int(l.GetParityShards())
+	if err := (ErasureParams{DataShards: k, ParityShards: m}).Validate(); err != nil {
+		return nil, err
+	}
+	if len(l.GetChunkSizes()) != numChunks {
+		return nil, errors.New("chunk sizes do not match the chunks")
+	}
+	if want := (numChunks + k - 1) / k; len(l.GetStripes()) != want {
+		return nil, fmt.Errorf("expected %d stripes, got %d", want, len(l.GetStripes()))
+	}
+
+	layout := &erasureLayout{
+		dataShards:   k,
+		parityShards: m,
+		chunkSizes:   make([]int, numChunks),
+		stripes:      make([]erasureStripe, len(l.GetStripes())),
+	}
+	for i, s := range l.GetStripes() {
+		if s.GetShardSize() > p2p.MaxMessageSize || len(s.GetParityCids()) != m {
+			return nil, fmt.Errorf("stripe %d is malformed", i)
+		}
+		parity, err := decodeCids(s.GetParityCids())
+		if err != nil {
+			return nil, err
+		}
+		layout.stripes[i] = erasureStripe{shardSize: int(s.GetShardSize()), parity: parity}
+	}
+	for i, size := range l.GetChunkSizes() {
+		if size > uint64(layout.stripes[i/k].shardSize) {
+			return nil, fmt.Errorf("chunk %d is larger than its stripe's shard size", i)
+		}
+		layout.chunkSizes[i] = int(size)
+	}
+	return layout, nil
+}
+
+func decodeCids(strs []string) ([]cid.Cid, error) {
+	cids := make([]cid.Cid, len(strs))
+	for i, s := range strs {
+		c, err := cid.Decode(s)
+		if err != nil {
+			return nil, err
+		}
+		cids[i] = c
+	}
+	return cids, nil
+}
+
+// blocks returns the blocks the root manifest references directly: the
+// chunks and parity blocks, the top DagNodes of a deeper DAG, or the roots
+// of a directory's entries.
+func (m *fileManifest) blocks() []cid.Cid {
+	if m.info.Directory {
+		roots := make([]cid.Cid, len(m.dir))
+		for i, e := range m.dir {
+			roots[i] = e.Cid
+		}
+		return roots
+	}
+	if m.sealed != nil && m.sealed.blocks != nil {
+		return m.sealed.blocks
+	}
+	if m.depth > 0 {
+		nodes := make([]cid.Cid, len(m.links))
+		for i, l := range m.links {
+			nodes[i] = l.cid
+		}
+		return nodes
+	}
+	blocks := append([]cid.Cid(nil), m.chunks...)
+	if m.erasure != nil {
+		for _, s := range m.erasure.stripes {
+			blocks = append(blocks, s.parity...)
+		}
+	}
+	return blocks
+}
+
+// allBlocks returns every block below the root manifest, reading DagNodes
+// and the manifests below a directory with fetch. discover, if not nil, is
+// told the links of every block read before they are fetched.
+func (m *fileManifest) allBlocks(ctx context.Context, fetch blockFetchFunc, discover func(context.Context, []cid.Cid)) ([]cid.Cid, error) {
+	if m.info.Directory {
+		return m.treeBlocks(ctx, fetch, discover, map[cid.Cid]bool{})
+	}
+	if m.depth == 0 {
+		return m.blocks(), nil
+	}
+	var blocks []cid.Cid
+	w := newDagWalker(m.links, m.depth, fetch)
+	w.discover = discover
+	w.node = func(c cid.Cid) {
+		blocks = append(blocks, c)
+	}
+	for {
+		ref, err := w.next(ctx)
+		if err == io.EOF {
+			return blocks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, ref.cid)
+	}
+}
+
+// treeBlocks returns every block below a directory. Subtrees shared by
+// several entries are listed once.
+func (m *fileManifest) treeBlocks(ctx context.Context, fetch blockFetchFunc, discover func(context.Context, []cid.Cid), seen map[cid.Cid]bool) ([]cid.Cid, error) {
+	if discover != nil {
+		discover(ctx, m.blocks())
+	}
+	var blocks []cid.Cid
+	for _, e := range m.dir {
+		if seen[e.Cid] {
+			continue
+		}
+		seen[e.Cid] = true
+		data, err := fetch(ctx, e.Cid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s: %w", e.Name, err)
+		}
+		child, err := decodeManifest(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest for %s: %w", e.Name, err)
+		}
+		var below []cid.Cid
+		if child.info.Directory {
+			below, err = child.treeBlocks(ctx, fetch, discover, seen)
+		} else {
+			if discover != nil {
+				discover(ctx, child.blocks())
+			}
+			below, err = child.allBlocks(ctx, fetch, discover)
+		}
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(append(blocks, e.Cid), below...)
+	}
+	return blocks, nil
+}
+
+// chunkIter returns an iterator over the file's chunks, starting with the
+// one holding offset, and the position of offset within that chunk.
+// DagNodes are read with fetch, and their links passed to discover if it is
+// not nil. The chunks of a version 0 manifest have no recorded size, so none
+// of them are skipped.
+func (m *fileManifest) chunkIter(ctx context.Context, fetch blockFetchFunc, discover func(context.Context, []cid.Cid), offset int64) (chunkIter, int64, error) {
+	if m.info.Version >= 2 {
+		w := newDagWalker(m.links, m.depth, fetch)
+		w.discover = discover
+		skip, err := w.seek(ctx, offset)
+		return w.next, skip, err
+	}
+	i := 0
+	for m.chunkSizes != nil && i < len(m.chunks) && offset >= m.chunkSizes[i] {
+		offset -= m.chunkSizes[i]
+		i++
+	}
+	return func(ctx context.Context) (chunkRef, error) {
+		if i == len(m.chunks) {
+			return chunkRef{}, io.EOF
+		}
+		ref := chunkRef{cid: m.chunks[i], size: -1}
+		if m.chunkSizes != nil {
+			ref.size = m.chunkSizes[i]
+		}
+		i++
+		return ref, nil
+	}, offset, nil
+}
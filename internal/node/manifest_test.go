@@ -0,0 +1,77 @@
+package node
+
+import (
+	"testing"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDecodeManifest_Versions(t *testing.T) {
+	var cids []string
+	for _, data := range []string{"a", "b"} {
+		h, err := multihash.Sum([]byte(data), multihash.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cids = append(cids, cid.NewCidV1(cid.Raw, h).String())
+	}
+	decode := func(manifest *api.Manifest) (*fileManifest, error) {
+		data, err := proto.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return decodeManifest(data)
+	}
+
+	// Manifests written before versioning only list their blocks.
+	m, err := decode(&api.Manifest{BlockCids: cids})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.info.Version != 0 || m.info.Size != -1 || m.info.Chunks != 2 || m.chunkSizes != nil {
+		t.Fatalf("unexpected info for a version 0 manifest: %+v", m.info)
+	}
+
+	m, err = decode(&api.Manifest{
+		BlockCids:   cids,
+		Version:     1,
+		Size:        30,
+		ChunkSizes:  []uint64{10, 20},
+		Name:        "notes.txt",
+		ContentType: "text/plain",
+		CreatedAt:   1700000000,
+		Chunker:     "fixed-10",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.info.Size != 30 || m.info.Name != "notes.txt" || m.info.Created.Unix() != 1700000000 || m.chunkSizes[1] != 20 {
+		t.Fatalf("unexpected info for a version 1 manifest: %+v", m.info)
+	}
+
+	links := []*api.DagLink{{Cid: cids[0], Size: 10}, {Cid: cids[1], Size: 20}}
+	m, err = decode(&api.Manifest{Version: 2, Links: links, Chunks: 2, Size: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.info.Size != 30 || m.info.Chunks != 2 || !equalCids(m.blocks(), m.chunks) || m.chunkSizes[1] != 20 {
+		t.Fatalf("unexpected info for a version 2 manifest: %+v", m.info)
+	}
+
+	for name, manifest := range map[string]*api.Manifest{
+		"future version": {BlockCids: cids, Version: manifestVersion + 1},
+		"missing sizes":  {BlockCids: cids, Version: 1, Size: 30},
+		"wrong size":     {BlockCids: cids, Version: 1, Size: 31, ChunkSizes: []uint64{10, 20}},
+		"path as name":   {BlockCids: cids, Version: 1, Size: 30, ChunkSizes: []uint64{10, 20}, Name: "../x"},
+		"wrong DAG size": {Version: 2, Links: links, Chunks: 2, Size: 31},
+		"wrong chunks":   {Version: 2, Links: links, Chunks: 3, Size: 30},
+		"too deep":       {Version: 2, Links: links, Chunks: 2, Size: 30, DagDepth: maxDagDepth + 1},
+	} {
+		if _, err := decode(manifest); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
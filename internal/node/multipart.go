@@ -0,0 +1,314 @@
+package node
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// multipartPrefix is the metadata prefix of multipart uploads.
+	multipartPrefix = "multipart/"
+	// maxPartNumber is the highest part number of a multipart upload.
+	maxPartNumber = 10000
+	// partBlockSize is the size of the blocks a part is stored in until
+	// the upload completes.
+	partBlockSize = 1024 * 1024
+)
+
+var (
+	// ErrNoSuchUpload is returned for an unknown, finished or expired
+	// multipart upload.
+	ErrNoSuchUpload = errors.New("multipart upload does not exist")
+	// ErrInvalidPart is returned for a part number out of range, or by
+	// CompleteMultipart for a part that was not uploaded as listed.
+	ErrInvalidPart = errors.New("invalid part")
+	// ErrInvalidPartOrder is returned by CompleteMultipart when the parts
+	// are not listed in ascending order.
+	ErrInvalidPartOrder = errors.New("parts are not in ascending order")
+)
+
+// CompletedPart names a part of a multipart upload and the ETag it was
+// uploaded with.
+type CompletedPart struct {
+	Number int
+	ETag   string
+}
+
+// StartMultipart opens a multipart upload of an object and returns its ID.
+// Parts may be uploaded in any order and concurrently; the object is only
+// stored once CompleteMultipart assembles them. An upload that receives no
+// part for uploadExpiry is dropped by the next garbage collection.
+func (n *Node) StartMultipart(bucket, key string, opts ObjectOptions) (string, error) {
+	if err := checkObjectKey(key); err != nil {
+		return "", err
+	}
+	if _, err := n.StatBucket(bucket); err != nil {
+		return "", err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	u := &api.MultipartUpload{
+		Bucket:      bucket,
+		Key:         key,
+		ContentType: opts.ContentType,
+		UpdatedAt:   time.Now().Unix(),
+	}
+	for k, v := range opts.Metadata {
+		u.Metadata = append(u.Metadata, &api.ObjectMetadata{Key: k, Value: v})
+	}
+	uploadID := hex.EncodeToString(id)
+	if err := n.putMetaProto(multipartPrefix+uploadID, u); err != nil {
+		return "", err
+	}
+	return uploadID, nil
+}
+
+// PutPart stores part number of the multipart upload id of key in bucket,
+// replacing any earlier upload of the same part, and returns its ETag.
+func (n *Node) PutPart(bucket, key, id string, number int, r io.Reader, contentMD5 []byte) (string, error) {
+	if number < 1 || number > maxPartNumber {
+		return "", fmt.Errorf("%w: part number %d", ErrInvalidPart, number)
+	}
+	if _, err := n.loadMultipart(bucket, key, id); err != nil {
+		return "", err
+	}
+
+	// The blocks are unreferenced until the part is recorded.
+	n.gcLock.RLock()
+	defer n.gcLock.RUnlock()
+
+	part := &api.MultipartPart{}
+	h := md5.New()
+	buf := make([]byte, partBlockSize)
+	for {
+		k, err := io.ReadFull(r, buf)
+		if k > 0 {
+			c, perr := n.store.Put(buf[:k])
+			if perr != nil {
+				return "", perr
+			}
+			h.Write(buf[:k])
+			part.Blocks = append(part.Blocks, &api.DagLink{Cid: c.String(), Size: uint64(k)})
+			part.Size += int64(k)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	part.Md5 = h.Sum(nil)
+	if contentMD5 != nil && !bytes.Equal(part.Md5, contentMD5) {
+		return "", ErrBadDigest
+	}
+
+	n.uploadMu.Lock()
+	defer n.uploadMu.Unlock()
+	u, err := n.loadMultipart(bucket, key, id)
+	if err != nil {
+		return "", err
+	}
+	if err := n.putMetaProto(partKey(id, number), part); err != nil {
+		return "", err
+	}
+	u.UpdatedAt = time.Now().Unix()
+	if err := n.putMetaProto(multipartPrefix+id, u); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(part.Md5), nil
+}
+
+// CompleteMultipart stores the listed parts, in order, as one object and
+// closes the upload. Parts that are not listed are discarded.
+func (n *Node) CompleteMultipart(ctx context.Context, bucket, key, id string, parts []CompletedPart) (Object, error) {
+	u, err := n.loadMultipart(bucket, key, id)
+	if err != nil {
+		return Object{}, err
+	}
+	if len(parts) == 0 {
+		return Object{}, fmt.Errorf("%w: no parts listed", ErrInvalidPart)
+	}
+	var blocks []*api.DagLink
+	var sums []byte
+	for i, p := range parts {
+		if i > 0 && p.Number <= parts[i-1].Number {
+			return Object{}, ErrInvalidPartOrder
+		}
+		part := &api.MultipartPart{}
+		if err := n.getMetaProto(partKey(id, p.Number), part); errors.Is(err, storage.ErrMetaNotFound) {
+			return Object{}, fmt.Errorf("%w: part %d was not uploaded", ErrInvalidPart, p.Number)
+		} else if err != nil {
+			return Object{}, err
+		}
+		if strings.Trim(p.ETag, `"`) != hex.EncodeToString(part.Md5) {
+			return Object{}, fmt.Errorf("%w: part %d has a different ETag", ErrInvalidPart, p.Number)
+		}
+		blocks = append(blocks, part.Blocks...)
+		sums = append(sums, part.Md5...)
+	}
+
+	// The upload keeps the parts from being collected until the file is
+	// pinned.
+	res, pinned, err := n.addObjectFile(ctx, &partReader{store: n.store, parts: blocks}, AddOptions{Name: path.Base(u.Key), ContentType: u.ContentType})
+	if err != nil {
+		return Object{}, err
+	}
+	sum := md5.Sum(sums)
+	o := Object{
+		Bucket:   u.Bucket,
+		Key:      u.Key,
+		Root:     res.Root,
+		Size:     res.Size,
+		ETag:     hex.EncodeToString(sum[:]) + "-" + strconv.Itoa(len(parts)),
+		Modified: time.Now(),
+	}
+	if len(u.Metadata) > 0 {
+		o.Metadata = make(map[string]string, len(u.Metadata))
+		for _, m := range u.Metadata {
+			o.Metadata[m.Key] = m.Value
+		}
+	}
+	if o, err = n.commitObject(o, pinned); err != nil {
+		return Object{}, err
+	}
+	// The parts are left to the next garbage collection.
+	if err := n.deleteMultipart(id); err != nil {
+		return Object{}, err
+	}
+	return o, nil
+}
+
+// AbortMultipart closes a multipart upload without storing the object.
+func (n *Node) AbortMultipart(bucket, key, id string) error {
+	if _, err := n.loadMultipart(bucket, key, id); err != nil {
+		return err
+	}
+	return n.deleteMultipart(id)
+}
+
+// multipartBlocks returns the blocks of the parts of every live multipart
+// upload, dropping the uploads that expired. The caller holds gcLock.
+func (n *Node) multipartBlocks() ([]cid.Cid, error) {
+	n.uploadMu.Lock()
+	defer n.uploadMu.Unlock()
+
+	blocks := make(map[string][]cid.Cid)
+	live := make(map[string]bool)
+	deadline := time.Now().Add(-uploadExpiry).Unix()
+	err := n.store.ScanMeta(multipartPrefix, func(key string, value []byte) error {
+		id, number, isPart := strings.Cut(key, "/")
+		if !isPart {
+			u := &api.MultipartUpload{}
+			if err := proto.Unmarshal(value, u); err != nil {
+				return fmt.Errorf("invalid multipart upload %s: %w", id, err)
+			}
+			live[id] = u.UpdatedAt >= deadline
+			return nil
+		}
+		part := &api.MultipartPart{}
+		if err := proto.Unmarshal(value, part); err != nil {
+			return fmt.Errorf("invalid part %s of multipart upload %s: %w", number, id, err)
+		}
+		for _, b := range part.Blocks {
+			c, err := cid.Decode(b.Cid)
+			if err != nil {
+				return fmt.Errorf("invalid part %s of multipart upload %s: %w", number, id, err)
+			}
+			blocks[id] = append(blocks[id], c)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Expired uploads are dropped, along with parts that outlived their
+	// upload.
+	var keep []cid.Cid
+	for id, ok := range live {
+		if ok {
+			keep = append(keep, blocks[id]...)
+			continue
+		}
+		if err := n.deleteMultipartLocked(id); err != nil {
+			return nil, err
+		}
+	}
+	for id := range blocks {
+		if _, ok := live[id]; !ok {
+			if err := n.deleteMultipartLocked(id); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return keep, nil
+}
+
+// loadMultipart returns the multipart upload id, which must be one of key in
+// bucket.
+func (n *Node) loadMultipart(bucket, key, id string) (*api.MultipartUpload, error) {
+	// An ID with a slash would name a part.
+	if strings.Contains(id, "/") {
+		return nil, ErrNoSuchUpload
+	}
+	u := &api.MultipartUpload{}
+	err := n.getMetaProto(multipartPrefix+id, u)
+	if errors.Is(err, storage.ErrMetaNotFound) {
+		return nil, ErrNoSuchUpload
+	}
+	if err != nil {
+		return nil, err
+	}
+	if u.Bucket != bucket || u.Key != key || u.UpdatedAt < time.Now().Add(-uploadExpiry).Unix() {
+		return nil, ErrNoSuchUpload
+	}
+	return u, nil
+}
+
+func (n *Node) deleteMultipart(id string) error {
+	n.uploadMu.Lock()
+	defer n.uploadMu.Unlock()
+	return n.deleteMultipartLocked(id)
+}
+
+// deleteMultipartLocked removes an upload and its parts. uploadMu must be
+// held.
+func (n *Node) deleteMultipartLocked(id string) error {
+	var keys []string
+	err := n.store.ScanMeta(multipartPrefix+id+"/", func(number string, _ []byte) error {
+		keys = append(keys, multipartPrefix+id+"/"+number)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range append(keys, multipartPrefix+id) {
+		if err := n.store.DeleteMeta(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// partKey returns the metadata key of a part. Numbers are zero-padded so
+// that parts are scanned in order.
+func partKey(id string, number int) string {
+	return fmt.Sprintf("%s%s/%05d", multipartPrefix, id, number)
+}
@@ -0,0 +1,288 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/p2p"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/routing"
+	"golang.org/x/net/context"
+)
+
+const (
+	// DefaultNameLifetime is how long a name record stays valid unless
+	// another lifetime is given.
+	DefaultNameLifetime = 48 * time.Hour
+	// NamePathPrefix starts a path that names a file by a published name
+	// rather than by its CID, as in /name/<peer id>.
+	NamePathPrefix = "/name/"
+	// nameRepublishInterval is how often the node signs and publishes its
+	// names again, well within their lifetime and the time DHT peers keep
+	// records.
+	nameRepublishInterval = 4 * time.Hour
+	// nameRepublishDelay leaves the node time to join the network before it
+	// first publishes its names again after a start.
+	nameRepublishDelay = time.Minute
+	// nameLookupTimeout bounds the lookup of the latest record of a key
+	// this node has no record of.
+	nameLookupTimeout = 30 * time.Second
+	// namesPrefix is the metadata prefix of the names this node publishes.
+	namesPrefix = "names/"
+)
+
+var (
+	// ErrNameNotFound is returned when no valid record of a name is found.
+	ErrNameNotFound = errors.New("name not found")
+	// ErrNoNameKey is returned by PublishName for a key that does not
+	// exist.
+	ErrNoNameKey = errors.New("no such key")
+	// ErrNamePublish is returned by PublishName when the DHT cannot be
+	// asked for the latest record of a name, or does not take the new one.
+	ErrNamePublish = errors.New("name could not be published")
+)
+
+// NameEntry is the CID a name points to.
+type NameEntry struct {
+	// Name is the Peer ID of the key the record is signed with.
+	Name     peer.ID
+	Root     cid.Cid
+	Sequence uint64
+	Expires  time.Time
+}
+
+// PublishName signs a record pointing the name of the named key at root and
+// publishes it in the DHT. keyName is p2p.SelfKey, or empty, for the node
+// identity, or a key created with p2p.GenerateNamedKey. The record expires
+// after lifetime, DefaultNameLifetime if zero, but the node publishes a
+// fresh one every nameRepublishInterval while it runs. A record signed but
+// not taken by the DHT is still published again by the republisher.
+func (n *Node) PublishName(ctx context.Context, keyName string, root cid.Cid, lifetime time.Duration) (NameEntry, error) {
+	if keyName == "" {
+		keyName = p2p.SelfKey
+	}
+	if lifetime <= 0 {
+		lifetime = DefaultNameLifetime
+	}
+	priv, err := n.nameKey(keyName)
+	if err != nil {
+		return NameEntry{}, err
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		return NameEntry{}, err
+	}
+
+	// The key may have been used before this node kept its records, so the
+	// DHT is asked for its latest record. The lookup does not hold namesMu.
+	var seq uint64
+	if _, _, err := n.publishedName(id); errors.Is(err, storage.ErrMetaNotFound) {
+		lookupCtx, cancel := context.WithTimeout(ctx, nameLookupTimeout)
+		prev, err := n.lookupName(lookupCtx, id)
+		cancel()
+		if err == nil {
+			seq = prev.Sequence + 1
+		} else if !errors.Is(err, ErrNameNotFound) {
+			// Publishing a lower sequence number would be silently ignored.
+			return NameEntry{}, fmt.Errorf("%w: looking up the latest record of %s: %w", ErrNamePublish, id, err)
+		}
+	} else if err != nil {
+		return NameEntry{}, err
+	}
+
+	n.namesMu.Lock()
+	if _, prev, err := n.publishedName(id); err == nil {
+		seq = max(seq, prev.Sequence+1)
+	} else if !errors.Is(err, storage.ErrMetaNotFound) {
+		n.namesMu.Unlock()
+		return NameEntry{}, err
+	}
+	rec := p2p.NameRecord{Cid: root, Sequence: seq, Expires: time.Now().Add(lifetime)}
+	value, err := n.signName(keyName, priv, rec, lifetime)
+	n.namesMu.Unlock()
+	if err != nil {
+		return NameEntry{}, err
+	}
+
+	if err := n.putName(ctx, id, value); err != nil {
+		return NameEntry{}, fmt.Errorf("%w: %w", ErrNamePublish, err)
+	}
+	return NameEntry{Name: id, Root: rec.Cid, Sequence: rec.Sequence, Expires: rec.Expires}, nil
+}
+
+// ResolveName returns the CID the name, a Peer ID, points to.
+func (n *Node) ResolveName(ctx context.Context, name string) (NameEntry, error) {
+	id, err := peer.Decode(name)
+	if err != nil {
+		return NameEntry{}, fmt.Errorf("invalid name %q: %w", name, err)
+	}
+	rec, err := n.lookupName(ctx, id)
+	if err != nil {
+		return NameEntry{}, err
+	}
+	return NameEntry{Name: id, Root: rec.Cid, Sequence: rec.Sequence, Expires: rec.Expires}, nil
+}
+
+// resolveRoot returns the root of a file named by its CID or by a
+// NamePathPrefix path.
+func (n *Node) resolveRoot(ctx context.Context, path string) (cid.Cid, error) {
+	if name, ok := strings.CutPrefix(path, NamePathPrefix); ok {
+		e, err := n.ResolveName(ctx, name)
+		if err != nil {
+			return cid.Undef, err
+		}
+		log.Printf("Resolved %s to %s", path, e.Root)
+		return e.Root, nil
+	}
+	c, err := cid.Decode(path)
+	if err != nil {
+		return cid.Undef, fmt.Errorf("failed to decode root CID: %w", err)
+	}
+	return c, nil
+}
+
+// lookupName returns the best valid record of id in the DHT.
+func (n *Node) lookupName(ctx context.Context, id peer.ID) (p2p.NameRecord, error) {
+	value, err := n.dht.GetValue(ctx, p2p.NameKey(id))
+	if err != nil {
+		// A node can resolve its own names without any peers.
+		if _, own, ownErr := n.publishedName(id); ownErr == nil && time.Now().Before(own.Expires) {
+			return own, nil
+		}
+		if errors.Is(err, routing.ErrNotFound) {
+			return p2p.NameRecord{}, fmt.Errorf("%w: %s", ErrNameNotFound, id)
+		}
+		return p2p.NameRecord{}, err
+	}
+	// The DHT only returns records that passed p2p.NameValidator.
+	return p2p.ParseNameRecord(id, value)
+}
+
+// nameKey returns the private key of the named key.
+func (n *Node) nameKey(keyName string) (crypto.PrivKey, error) {
+	if keyName == p2p.SelfKey {
+		return n.Host.Peerstore().PrivKey(n.Host.ID()), nil
+	}
+	priv, err := p2p.LoadNamedKey(n.dataDir, keyName)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNoNameKey, keyName)
+	}
+	return priv, err
+}
+
+// signName signs rec and records it as the latest record of the key.
+// namesMu must be held.
+func (n *Node) signName(keyName string, priv crypto.PrivKey, rec p2p.NameRecord, lifetime time.Duration) ([]byte, error) {
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	value, err := p2p.SignNameRecord(priv, rec)
+	if err != nil {
+		return nil, err
+	}
+	err = n.putMetaProto(namesPrefix+id.String(), &api.PublishedName{
+		Key:      keyName,
+		Record:   value,
+		Lifetime: int64(lifetime / time.Second),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// putName stores a signed record in the DHT. A record that cannot be stored
+// is kept locally and published again by the republisher.
+func (n *Node) putName(ctx context.Context, id peer.ID, value []byte) error {
+	if err := n.dht.PutValue(ctx, p2p.NameKey(id), value); err != nil {
+		return fmt.Errorf("storing the record of %s in the DHT: %w", id, err)
+	}
+	log.Printf("Published name %s", id)
+	return nil
+}
+
+// publishedName returns the latest record this node published for id.
+func (n *Node) publishedName(id peer.ID) (*api.PublishedName, p2p.NameRecord, error) {
+	p := &api.PublishedName{}
+	if err := n.getMetaProto(namesPrefix+id.String(), p); err != nil {
+		return nil, p2p.NameRecord{}, err
+	}
+	rec, err := p2p.ParseNameRecord(id, p.Record)
+	if err != nil {
+		return nil, p2p.NameRecord{}, fmt.Errorf("invalid published name %s: %w", id, err)
+	}
+	return p, rec, nil
+}
+
+// startNameRepublisher signs and publishes every name of this node again,
+// with a higher sequence number and a new expiry, shortly after the start
+// and then every nameRepublishInterval, until ctx is cancelled.
+func (n *Node) startNameRepublisher(ctx context.Context) {
+	go func() {
+		timer := time.NewTimer(nameRepublishDelay)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+			n.republishNames(ctx)
+			timer.Reset(nameRepublishInterval)
+		}
+	}()
+}
+
+func (n *Node) republishNames(ctx context.Context) {
+	var ids []peer.ID
+	err := n.store.ScanMeta(namesPrefix, func(key string, _ []byte) error {
+		id, err := peer.Decode(key)
+		if err != nil {
+			return fmt.Errorf("invalid published name %s: %w", key, err)
+		}
+		ids = append(ids, id)
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error reading published names: %v", err)
+		return
+	}
+	for _, id := range ids {
+		n.namesMu.Lock()
+		value, err := n.renewName(id)
+		n.namesMu.Unlock()
+		if err != nil {
+			log.Printf("Cannot republish name %s: %v", id, err)
+			continue
+		}
+		if err := n.putName(ctx, id, value); err != nil {
+			log.Printf("Error republishing name %s: %v", id, err)
+		}
+	}
+}
+
+// renewName signs the latest record of id again with the next sequence
+// number and a new expiry. namesMu must be held.
+func (n *Node) renewName(id peer.ID) ([]byte, error) {
+	p, rec, err := n.publishedName(id)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := n.nameKey(p.Key)
+	if err != nil {
+		return nil, err
+	}
+	lifetime := time.Duration(p.Lifetime) * time.Second
+	rec.Sequence++
+	rec.Expires = time.Now().Add(lifetime)
+	return n.signName(p.Key, priv, rec, lifetime)
+}
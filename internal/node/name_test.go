@@ -0,0 +1,104 @@
+package node
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Yashh56/p2p-storage/internal/p2p"
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multihash"
+	"golang.org/x/net/context"
+)
+
+// newNetworkNode returns a node listening on the loopback interface.
+func newNetworkNode(t *testing.T, ctx context.Context) *Node {
+	t.Helper()
+	dataDir := t.TempDir()
+	n, err := NewNode(ctx, newTestNode(t).store, Config{
+		Host:        p2p.HostConfig{ListenAddrs: []string{"/ip4/127.0.0.1/tcp/0"}},
+		Replication: ReplicationConfig{Interval: time.Hour},
+		DataDir:     dataDir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		n.dht.Close()
+		n.Host.Close()
+	})
+	return n
+}
+
+func TestPublishName_Sequences(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	roots := make([]cid.Cid, 3)
+	for i := range roots {
+		h, err := multihash.Sum([]byte{byte(i)}, multihash.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots[i] = cid.NewCidV1(cid.Raw, h)
+	}
+
+	// A node that cannot reach the DHT says so.
+	a := newNetworkNode(t, ctx)
+	if _, err := a.PublishName(ctx, "", roots[0], 0); !errors.Is(err, ErrNamePublish) {
+		t.Fatalf("got %v publishing without peers, want ErrNamePublish", err)
+	}
+
+	b := newNetworkNode(t, ctx)
+	if err := b.Host.Connect(ctx, peer.AddrInfo{ID: a.Host.ID(), Addrs: a.Host.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+	for a.dht.RoutingTable().Size() == 0 || b.dht.RoutingTable().Size() == 0 {
+		select {
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	// The same key is used from both nodes.
+	if _, err := p2p.GenerateNamedKey(a.dataDir, "site", "ed25519"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(a.dataDir, p2p.KeysDir, "site.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(b.dataDir, p2p.KeysDir), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(b.dataDir, p2p.KeysDir, "site.key"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []uint64{0, 1} {
+		e, err := a.PublishName(ctx, "site", roots[i], 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Sequence != want {
+			t.Fatalf("publish %d: got sequence %d, want %d", i, e.Sequence, want)
+		}
+	}
+	// The other node continues from the record in the DHT.
+	e, err := b.PublishName(ctx, "site", roots[2], 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Sequence != 2 {
+		t.Fatalf("got sequence %d from the other node, want 2", e.Sequence)
+	}
+	for _, n := range []*Node{a, b} {
+		got, err := n.ResolveName(ctx, e.Name.String())
+		if err != nil || !got.Root.Equals(roots[2]) {
+			t.Fatalf("resolved %v, %v", got.Root, err)
+		}
+	}
+}
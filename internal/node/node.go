@@ -1,11 +1,16 @@
 package node
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math"
+	"mime"
+	"net/http"
+	"path/filepath"
 	"sync"
+	"time"
 
 	api "github.com/Yashh56/p2p-storage/api/v1"
 	"github.com/Yashh56/p2p-storage/internal/file"
@@ -21,70 +26,282 @@ import (
 )
 
 type Node struct {
-	store *storage.BlockStore
-	Host  host.Host
-	dht   *dht.IpfsDHT
+	store   *storage.BlockStore
+	Host    host.Host
+	dht     *dht.IpfsDHT
+	peers   *peerTracker
+	limiter *blockLimiter
+	// requests batches the blocks requested from each peer.
+	requests *blockRequester
+
+	// gcLock keeps garbage collection from sweeping blocks that are being
+	// written but are not pinned yet.
+	gcLock sync.RWMutex
+	// uploadMu serialises changes to upload sessions.
+	uploadMu sync.Mutex
+	// objectsMu serialises changes to the object index.
+	objectsMu sync.Mutex
+	// namesMu serialises signing name records, so that sequence numbers
+	// only go up.
+	namesMu sync.Mutex
+	// dataDir holds the named keys names are published under.
+	dataDir string
+
+	// replicate schedules an immediate replication run for a root.
+	replicate      chan cid.Cid
+	acceptReplicas bool
+}
+
+// Config holds the settings used to start a node.
+type Config struct {
+	Host        p2p.HostConfig
+	Bootstrap   p2p.BootstrapConfig
+	MDNS        p2p.MDNSConfig
+	Replication ReplicationConfig
+	// DataDir holds the named keys the node can publish names under.
+	DataDir string
 }
 
 // NewNode creates a new P2P node.
-func NewNode(ctx context.Context, store *storage.BlockStore) (*Node, error) {
-	h, err := p2p.NewHost(ctx)
+func NewNode(ctx context.Context, store *storage.BlockStore, cfg Config) (*Node, error) {
+	if cfg.Replication.Interval <= 0 {
+		return nil, fmt.Errorf("replication interval must be positive, got %v", cfg.Replication.Interval)
+	}
+
+	h, err := p2p.NewHost(ctx, cfg.Host)
 	if err != nil {
 		return nil, err
 	}
 
 	dht, err := p2p.InitDHT(ctx, h)
 	if err != nil {
+		h.Close()
+		return nil, err
+	}
+
+	if err := p2p.StartBootstrap(ctx, h, dht, cfg.Bootstrap); err != nil {
+		dht.Close()
+		h.Close()
+		return nil, err
+	}
+	if err := p2p.StartMDNS(ctx, h, dht, cfg.MDNS); err != nil {
+		dht.Close()
+		h.Close()
 		return nil, err
 	}
 
 	node := &Node{
-		store: store,
-		Host:  h,
-		dht:   dht,
+		store:   store,
+		Host:    h,
+		dht:     dht,
+		peers:   newPeerTracker(),
+		limiter: newBlockLimiter(),
+
+		replicate:      make(chan cid.Cid, 64),
+		acceptReplicas: cfg.Replication.Accept,
+		dataDir:        cfg.DataDir,
 	}
+	node.requests = newBlockRequester(node)
 
 	// Register the handler that allows this node to respond to block requests.
 	node.setupBlockRequestHandler()
+	node.startReplication(ctx, cfg.Replication.Interval)
+	node.startNameRepublisher(ctx)
 
 	return node, nil
 }
 
+// AddOptions controls how AddFile splits a file into blocks.
+type AddOptions struct {
+	// Chunker selects the chunking strategy. The zero value means
+	// file.DefaultParams.
+	Chunker file.Params
+	// Replication is the number of nodes, including this one, that should
+	// keep a pinned copy of the file. Values below 2 keep it on this node
+	// only.
+	Replication int
+	// Erasure, when enabled, stores parity blocks for every stripe of
+	// chunks and spreads the blocks of each stripe over distinct peers. It
+	// cannot be combined with Replication.
+	Erasure ErasureParams
+	// Encryption, when a mode is set, encrypts every chunk and the manifest
+	// before they are stored.
+	Encryption EncryptOptions
+	// Name is the file name recorded in the manifest, without directories.
+	Name string
+	// ContentType is the MIME type recorded in the manifest. When empty it
+	// is guessed from Name, then from the start of the file.
+	ContentType string
+}
+
+// AddResult describes a file stored by AddFile.
+type AddResult struct {
+	Root cid.Cid
+	// Size is the size of the file, or of all files in a directory.
+	Size int64
+	// Key is the key needed to read an encrypted file. The node does not
+	// keep it.
+	Key []byte
+}
+
 // AddFile chunks a file, stores it locally, and announces it to the network.
-func (n *Node) AddFile(ctx context.Context, r io.Reader) (cid.Cid, error) {
-	chunks, err := file.Chunk(r)
+// The root is pinned recursively so the file survives garbage collection.
+func (n *Node) AddFile(ctx context.Context, r io.Reader, opts AddOptions) (AddResult, error) {
+	n.gcLock.RLock()
+	defer n.gcLock.RUnlock()
+
+	result, err := n.addFile(ctx, r, opts)
 	if err != nil {
-		return cid.Undef, err
+		return AddResult{}, err
 	}
+	if err := n.store.Pin(result.Root, storage.PinRecursive); err != nil {
+		return AddResult{}, err
+	}
+	if opts.Replication > 1 || opts.Erasure.Enabled() {
+		if err := n.setReplication(result.Root, max(opts.Replication, 1)); err != nil {
+			return AddResult{}, err
+		}
+	}
+	return result, nil
+}
 
-	chunkCIDs := make([]cid.Cid, len(chunks))
-	for i, chunkData := range chunks {
+// addFile stores and announces a file without pinning it. gcLock must be
+// held.
+func (n *Node) addFile(ctx context.Context, r io.Reader, opts AddOptions) (AddResult, error) {
+	if opts.Chunker.Strategy == "" {
+		opts.Chunker = file.DefaultParams
+	}
+	if err := ValidateName(opts.Name); err != nil {
+		return AddResult{}, err
+	}
+	if opts.ContentType == "" && opts.Name != "" {
+		opts.ContentType = mime.TypeByExtension(filepath.Ext(opts.Name))
+	}
+	chunker, err := file.NewChunker(r, opts.Chunker)
+	if err != nil {
+		return AddResult{}, err
+	}
+	var stripes *stripeEncoder
+	if opts.Erasure.Enabled() {
+		if opts.Replication > 1 {
+			return AddResult{}, errors.New("replication and erasure coding cannot be combined")
+		}
+		if stripes, err = n.newStripeEncoder(opts.Erasure, opts.Chunker); err != nil {
+			return AddResult{}, err
+		}
+	}
+	var encrypter *chunkEncrypter
+	if opts.Encryption.Mode != "" {
+		if encrypter, err = newChunkEncrypter(opts.Encryption); err != nil {
+			return AddResult{}, err
+		}
+	}
+
+	// The chunks of an erasure-coded file are linked from the root, where
+	// its layout refers to them by position.
+	dag := n.newDagBuilder(dagFanout)
+	if stripes != nil {
+		dag = n.newDagBuilder(math.MaxInt)
+	}
+
+	// Each chunk is stored and announced as soon as it is cut, so memory use
+	// is bounded by the chunker's buffer rather than the size of the file.
+	var size uint64
+	for i := 0; ; i++ {
+		chunkData, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return AddResult{}, err
+		}
+		if i == 0 && opts.ContentType == "" {
+			opts.ContentType = http.DetectContentType(chunkData)
+		}
+		link := &api.DagLink{Size: uint64(len(chunkData))}
+		size += link.Size
+		var chunkKey []byte
+		if encrypter != nil {
+			if chunkData, chunkKey, err = encrypter.encrypt(chunkData); err != nil {
+				return AddResult{}, err
+			}
+		}
 		c, err := n.store.Put(chunkData)
 		if err != nil {
-			return cid.Undef, err
+			return AddResult{}, err
+		}
+		link.Cid = c.String()
+		if chunkKey != nil {
+			if link.Key, err = encrypter.sealKey(c, chunkKey); err != nil {
+				return AddResult{}, err
+			}
+		}
+		if err := dag.add(link); err != nil {
+			return AddResult{}, err
 		}
-		chunkCIDs[i] = c
 
 		fmt.Printf("Announcing provider for chunk %d: %s\n", i, c)
 		if err := n.dht.Provide(ctx, c, true); err != nil {
 			log.Printf("Error providing chunk %s: %v", c, err)
 		}
+
+		if stripes != nil {
+			if err := stripes.add(chunkData); err != nil {
+				return AddResult{}, err
+			}
+		}
 	}
 
-	cidStrs := make([]string, len(chunkCIDs))
-	for i, c := range chunkCIDs {
-		cidStrs[i] = c.String()
+	links, depth, err := dag.finish()
+	if err != nil {
+		return AddResult{}, err
+	}
+	for _, c := range dag.nodes {
+		if err := n.dht.Provide(ctx, c, true); err != nil {
+			log.Printf("Error providing DAG node %s: %v", c, err)
+		}
+	}
+	manifest := &api.Manifest{
+		Version:     manifestVersion,
+		Links:       links,
+		DagDepth:    uint32(depth),
+		Chunks:      uint64(dag.chunks),
+		Size:        size,
+		Name:        opts.Name,
+		ContentType: opts.ContentType,
+		CreatedAt:   time.Now().Unix(),
+		Chunker:     opts.Chunker.String(),
+	}
+	if stripes != nil {
+		if err := stripes.flush(); err != nil {
+			return AddResult{}, err
+		}
+		manifest.Erasure = stripes.layout
+		for _, c := range stripes.parity {
+			if err := n.dht.Provide(ctx, c, true); err != nil {
+				log.Printf("Error providing parity block %s: %v", c, err)
+			}
+		}
+	}
+	var result AddResult
+	if encrypter != nil {
+		if manifest, err = encrypter.seal(manifest); err != nil {
+			return AddResult{}, err
+		}
+		result.Key = encrypter.key
 	}
-	manifest := &api.Manifest{BlockCids: cidStrs}
 
 	manifestData, err := proto.Marshal(manifest)
 	if err != nil {
-		return cid.Undef, err
+		return AddResult{}, err
+	}
+	if stripes != nil && len(manifestData) > maxErasureManifestSize {
+		return AddResult{}, fmt.Errorf("%w: its manifest has %d bytes", ErrErasureTooLarge, len(manifestData))
 	}
 
 	rootCID, err := n.store.Put(manifestData)
 	if err != nil {
-		return cid.Undef, err
+		return AddResult{}, err
 	}
 
 	fmt.Printf("Announcing provider for root manifest: %s\n", rootCID)
@@ -92,134 +309,202 @@ func (n *Node) AddFile(ctx context.Context, r io.Reader) (cid.Cid, error) {
 		log.Printf("Error providing root manifest %s: %v", rootCID, err)
 	}
 
-	return rootCID, nil
+	result.Root = rootCID
+	result.Size = int64(size)
+	return result, nil
+}
+
+// GetOptions controls how GetFile reads a file.
+type GetOptions struct {
+	// Key is the key returned by AddFile for an encrypted file.
+	Key []byte
+	// Offset and Length select a range of the file. Only the chunks
+	// covering it are fetched. A zero Length reads to the end of the file.
+	Offset int64
+	Length int64
 }
 
+// ErrInvalidRange is returned by GetFile for a range that starts after the
+// end of the file.
+var ErrInvalidRange = errors.New("invalid range")
+
 // GetFile retrieves a file. It checks the local store first, then searches the network.
-func (n *Node) GetFile(ctx context.Context, rootCIDStr string) (io.Reader, error) {
+// The file is named by its root CID or by a published name, as /name/<peer id>.
+// The returned reader fetches chunks on demand and must be closed by the caller.
+func (n *Node) GetFile(ctx context.Context, rootCIDStr string, opts GetOptions) (io.ReadCloser, FileInfo, error) {
 	log.Printf("Attempting to get file with root CID: %s", rootCIDStr)
 
-	rootCidObj, err := cid.Decode(rootCIDStr)
+	rootCidObj, err := n.resolveRoot(ctx, rootCIDStr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode root CID: %w", err)
+		return nil, FileInfo{}, err
 	}
-
-	// --- THE COMPLETE FIX ---
-	// 1. Check if we have the root manifest locally.
-	manifestData, err := n.store.Get(rootCidObj)
-	if err == nil {
-		// LOCAL PATH: We have the manifest. Assume all chunks are local.
-		log.Println("Content found locally. Retrieving from disk.")
-		return n.retrieveFileFromLocalStore(manifestData)
+	m, fetch, discover, err := n.openFile(ctx, rootCidObj)
+	if err != nil {
+		return nil, FileInfo{Root: rootCidObj}, err
 	}
-
-	// NETWORK PATH: We don't have it locally, so search the network.
-	log.Println("Content not found locally, searching network...")
-	return n.retrieveFileFromNetwork(ctx, rootCidObj)
+	r, info, err := n.newReader(ctx, m, fetch, discover, opts)
+	info.Root = rootCidObj
+	return r, info, err
 }
 
-// retrieveFileFromLocalStore is called when the root manifest is already in our blockstore.
-func (n *Node) retrieveFileFromLocalStore(manifestData []byte) (io.Reader, error) {
-	manifest := &api.Manifest{}
-	if err := proto.Unmarshal(manifestData, manifest); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal local manifest: %w", err)
+// openFile decodes the root manifest of a file and returns how to fetch its
+// blocks, and the hook to tell about the blocks below DagNodes. Blocks held
+// locally are read from disk; only the others are fetched from the network.
+func (n *Node) openFile(ctx context.Context, rootCidObj cid.Cid) (*fileManifest, blockFetchFunc, func(context.Context, []cid.Cid), error) {
+	manifestData, err := n.store.Get(rootCidObj)
+	if err != nil {
+		log.Println("Content not found locally, searching network...")
+		sess, m, err := n.openSession(ctx, rootCidObj)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return m, n.localFirst(sess.fetch), sess.discover, nil
 	}
 
-	var fileData []byte
-	for _, chunkCIDStr := range manifest.BlockCids {
-		c, err := cid.Decode(chunkCIDStr)
-		if err != nil {
-			return nil, err
+	m, err := decodeManifest(manifestData)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to unmarshal local manifest: %w", err)
+	}
+	// A manifest alone may have been cached by an interrupted pin, so
+	// neighbours are asked for whatever blocks are missing.
+	sess := n.newSession(ctx, n.newBlockFetcher(nil))
+	discover := func(ctx context.Context, cids []cid.Cid) {
+		var missing []cid.Cid
+		for _, c := range cids {
+			if ok, _ := n.store.Has(c); !ok {
+				missing = append(missing, c)
+			}
 		}
-		chunkData, err := n.store.Get(c)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get local chunk %s: %w", c, err)
+		if len(missing) > 0 {
+			sess.discover(ctx, missing)
 		}
-		fileData = append(fileData, chunkData...)
 	}
-	return bytes.NewReader(fileData), nil
+	discover(ctx, m.blocks())
+	return m, n.localFirst(sess.fetch), discover, nil
 }
 
-// retrieveFileFromNetwork finds providers and fetches the file block by block.
-func (n *Node) retrieveFileFromNetwork(ctx context.Context, rootCidObj cid.Cid) (io.Reader, error) {
-	peerChan, err := n.dht.FindProviders(ctx, rootCidObj)
+// openSession fetches a file's manifest from other peers and returns a
+// session ready to fetch its blocks. Connected neighbours are asked first
+// with a want-list session; the DHT is only consulted for blocks none of
+// them have.
+func (n *Node) openSession(ctx context.Context, rootCidObj cid.Cid) (*session, *fileManifest, error) {
+	sess := n.newSession(ctx, nil)
+	sess.discover(ctx, []cid.Cid{rootCidObj})
+
+	// Peers holding the manifest most likely hold its chunks too, so they are
+	// tried alongside the per-chunk providers.
+	var providers []peer.AddrInfo
+	found, err := sess.await(ctx, rootCidObj)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-
-	var provider peer.AddrInfo
-	for _, p := range peerChan {
-		if p.ID == n.Host.ID() {
-			continue
+	if !found {
+		providers, err = n.dht.FindProviders(ctx, rootCidObj)
+		if err != nil {
+			return nil, nil, err
 		}
-		provider = p
-		break
-	}
-	if provider.ID == "" {
-		return nil, fmt.Errorf("no providers found for root CID")
+		log.Printf("Found %d providers for root CID", len(providers))
 	}
-	log.Printf("Found provider: %s", provider.ID)
+	sess.fallback = n.newBlockFetcher(providers)
 
-	manifestData, err := n.requestBlock(ctx, provider, rootCidObj.String())
+	manifestData, err := sess.fetch(ctx, rootCidObj)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get manifest block from network: %w", err)
+		return nil, nil, fmt.Errorf("failed to get manifest block from network: %w", err)
 	}
 
-	manifest := &api.Manifest{}
-	if err := proto.Unmarshal(manifestData, manifest); err != nil {
-		return nil, err
+	m, err := decodeManifest(manifestData)
+	if err != nil {
+		return nil, nil, err
 	}
+	sess.discover(ctx, m.blocks())
+	return sess, m, nil
+}
 
-	var wg sync.WaitGroup
-	chunks := make(map[int][]byte)
-	var mtx sync.Mutex
-
-	for i, chunkCIDStr := range manifest.BlockCids {
-		wg.Add(1)
-		go func(idx int, cStr string) {
-			defer wg.Done()
-			chunkData, err := n.requestBlock(ctx, provider, cStr)
-			if err != nil {
-				log.Printf("Error getting block %s: %v", cStr, err)
-				return
-			}
-			mtx.Lock()
-			chunks[idx] = chunkData
-			mtx.Unlock()
-		}(i, chunkCIDStr)
+// newReader returns a reader over the chunks of m fetched with fetch and
+// the file's metadata, opening an encrypted file with the key in opts and
+// rebuilding chunks of an erasure-coded file that cannot be fetched.
+// discover, if not nil, is told the blocks below every DagNode read.
+func (n *Node) newReader(ctx context.Context, m *fileManifest, fetch blockFetchFunc, discover func(context.Context, []cid.Cid), opts GetOptions) (io.ReadCloser, FileInfo, error) {
+	m, err := m.unseal(opts.Key)
+	if err != nil {
+		return nil, m.info, err
 	}
-	wg.Wait()
-
-	var fileData []byte
-	for i := 0; i < len(manifest.BlockCids); i++ {
-		if chunks[i] == nil {
-			return nil, fmt.Errorf("failed to retrieve chunk %d", i)
-		}
-		fileData = append(fileData, chunks[i]...)
+	if opts.Offset < 0 || opts.Length < 0 || (m.info.Size >= 0 && opts.Offset > m.info.Size) {
+		return nil, m.info, fmt.Errorf("%w: offset %d, length %d", ErrInvalidRange, opts.Offset, opts.Length)
 	}
-
-	return bytes.NewReader(fileData), nil
+	chunks, skip, err := m.chunkIter(ctx, fetch, discover, opts.Offset)
+	if err != nil {
+		return nil, m.info, err
+	}
+	if opts.Length > 0 {
+		chunks = limitChunks(chunks, skip+opts.Length)
+	}
+	fetchChunk, err := n.chunkFetcher(m, fetch)
+	if err != nil {
+		return nil, m.info, err
+	}
+	var r io.ReadCloser = newFileReader(ctx, chunks, prefetchWindow, fetchChunk)
+	left := opts.Length
+	if left == 0 {
+		left = -1
+	}
+	if skip > 0 || left > 0 {
+		r = &rangeReader{ReadCloser: r, skip: skip, left: left}
+	}
+	return r, m.info, nil
 }
 
-// requestBlock handles sending a request for a block to a peer.
-func (n *Node) requestBlock(ctx context.Context, p peer.AddrInfo, cidStr string) ([]byte, error) {
-	s, err := n.Host.NewStream(ctx, p.ID, p2p.BlockProtocolID)
+// unseal returns m opened with key if it is encrypted. Directories cannot
+// be read as files. On error, the returned manifest is m.
+func (m *fileManifest) unseal(key []byte) (*fileManifest, error) {
+	if m.info.Directory {
+		return m, ErrIsDirectory
+	}
+	if m.sealed == nil {
+		return m, nil
+	}
+	if key == nil {
+		return m, ErrKeyRequired
+	}
+	opened, err := m.open(key)
 	if err != nil {
-		return nil, err
+		return m, err
 	}
-	defer s.Close()
+	return opened, nil
+}
 
-	_, err = s.Write([]byte(cidStr))
-	if err != nil {
-		return nil, err
+// chunkFetcher returns how to read the content of m's chunks from blocks
+// fetched with fetch.
+func (n *Node) chunkFetcher(m *fileManifest, fetch blockFetchFunc) (chunkFetchFunc, error) {
+	if m.erasure != nil {
+		fetch = n.newStripeRecovery(m, fetch).fetch
 	}
-	s.CloseWrite()
+	fetchChunk := fetchChunks(fetch)
+	if m.key != nil {
+		var err error
+		if fetchChunk, err = m.decrypter(fetch); err != nil {
+			return nil, err
+		}
+	}
+	return checkChunkSize(fetchChunk), nil
+}
 
-	return io.ReadAll(s)
+// checkChunkSize makes sure chunks are as large as the manifest says.
+func checkChunkSize(fetch chunkFetchFunc) chunkFetchFunc {
+	return func(ctx context.Context, ref chunkRef) ([]byte, error) {
+		data, err := fetch(ctx, ref)
+		if err == nil && ref.size >= 0 && int64(len(data)) != ref.size {
+			return nil, fmt.Errorf("chunk %s has %d bytes, the manifest says %d", ref.cid, len(data), ref.size)
+		}
+		return data, err
+	}
 }
 
-// setupBlockRequestHandler sets up the handler for responding to block requests.
+// setupBlockRequestHandler sets up the handlers for responding to block requests.
 func (n *Node) setupBlockRequestHandler() {
+	n.Host.SetStreamHandler(p2p.BlockProtocolV2ID, n.handleBlockStreamV2)
+	n.Host.SetStreamHandler(p2p.WantProtocolID, n.handleWantStream)
+	n.Host.SetStreamHandler(p2p.ReplicateProtocolID, n.handleReplicateStream)
 	n.Host.SetStreamHandler(p2p.BlockProtocolID, func(s network.Stream) {
 		defer s.Close()
 		cidBytes, err := io.ReadAll(s)
@@ -0,0 +1,28 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Yashh56/p2p-storage/internal/p2p"
+	"golang.org/x/net/context"
+)
+
+func TestNewNode_ReplicationInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := newTestNode(t).store
+	cfg := Config{Host: p2p.HostConfig{ListenAddrs: []string{"/ip4/127.0.0.1/tcp/0"}}}
+
+	// A zero interval would make the replication ticker panic.
+	if _, err := NewNode(ctx, store, cfg); err == nil {
+		t.Fatal("no error for a zero replication interval")
+	}
+
+	cfg.Replication.Interval = time.Hour
+	n, err := NewNode(ctx, store, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Host.Close()
+}
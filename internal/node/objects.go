@@ -0,0 +1,402 @@
+package node
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// bucketPrefix and objectPrefix are the metadata prefixes of the
+	// object index, which names files by bucket and key.
+	bucketPrefix = "buckets/"
+	objectPrefix = "objects/"
+	// objectRefPrefix counts the objects that refer to a root. The index
+	// keeps a root pinned while any object refers to it.
+	objectRefPrefix = "objectrefs/"
+	// objectRefBorrowed follows the count of a root that was pinned before
+	// the index referred to it. Its pin is left alone when the last object
+	// goes away.
+	objectRefBorrowed = "borrowed"
+	// maxObjectKey is the longest key accepted, in bytes.
+	maxObjectKey = 1024
+)
+
+var (
+	// ErrNoSuchBucket is returned for a bucket that was not created.
+	ErrNoSuchBucket = errors.New("bucket does not exist")
+	// ErrBucketNotEmpty is returned by DeleteBucket for a bucket that still
+	// holds objects.
+	ErrBucketNotEmpty = errors.New("bucket is not empty")
+	// ErrNoSuchKey is returned for a key that names no object.
+	ErrNoSuchKey = errors.New("object does not exist")
+	// ErrInvalidName is returned for a bucket name or key that cannot be
+	// used.
+	ErrInvalidName = errors.New("invalid bucket name or key")
+	// ErrBadDigest is returned when content does not match the MD5 digest
+	// sent with it.
+	ErrBadDigest = errors.New("content does not match its MD5 digest")
+)
+
+// bucketName follows the S3 naming rules, short of rejecting names that
+// look like IP addresses.
+var bucketName = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// Bucket is a namespace of objects.
+type Bucket struct {
+	Name    string
+	Created time.Time
+}
+
+// Object is a file stored under a key in a bucket.
+type Object struct {
+	Bucket string
+	Key    string
+	Root   cid.Cid
+	Size   int64
+	// ETag is the hex MD5 of the content or, for an object assembled from
+	// parts, the MD5 of the parts' MD5s followed by "-" and the number of
+	// parts.
+	ETag        string
+	ContentType string
+	Modified    time.Time
+	// Metadata holds user-defined metadata.
+	Metadata map[string]string
+}
+
+// ObjectOptions are the attributes of an object being stored.
+type ObjectOptions struct {
+	// ContentType is guessed from the key or the content when empty.
+	ContentType string
+	Metadata    map[string]string
+	// ContentMD5, if set, is checked against the content.
+	ContentMD5 []byte
+}
+
+// CreateBucket creates a bucket. Creating an existing bucket is not an
+// error.
+func (n *Node) CreateBucket(name string) error {
+	if !bucketName.MatchString(name) {
+		return fmt.Errorf("%w: bucket %q", ErrInvalidName, name)
+	}
+	n.objectsMu.Lock()
+	defer n.objectsMu.Unlock()
+
+	if _, err := n.StatBucket(name); !errors.Is(err, ErrNoSuchBucket) {
+		return err
+	}
+	return n.putMetaProto(bucketPrefix+name, &api.Bucket{CreatedAt: time.Now().Unix()})
+}
+
+// StatBucket returns a bucket.
+func (n *Node) StatBucket(name string) (Bucket, error) {
+	b := &api.Bucket{}
+	if err := n.getMetaProto(bucketPrefix+name, b); errors.Is(err, storage.ErrMetaNotFound) {
+		return Bucket{}, fmt.Errorf("%w: %s", ErrNoSuchBucket, name)
+	} else if err != nil {
+		return Bucket{}, err
+	}
+	return Bucket{Name: name, Created: time.Unix(b.CreatedAt, 0)}, nil
+}
+
+// DeleteBucket deletes an empty bucket.
+func (n *Node) DeleteBucket(name string) error {
+	n.objectsMu.Lock()
+	defer n.objectsMu.Unlock()
+
+	if _, err := n.StatBucket(name); err != nil {
+		return err
+	}
+	errFound := errors.New("found")
+	err := n.store.ScanMeta(objectPrefix+name+"/", func(string, []byte) error { return errFound })
+	if errors.Is(err, errFound) {
+		return fmt.Errorf("%w: %s", ErrBucketNotEmpty, name)
+	}
+	if err != nil {
+		return err
+	}
+	return n.store.DeleteMeta(bucketPrefix + name)
+}
+
+// ListBuckets returns every bucket, ordered by name.
+func (n *Node) ListBuckets() ([]Bucket, error) {
+	var buckets []Bucket
+	err := n.store.ScanMeta(bucketPrefix, func(name string, value []byte) error {
+		b := &api.Bucket{}
+		if err := proto.Unmarshal(value, b); err != nil {
+			return fmt.Errorf("invalid bucket %s: %w", name, err)
+		}
+		buckets = append(buckets, Bucket{Name: name, Created: time.Unix(b.CreatedAt, 0)})
+		return nil
+	})
+	return buckets, err
+}
+
+// PutObject adds the content read from r as a file and stores it under key
+// in bucket, replacing any object already there.
+func (n *Node) PutObject(ctx context.Context, bucket, key string, r io.Reader, opts ObjectOptions) (Object, error) {
+	if err := checkObjectKey(key); err != nil {
+		return Object{}, err
+	}
+	if _, err := n.StatBucket(bucket); err != nil {
+		return Object{}, err
+	}
+	h := md5.New()
+	res, pinned, err := n.addObjectFile(ctx, io.TeeReader(r, h), AddOptions{Name: path.Base(key), ContentType: opts.ContentType})
+	if err != nil {
+		return Object{}, err
+	}
+	sum := h.Sum(nil)
+	if opts.ContentMD5 != nil && !bytes.Equal(sum, opts.ContentMD5) {
+		n.objectsMu.Lock()
+		defer n.objectsMu.Unlock()
+		if err := n.addObjectRef(res.Root, 0, pinned); err != nil {
+			return Object{}, err
+		}
+		return Object{}, ErrBadDigest
+	}
+	return n.commitObject(Object{
+		Bucket:   bucket,
+		Key:      key,
+		Root:     res.Root,
+		Size:     res.Size,
+		ETag:     hex.EncodeToString(sum),
+		Modified: time.Now(),
+		Metadata: opts.Metadata,
+	}, pinned)
+}
+
+// addObjectFile stores a file for the object index, like AddFile. Its root
+// is pinned unless it already was, and pinned reports whether the pin is
+// the index's own.
+func (n *Node) addObjectFile(ctx context.Context, r io.Reader, opts AddOptions) (res AddResult, pinned bool, err error) {
+	n.gcLock.RLock()
+	defer n.gcLock.RUnlock()
+
+	if res, err = n.addFile(ctx, r, opts); err != nil {
+		return AddResult{}, false, err
+	}
+	pinned, err = n.store.PinNew(res.Root, storage.PinRecursive)
+	return res, pinned, err
+}
+
+// commitObject records a file added by addObjectFile in the object index.
+// pinned says whether adding it pinned its root; that pin is released
+// again if the bucket went away.
+func (n *Node) commitObject(o Object, pinned bool) (Object, error) {
+	m, err := n.localManifest(o.Root)
+	if err != nil {
+		return Object{}, err
+	}
+	o.ContentType = m.info.ContentType
+
+	n.objectsMu.Lock()
+	defer n.objectsMu.Unlock()
+
+	if _, err := n.StatBucket(o.Bucket); err != nil {
+		if rerr := n.addObjectRef(o.Root, 0, pinned); rerr != nil {
+			return Object{}, rerr
+		}
+		return Object{}, err
+	}
+	old, err := n.loadObject(o.Bucket, o.Key)
+	if err != nil && !errors.Is(err, ErrNoSuchKey) {
+		return Object{}, err
+	}
+	if err := n.addObjectRef(o.Root, 1, pinned); err != nil {
+		return Object{}, err
+	}
+	entry := &api.Object{
+		Cid:         o.Root.String(),
+		Size:        o.Size,
+		Etag:        o.ETag,
+		ContentType: o.ContentType,
+		ModifiedAt:  o.Modified.Unix(),
+	}
+	for k, v := range o.Metadata {
+		entry.Metadata = append(entry.Metadata, &api.ObjectMetadata{Key: k, Value: v})
+	}
+	if err := n.putMetaProto(objectKey(o.Bucket, o.Key), entry); err != nil {
+		return Object{}, err
+	}
+	if old.Root.Defined() {
+		if err := n.addObjectRef(old.Root, -1, false); err != nil {
+			return Object{}, err
+		}
+	}
+	o.Modified = time.Unix(entry.ModifiedAt, 0)
+	return o, nil
+}
+
+// GetObject returns the object stored under key in bucket. Its content is
+// read with GetFile.
+func (n *Node) GetObject(bucket, key string) (Object, error) {
+	o, err := n.loadObject(bucket, key)
+	if errors.Is(err, ErrNoSuchKey) {
+		if _, berr := n.StatBucket(bucket); berr != nil {
+			return Object{}, berr
+		}
+	}
+	return o, err
+}
+
+// DeleteObject removes an object from the index. Its content is unpinned
+// once no object refers to it any more, and stays until the next garbage
+// collection. Deleting a missing object is not an error.
+func (n *Node) DeleteObject(bucket, key string) error {
+	n.objectsMu.Lock()
+	defer n.objectsMu.Unlock()
+
+	if _, err := n.StatBucket(bucket); err != nil {
+		return err
+	}
+	o, err := n.loadObject(bucket, key)
+	if errors.Is(err, ErrNoSuchKey) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := n.store.DeleteMeta(objectKey(bucket, key)); err != nil {
+		return err
+	}
+	return n.addObjectRef(o.Root, -1, false)
+}
+
+// ListObjects calls fn with every object in bucket whose key starts with
+// prefix and sorts after startAfter, in key order. An error returned by fn
+// stops the listing and is returned.
+func (n *Node) ListObjects(bucket, prefix, startAfter string, fn func(Object) error) error {
+	if _, err := n.StatBucket(bucket); err != nil {
+		return err
+	}
+	return n.store.ScanMeta(objectPrefix+bucket+"/"+prefix, func(rest string, value []byte) error {
+		key := prefix + rest
+		if key <= startAfter {
+			return nil
+		}
+		o, err := decodeObject(bucket, key, value)
+		if err != nil {
+			return err
+		}
+		return fn(o)
+	})
+}
+
+func (n *Node) loadObject(bucket, key string) (Object, error) {
+	value, err := n.store.GetMeta(objectKey(bucket, key))
+	if errors.Is(err, storage.ErrMetaNotFound) {
+		return Object{}, fmt.Errorf("%w: %s/%s", ErrNoSuchKey, bucket, key)
+	}
+	if err != nil {
+		return Object{}, err
+	}
+	return decodeObject(bucket, key, value)
+}
+
+func decodeObject(bucket, key string, value []byte) (Object, error) {
+	entry := &api.Object{}
+	if err := proto.Unmarshal(value, entry); err != nil {
+		return Object{}, fmt.Errorf("invalid object %s/%s: %w", bucket, key, err)
+	}
+	root, err := cid.Decode(entry.Cid)
+	if err != nil {
+		return Object{}, fmt.Errorf("invalid object %s/%s: %w", bucket, key, err)
+	}
+	o := Object{
+		Bucket:      bucket,
+		Key:         key,
+		Root:        root,
+		Size:        entry.Size,
+		ETag:        entry.Etag,
+		ContentType: entry.ContentType,
+		Modified:    time.Unix(entry.ModifiedAt, 0),
+	}
+	if len(entry.Metadata) > 0 {
+		o.Metadata = make(map[string]string, len(entry.Metadata))
+		for _, m := range entry.Metadata {
+			o.Metadata[m.Key] = m.Value
+		}
+	}
+	return o, nil
+}
+
+// addObjectRef adds delta to the number of objects that refer to root and,
+// when none is left, unpins it if the index pinned it. pinned says whether
+// the caller just pinned root for the index. objectsMu must be held.
+func (n *Node) addObjectRef(root cid.Cid, delta int, pinned bool) error {
+	key := objectRefPrefix + root.String()
+	refs, owned := 0, pinned
+	value, err := n.store.GetMeta(key)
+	if err == nil {
+		count, flag, _ := strings.Cut(string(value), " ")
+		if refs, err = strconv.Atoi(count); err != nil {
+			return fmt.Errorf("invalid reference count of %s: %w", root, err)
+		}
+		owned = owned || flag != objectRefBorrowed
+	} else if !errors.Is(err, storage.ErrMetaNotFound) {
+		return err
+	}
+	if refs += delta; refs > 0 {
+		value := strconv.Itoa(refs)
+		if !owned {
+			value += " " + objectRefBorrowed
+		}
+		return n.store.PutMeta(key, []byte(value))
+	}
+	if err := n.store.DeleteMeta(key); err != nil {
+		return err
+	}
+	if !owned {
+		return nil
+	}
+	if err := n.Unpin(root); err != nil && !errors.Is(err, storage.ErrNotPinned) {
+		return err
+	}
+	return nil
+}
+
+func objectKey(bucket, key string) string {
+	return objectPrefix + bucket + "/" + key
+}
+
+func checkObjectKey(key string) error {
+	if key == "" || len(key) > maxObjectKey || !utf8.ValidString(key) {
+		return fmt.Errorf("%w: key %q", ErrInvalidName, key)
+	}
+	return nil
+}
+
+func (n *Node) putMetaProto(key string, m proto.Message) error {
+	value, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return n.store.PutMeta(key, value)
+}
+
+func (n *Node) getMetaProto(key string, m proto.Message) error {
+	value, err := n.store.GetMeta(key)
+	if err != nil {
+		return err
+	}
+	if err := proto.Unmarshal(value, m); err != nil {
+		return fmt.Errorf("invalid metadata %s: %w", key, err)
+	}
+	return nil
+}
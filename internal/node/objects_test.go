@@ -0,0 +1,184 @@
+package node
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/net/context"
+)
+
+func TestObjects_IndexAndPins(t *testing.T) {
+	n := newTestNode(t)
+
+	if err := n.CreateBucket("Not_Valid"); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("got %v for an invalid bucket name, want ErrInvalidName", err)
+	}
+	if err := n.CreateBucket("photos"); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.CreateBucket("photos"); err != nil {
+		t.Fatalf("creating an existing bucket: %v", err)
+	}
+	if buckets, err := n.ListBuckets(); err != nil || len(buckets) != 1 || buckets[0].Name != "photos" {
+		t.Fatalf("got buckets %v, %v", buckets, err)
+	}
+
+	// Two keys share the same content, as addObjectFile would store it.
+	put := func(key string, root cid.Cid) {
+		t.Helper()
+		pinned, err := n.store.PinNew(root, storage.PinRecursive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := n.commitObject(Object{Bucket: "photos", Key: key, Root: root, Size: 100, Modified: time.Now()}, pinned); err != nil {
+			t.Fatal(err)
+		}
+	}
+	content := bytes.Repeat([]byte("0123456789"), 10)
+	shared, _ := storeFile(t, n, content, 16, "")
+	other, _ := storeFile(t, n, content[:50], 16, "")
+	put("2024/a.jpg", shared)
+	put("2024/b.jpg", shared)
+	put("2025/c.jpg", other)
+
+	var keys []string
+	err := n.ListObjects("photos", "2024/", "2024/a.jpg", func(o Object) error {
+		keys = append(keys, o.Key)
+		return nil
+	})
+	if err != nil || strings.Join(keys, ",") != "2024/b.jpg" {
+		t.Fatalf("got keys %v, %v", keys, err)
+	}
+	if o, err := n.GetObject("photos", "2024/a.jpg"); err != nil || !o.Root.Equals(shared) {
+		t.Fatalf("got object %+v, %v", o, err)
+	}
+	if _, err := n.GetObject("photos", "missing"); !errors.Is(err, ErrNoSuchKey) {
+		t.Fatalf("got %v for a missing key, want ErrNoSuchKey", err)
+	}
+	if _, err := n.GetObject("videos", "a"); !errors.Is(err, ErrNoSuchBucket) {
+		t.Fatalf("got %v for a missing bucket, want ErrNoSuchBucket", err)
+	}
+	if err := n.DeleteBucket("photos"); !errors.Is(err, ErrBucketNotEmpty) {
+		t.Fatalf("got %v deleting a bucket with objects, want ErrBucketNotEmpty", err)
+	}
+
+	pinned := func(c cid.Cid) bool {
+		pins, err := n.store.Pins()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range pins {
+			if p.Cid.Equals(c) {
+				return true
+			}
+		}
+		return false
+	}
+	// Content stays pinned while any key refers to it, including after a
+	// key is overwritten with other content.
+	if err := n.DeleteObject("photos", "2024/a.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if !pinned(shared) {
+		t.Fatal("content unpinned while another key refers to it")
+	}
+	put("2024/b.jpg", other)
+	if pinned(shared) {
+		t.Fatal("content still pinned after its last key was overwritten")
+	}
+	for _, key := range []string{"2024/b.jpg", "2025/c.jpg", "2025/c.jpg"} {
+		if err := n.DeleteObject("photos", key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pinned(other) {
+		t.Fatal("content still pinned after its keys were deleted")
+	}
+
+	// Content pinned before an object referred to it keeps its pin.
+	if err := n.store.Pin(shared, storage.PinRecursive); err != nil {
+		t.Fatal(err)
+	}
+	put("2026/d.jpg", shared)
+	put("2026/d.jpg", other)
+	if !pinned(shared) {
+		t.Fatal("a pin the index did not add was removed")
+	}
+	if err := n.DeleteObject("photos", "2026/d.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if !pinned(shared) || pinned(other) {
+		t.Fatal("deleting the object changed pins it did not own")
+	}
+	if err := n.DeleteBucket("photos"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMultipart_PartsSurviveGC(t *testing.T) {
+	n := newTestNode(t)
+	ctx := context.Background()
+
+	if _, err := n.StartMultipart("backups", "db.tar", ObjectOptions{}); !errors.Is(err, ErrNoSuchBucket) {
+		t.Fatalf("got %v for a missing bucket, want ErrNoSuchBucket", err)
+	}
+	if err := n.CreateBucket("backups"); err != nil {
+		t.Fatal(err)
+	}
+	id, err := n.StartMultipart("backups", "db.tar", ObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Parts may arrive out of order.
+	etag2, err := n.PutPart("backups", "db.tar", id, 2, bytes.NewReader([]byte("second")), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.PutPart("backups", "db.tar", id, 1, bytes.NewReader([]byte("garbled")), []byte("not the digest")); !errors.Is(err, ErrBadDigest) {
+		t.Fatalf("got %v for a wrong Content-MD5, want ErrBadDigest", err)
+	}
+	if _, err := n.PutPart("backups", "db.tar", id, 0, bytes.NewReader(nil), nil); !errors.Is(err, ErrInvalidPart) {
+		t.Fatalf("got %v for part 0, want ErrInvalidPart", err)
+	}
+	etag1, err := n.PutPart("backups", "db.tar", id, 1, bytes.NewReader([]byte("first")), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The recorded parts survive garbage collection; the block of the part
+	// that failed its digest check does not.
+	res, err := n.GC(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RemovedBlocks != 1 {
+		t.Fatalf("GC removed %d blocks, want 1", res.RemovedBlocks)
+	}
+	for _, parts := range [][]CompletedPart{
+		{{Number: 2, ETag: etag2}, {Number: 1, ETag: etag1}},
+		{{Number: 1, ETag: etag2}},
+		{{Number: 3, ETag: etag1}},
+	} {
+		if _, err := n.CompleteMultipart(ctx, "backups", "db.tar", id, parts); !errors.Is(err, ErrInvalidPart) && !errors.Is(err, ErrInvalidPartOrder) {
+			t.Fatalf("got %v completing with %v", err, parts)
+		}
+	}
+
+	if err := n.AbortMultipart("backups", "other.tar", id); !errors.Is(err, ErrNoSuchUpload) {
+		t.Fatalf("got %v aborting under another key, want ErrNoSuchUpload", err)
+	}
+	if err := n.AbortMultipart("backups", "db.tar", id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.PutPart("backups", "db.tar", id, 3, bytes.NewReader([]byte("third")), nil); !errors.Is(err, ErrNoSuchUpload) {
+		t.Fatalf("got %v after abort, want ErrNoSuchUpload", err)
+	}
+	if res, err = n.GC(ctx); err != nil || res.RemovedBlocks != 2 {
+		t.Fatalf("GC removed %d blocks, %v after abort, want the 2 parts", res.RemovedBlocks, err)
+	}
+}
@@ -0,0 +1,207 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/net/context"
+)
+
+// Pin protects content from garbage collection and eviction. Content that is
+// not stored locally is fetched from the network first: the whole file for a
+// recursive pin, the single block for a direct pin. gcLock is only held to pin
+// blocks that are all stored, never while waiting on the network.
+func (n *Node) Pin(ctx context.Context, c cid.Cid, mode storage.PinMode) error {
+	switch mode {
+	case storage.PinRecursive:
+		if err := n.fetchAll(ctx, c); err != nil {
+			return err
+		}
+	case storage.PinDirect:
+		if ok, _ := n.store.Has(c); !ok {
+			if _, err := n.newBlockFetcher(nil).fetch(ctx, c); err != nil {
+				return fmt.Errorf("failed to fetch %s: %w", c, err)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown pin mode %v", mode)
+	}
+
+	n.gcLock.RLock()
+	defer n.gcLock.RUnlock()
+	// A collection may have swept the cached blocks in the meantime.
+	blocks := []cid.Cid{c}
+	if mode == storage.PinRecursive {
+		var err error
+		if blocks, err = n.storedBlocks(c); err != nil {
+			return err
+		}
+	} else if ok, _ := n.store.Has(c); !ok {
+		return fmt.Errorf("%s is not stored locally", c)
+	}
+	if err := n.store.Pin(c, mode); err != nil {
+		return err
+	}
+	// Fetched blocks were cached; once pinned they must not be evicted.
+	return n.store.Uncache(blocks...)
+}
+
+// storedBlocks returns the root manifest c and the blocks below it, or an
+// error if a block needed to read the file is not stored locally. The
+// parity blocks of an erasure-coded file are optional, as fetchAll only
+// fetches them on a best-effort basis.
+func (n *Node) storedBlocks(c cid.Cid) ([]cid.Cid, error) {
+	m, err := n.localManifest(c)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not stored locally: %w", c, err)
+	}
+	blocks, err := n.descendants(c)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not fully stored locally: %w", c, err)
+	}
+	optional := make(map[cid.Cid]bool)
+	if m.erasure != nil {
+		for _, s := range m.erasure.stripes {
+			for _, p := range s.parity {
+				optional[p] = true
+			}
+		}
+	}
+	for _, b := range blocks {
+		if ok, _ := n.store.Has(b); !ok && !optional[b] {
+			return nil, fmt.Errorf("%s is not fully stored locally: %s is missing", c, b)
+		}
+	}
+	return append([]cid.Cid{c}, blocks...), nil
+}
+
+// fetchAll makes sure the root manifest c and all of its chunks are stored
+// locally.
+func (n *Node) fetchAll(ctx context.Context, c cid.Cid) error {
+	if n.hasAll(c) {
+		return nil
+	}
+	// Blocks are fetched as stored, so this works without the key of an
+	// encrypted file.
+	sess, m, err := n.openSession(ctx, c)
+	if err != nil {
+		return err
+	}
+	return n.fetchBlocks(ctx, m, n.localFirst(sess.fetch), sess.discover)
+}
+
+// fetchBlocks fetches every block below the root manifest m. The chunks of
+// an erasure-coded file that cannot be fetched are rebuilt from the rest of
+// their stripe, and its parity blocks are only fetched on a best-effort
+// basis, since the file can be read without them.
+func (n *Node) fetchBlocks(ctx context.Context, m *fileManifest, fetch blockFetchFunc, discover func(context.Context, []cid.Cid)) error {
+	if m.erasure == nil {
+		blocks, err := m.allBlocks(ctx, fetch, discover)
+		if err != nil {
+			return err
+		}
+		return fetchEach(ctx, blocks, fetch)
+	}
+
+	if err := fetchEach(ctx, m.chunks, n.newStripeRecovery(m, fetch).fetch); err != nil {
+		return err
+	}
+	var parity []cid.Cid
+	for _, s := range m.erasure.stripes {
+		parity = append(parity, s.parity...)
+	}
+	return fetchEach(ctx, parity, func(ctx context.Context, c cid.Cid) ([]byte, error) {
+		if _, err := fetch(ctx, c); err != nil && ctx.Err() == nil {
+			log.Printf("Parity block %s is unavailable: %v", c, err)
+		}
+		return nil, ctx.Err()
+	})
+}
+
+// fetchEach fetches blocks, prefetchWindow at a time, and drops them.
+func fetchEach(ctx context.Context, blocks []cid.Cid, fetch blockFetchFunc) error {
+	r := newFileReader(ctx, cidIter(blocks), prefetchWindow, fetchChunks(fetch))
+	defer r.Close()
+	_, err := io.Copy(io.Discard, r)
+	return err
+}
+
+// Unpin removes a pin and stops replicating the file. The content stays
+// until the next garbage collection.
+func (n *Node) Unpin(c cid.Cid) error {
+	if err := n.store.Unpin(c); err != nil {
+		return err
+	}
+	return n.store.DeleteMeta(replicationPrefix + c.String())
+}
+
+// ListPins returns the pin set.
+func (n *Node) ListPins() ([]storage.Pin, error) {
+	return n.store.Pins()
+}
+
+// GC removes every block that is not protected by a pin, by an upload
+// session in progress or by a multipart upload in progress.
+func (n *Node) GC(ctx context.Context) (storage.GCResult, error) {
+	n.gcLock.Lock()
+	defer n.gcLock.Unlock()
+	parts, err := n.uploadBlocks()
+	if err != nil {
+		return storage.GCResult{}, err
+	}
+	multipart, err := n.multipartBlocks()
+	if err != nil {
+		return storage.GCResult{}, err
+	}
+	return n.store.GC(ctx, n.descendants, append(parts, multipart...)...)
+}
+
+// hasAll reports whether the root manifest c and all of its chunks are
+// stored locally.
+func (n *Node) hasAll(c cid.Cid) bool {
+	chunks, err := n.descendants(c)
+	if err != nil {
+		return false
+	}
+	for _, chunk := range chunks {
+		if ok, _ := n.store.Has(chunk); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// descendants lists the blocks below a root manifest held in the local
+// store. It fails if a DagNode is missing.
+func (n *Node) descendants(root cid.Cid) ([]cid.Cid, error) {
+	m, err := n.localManifest(root)
+	if err != nil {
+		return nil, err
+	}
+	return m.allBlocks(context.Background(), func(ctx context.Context, c cid.Cid) ([]byte, error) {
+		return n.store.Get(c)
+	}, nil)
+}
+
+// localFirst wraps fetch to read blocks the local store already holds
+// without asking the network.
+func (n *Node) localFirst(fetch blockFetchFunc) blockFetchFunc {
+	return func(ctx context.Context, c cid.Cid) ([]byte, error) {
+		if data, err := n.store.Get(c); err == nil {
+			return data, nil
+		}
+		return fetch(ctx, c)
+	}
+}
+
+// localManifest decodes a root manifest held in the local store.
+func (n *Node) localManifest(root cid.Cid) (*fileManifest, error) {
+	data, err := n.store.Get(root)
+	if err != nil {
+		return nil, err
+	}
+	return decodeManifest(data)
+}
@@ -0,0 +1,77 @@
+package node
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Yashh56/p2p-storage/internal/p2p"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/libp2p/go-libp2p/core/network"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"golang.org/x/net/context"
+)
+
+func TestPin_CollectsWhileFetching(t *testing.T) {
+	mn := mocknet.New()
+	defer mn.Close()
+	client := newExchangeNode(t, mn)
+	holder := newExchangeNode(t, mn)
+	// The holder answers the want-list for the manifest, then does not
+	// send chunks until it is released.
+	requested, release := make(chan struct{}), make(chan struct{})
+	var wants atomic.Int32
+	holder.Host.SetStreamHandler(p2p.WantProtocolID, func(s network.Stream) {
+		if n := wants.Add(1); n > 1 {
+			if n == 2 {
+				close(requested)
+			}
+			<-release
+		}
+		holder.handleWantStream(s)
+	})
+	if err := mn.LinkAll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mn.ConnectAllButSelf(); err != nil {
+		t.Fatal(err)
+	}
+	root, _ := storeFile(t, holder, []byte("0123456789abcdefghij"), 4, "")
+
+	pinned := make(chan error, 1)
+	go func() {
+		pinned <- client.Pin(context.Background(), root, storage.PinRecursive)
+	}()
+	select {
+	case <-requested:
+	case err := <-pinned:
+		t.Fatalf("pin returned %v before fetching", err)
+	}
+
+	// A collection does not wait for the download.
+	collected := make(chan error, 1)
+	go func() {
+		_, err := client.GC(context.Background())
+		collected <- err
+	}()
+	select {
+	case err := <-collected:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(wantHaveTimeout / 2):
+		close(release)
+		t.Fatal("GC waited for a pin that was fetching")
+	}
+
+	close(release)
+	if err := <-pinned; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GC(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !client.hasAll(root) {
+		t.Fatal("pinned file was collected")
+	}
+}
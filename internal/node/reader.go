@@ -0,0 +1,156 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/net/context"
+)
+
+// prefetchWindow is the number of chunks fetched ahead of the one being read.
+const prefetchWindow = 4
+
+// blockFetchFunc retrieves the data of a single block.
+type blockFetchFunc func(ctx context.Context, c cid.Cid) ([]byte, error)
+
+// chunkFetchFunc retrieves the content of a chunk of a file.
+type chunkFetchFunc func(ctx context.Context, ref chunkRef) ([]byte, error)
+
+// fetchChunks reads chunks as the blocks they are stored in.
+func fetchChunks(fetch blockFetchFunc) chunkFetchFunc {
+	return func(ctx context.Context, ref chunkRef) ([]byte, error) {
+		return fetch(ctx, ref.cid)
+	}
+}
+
+type fetchResult struct {
+	data []byte
+	err  error
+}
+
+// fileReader streams the chunks of a file in order. Chunks are fetched
+// concurrently, but never more than prefetchWindow ahead of the reader, so
+// memory use stays bounded no matter how large the file is.
+type fileReader struct {
+	cancel  context.CancelFunc
+	results chan chan fetchResult
+	cur     []byte
+	err     error
+}
+
+func newFileReader(ctx context.Context, chunks chunkIter, window int, fetch chunkFetchFunc) *fileReader {
+	ctx, cancel := context.WithCancel(ctx)
+	r := &fileReader{
+		cancel:  cancel,
+		results: make(chan chan fetchResult, window),
+	}
+
+	go func() {
+		defer close(r.results)
+		for i := 0; ; i++ {
+			ref, err := chunks(ctx)
+			if err == io.EOF {
+				return
+			}
+			res := make(chan fetchResult, 1)
+			// Blocks once the window is full, until the reader catches up.
+			select {
+			case r.results <- res:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				res <- fetchResult{err: fmt.Errorf("failed to find chunk %d: %w", i, err)}
+				return
+			}
+			go func(i int, ref chunkRef) {
+				data, err := fetch(ctx, ref)
+				if err != nil {
+					err = fmt.Errorf("failed to get chunk %d (%s): %w", i, ref.cid, err)
+				}
+				res <- fetchResult{data: data, err: err}
+			}(i, ref)
+		}
+	}()
+
+	return r
+}
+
+func (r *fileReader) Read(p []byte) (int, error) {
+	for len(r.cur) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		res, ok := <-r.results
+		if !ok {
+			r.err = io.EOF
+			continue
+		}
+		out := <-res
+		if out.err != nil {
+			r.err = out.err
+			r.cancel()
+			continue
+		}
+		r.cur = out.data
+	}
+
+	n := copy(p, r.cur)
+	r.cur = r.cur[n:]
+	return n, nil
+}
+
+// Close stops any outstanding fetches.
+func (r *fileReader) Close() error {
+	r.cancel()
+	r.cur = nil
+	if r.err == nil {
+		r.err = os.ErrClosed
+	}
+	return nil
+}
+
+// limitChunks stops chunks once they hold n bytes. Chunks of unknown size
+// never count towards n.
+func limitChunks(chunks chunkIter, n int64) chunkIter {
+	return func(ctx context.Context) (chunkRef, error) {
+		if n <= 0 {
+			return chunkRef{}, io.EOF
+		}
+		ref, err := chunks(ctx)
+		if err == nil && ref.size >= 0 {
+			n -= ref.size
+		}
+		return ref, err
+	}
+}
+
+// rangeReader drops the first skip bytes of a file and stops after left
+// more, or reads to the end if left is negative.
+type rangeReader struct {
+	io.ReadCloser
+	skip int64
+	left int64
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	if r.skip > 0 {
+		if _, err := io.CopyN(io.Discard, r.ReadCloser, r.skip); err != nil {
+			return 0, err
+		}
+		r.skip = 0
+	}
+	if r.left == 0 {
+		return 0, io.EOF
+	}
+	if r.left > 0 && int64(len(p)) > r.left {
+		p = p[:r.left]
+	}
+	n, err := r.ReadCloser.Read(p)
+	if r.left > 0 {
+		r.left -= int64(n)
+	}
+	return n, err
+}
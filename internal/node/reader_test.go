@@ -0,0 +1,58 @@
+package node
+
+import (
+	"bytes"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/net/context"
+)
+
+func TestFileReader_InOrderWithBoundedPrefetch(t *testing.T) {
+	blocks := make(map[cid.Cid][]byte)
+	var chunks []cid.Cid
+	var want []byte
+	for i := 0; i < 32; i++ {
+		data := bytes.Repeat([]byte{byte(i)}, 100+i)
+		c, err := storage.Sum(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blocks[c] = data
+		chunks = append(chunks, c)
+		want = append(want, data...)
+	}
+
+	var inFlight, maxInFlight int32
+	fetch := func(ctx context.Context, c cid.Cid) ([]byte, error) {
+		cur := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if cur <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, cur) {
+				break
+			}
+		}
+		// Finish later chunks first to make sure ordering does not depend on timing.
+		time.Sleep(time.Duration(len(blocks[c])%7) * time.Millisecond)
+		return blocks[c], nil
+	}
+
+	r := newFileReader(context.Background(), cidIter(chunks), 4, fetchChunks(fetch))
+	defer r.Close()
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("reader returned chunks out of order")
+	}
+	if maxInFlight > 5 {
+		t.Fatalf("expected at most 5 concurrent fetches, saw %d", maxInFlight)
+	}
+}
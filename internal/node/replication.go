@@ -0,0 +1,373 @@
+package node
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/p2p"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-msgio/pbio"
+	"golang.org/x/net/context"
+)
+
+const (
+	// replicaLookupTimeout bounds the provider lookup used to count replicas.
+	replicaLookupTimeout = 30 * time.Second
+	// replicateTimeout bounds pushing one file to one peer.
+	replicateTimeout = 10 * time.Minute
+	// replicationPrefix is the metadata prefix of replication targets.
+	replicationPrefix = "replication/"
+)
+
+// ReplicationConfig controls how a node keeps files replicated.
+type ReplicationConfig struct {
+	// Interval is how often the replica count of every replicated file is
+	// checked and repaired. It must be positive.
+	Interval time.Duration
+	// Accept lets other nodes push replicas to this node. Replicas are
+	// pinned, so only nodes that trust their peers should accept them.
+	Accept bool
+}
+
+// setReplication records that root should be held by factor nodes and
+// schedules a replication run for it.
+func (n *Node) setReplication(root cid.Cid, factor int) error {
+	value := binary.AppendUvarint(nil, uint64(factor))
+	if err := n.store.PutMeta(replicationPrefix+root.String(), value); err != nil {
+		return err
+	}
+	select {
+	case n.replicate <- root:
+	default:
+		// The periodic check picks it up.
+	}
+	return nil
+}
+
+// replicationTargets returns the replication factor of every replicated file.
+func (n *Node) replicationTargets() (map[cid.Cid]int, error) {
+	targets := make(map[cid.Cid]int)
+	err := n.store.ScanMeta(replicationPrefix, func(key string, value []byte) error {
+		c, err := cid.Decode(key)
+		if err != nil {
+			return err
+		}
+		factor, size := binary.Uvarint(value)
+		if size <= 0 {
+			return fmt.Errorf("corrupt replication target for %s", c)
+		}
+		targets[c] = int(factor)
+		return nil
+	})
+	return targets, err
+}
+
+// startReplication repairs files as they are added and, every interval,
+// checks all replicated files, until ctx is cancelled.
+func (n *Node) startReplication(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case root := <-n.replicate:
+				targets, err := n.replicationTargets()
+				if err != nil {
+					log.Printf("Error reading replication targets: %v", err)
+					continue
+				}
+				if factor, ok := targets[root]; ok {
+					n.repair(ctx, root, factor)
+				}
+			case <-ticker.C:
+				targets, err := n.replicationTargets()
+				if err != nil {
+					log.Printf("Error reading replication targets: %v", err)
+					continue
+				}
+				for root, factor := range targets {
+					n.repair(ctx, root, factor)
+				}
+			}
+		}
+	}()
+}
+
+// repair counts the providers of root and pushes the file to more peers
+// until factor nodes, including this one, hold it. The blocks of an
+// erasure-coded file are instead spread over distinct peers, whatever the
+// factor.
+func (n *Node) repair(ctx context.Context, root cid.Cid, factor int) {
+	blocks, err := n.fileBlocks(root)
+	if err != nil {
+		log.Printf("Cannot replicate %s: %v", root, err)
+		return
+	}
+	if m, err := n.localManifest(root); err == nil && m.erasure != nil {
+		n.distributeShards(ctx, root, m)
+		return
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, replicaLookupTimeout)
+	providers, err := n.dht.FindProviders(lookupCtx, root)
+	cancel()
+	if err != nil {
+		log.Printf("Error finding providers of %s: %v", root, err)
+		return
+	}
+	holders := map[peer.ID]bool{n.Host.ID(): true}
+	for _, p := range providers {
+		holders[p.ID] = true
+	}
+	if len(holders) >= factor {
+		return
+	}
+	log.Printf("%s has %d of %d replicas, repairing", root, len(holders), factor)
+
+	for _, p := range n.replicaCandidates(ctx, root, holders) {
+		if len(holders) >= factor {
+			break
+		}
+		if err := n.pushReplica(ctx, p, root, blocks, false); err != nil {
+			log.Printf("Failed to replicate %s to %s: %v", root, p, err)
+			n.peers.failed(p)
+			continue
+		}
+		log.Printf("Replicated %s to %s", root, p)
+		holders[p] = true
+	}
+	if len(holders) < factor {
+		log.Printf("%s is still under-replicated: %d of %d replicas", root, len(holders), factor)
+	}
+}
+
+// fileBlocks lists root and the blocks it references, all of which must be
+// stored locally.
+func (n *Node) fileBlocks(root cid.Cid) ([]cid.Cid, error) {
+	if !n.hasAll(root) {
+		return nil, fmt.Errorf("%s is not fully stored locally", root)
+	}
+	blocks, err := n.descendants(root)
+	if err != nil {
+		return nil, err
+	}
+	return append([]cid.Cid{root}, blocks...), nil
+}
+
+// replicaCandidates returns peers that could take a replica of root, the
+// peers closest to it in the DHT first, followed by the remaining neighbours.
+func (n *Node) replicaCandidates(ctx context.Context, root cid.Cid, exclude map[peer.ID]bool) []peer.ID {
+	lookupCtx, cancel := context.WithTimeout(ctx, replicaLookupTimeout)
+	closest, err := n.dht.GetClosestPeers(lookupCtx, string(root.Hash()))
+	cancel()
+	if err != nil {
+		log.Printf("Error finding peers close to %s: %v", root, err)
+	}
+
+	seen := make(map[peer.ID]bool)
+	var candidates []peer.ID
+	for _, p := range append(closest, n.Host.Network().Peers()...) {
+		if exclude[p] || seen[p] {
+			continue
+		}
+		seen[p] = true
+		candidates = append(candidates, p)
+	}
+	return candidates
+}
+
+// pushReplica sends the blocks p is missing and asks it to pin root,
+// recursively unless direct is set.
+func (n *Node) pushReplica(ctx context.Context, p peer.ID, root cid.Cid, blocks []cid.Cid, direct bool) error {
+	ctx, cancel := context.WithTimeout(ctx, replicateTimeout)
+	defer cancel()
+
+	s, err := n.Host.NewStream(ctx, p, p2p.ReplicateProtocolID)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		s.SetDeadline(deadline)
+	}
+
+	w := pbio.NewDelimitedWriter(s)
+	r := pbio.NewDelimitedReader(s, p2p.MaxMessageSize)
+	defer r.Close()
+
+	offer := &api.ReplicateOffer{Root: root.String(), Cids: make([]string, len(blocks)), Direct: direct}
+	offered := make(map[string]cid.Cid, len(blocks))
+	for i, c := range blocks {
+		offer.Cids[i] = c.String()
+		offered[c.String()] = c
+	}
+	if err := w.WriteMsg(offer); err != nil {
+		s.Reset()
+		return err
+	}
+
+	wants := &api.ReplicateWants{}
+	if err := r.ReadMsg(wants); err != nil {
+		s.Reset()
+		return err
+	}
+	if wants.GetError() != "" {
+		return fmt.Errorf("peer declined: %s", wants.GetError())
+	}
+	for _, cidStr := range wants.GetMissing() {
+		c, ok := offered[cidStr]
+		if !ok {
+			s.Reset()
+			return fmt.Errorf("peer asked for block %q that was not offered", cidStr)
+		}
+		data, err := n.store.Get(c)
+		if err != nil {
+			s.Reset()
+			return err
+		}
+		if err := w.WriteMsg(&api.BlockData{Cid: cidStr, Data: data}); err != nil {
+			s.Reset()
+			return err
+		}
+	}
+
+	result := &api.ReplicateResult{}
+	if err := r.ReadMsg(result); err != nil {
+		s.Reset()
+		return err
+	}
+	if result.GetError() != "" {
+		return errors.New(result.GetError())
+	}
+	return nil
+}
+
+// handleReplicateStream receives a replica pushed by another node, pins it
+// and announces it. Received blocks are cached, counting against the quota
+// and evictable like any fetched block, until the replica is complete.
+func (n *Node) handleReplicateStream(s network.Stream) {
+	defer s.Close()
+	remote := s.Conn().RemotePeer()
+	s.SetDeadline(time.Now().Add(replicateTimeout))
+
+	r := pbio.NewDelimitedReader(s, p2p.MaxMessageSize)
+	defer r.Close()
+	w := pbio.NewDelimitedWriter(s)
+
+	offer := &api.ReplicateOffer{}
+	if err := r.ReadMsg(offer); err != nil {
+		log.Printf("Error reading replica offer from %s: %v", remote, err)
+		s.Reset()
+		return
+	}
+	decline := func(reason string) {
+		if err := w.WriteMsg(&api.ReplicateWants{Error: reason}); err != nil {
+			s.Reset()
+		}
+	}
+	if !n.acceptReplicas {
+		decline("this node does not accept replicas")
+		return
+	}
+	root, err := cid.Decode(offer.GetRoot())
+	if err != nil {
+		decline("invalid root CID")
+		return
+	}
+
+	wants := &api.ReplicateWants{}
+	expected := make(map[string]cid.Cid)
+	for _, cidStr := range offer.GetCids() {
+		c, err := cid.Decode(cidStr)
+		if err != nil {
+			decline("invalid CID in offer")
+			return
+		}
+		if ok, _ := n.store.Has(c); ok || expected[cidStr].Defined() {
+			continue
+		}
+		expected[cidStr] = c
+		wants.Missing = append(wants.Missing, cidStr)
+	}
+	if err := w.WriteMsg(wants); err != nil {
+		log.Printf("Error writing replica wants to %s: %v", remote, err)
+		s.Reset()
+		return
+	}
+
+	for range wants.Missing {
+		block := &api.BlockData{}
+		if err := r.ReadMsg(block); err != nil {
+			log.Printf("Error reading replica block from %s: %v", remote, err)
+			s.Reset()
+			return
+		}
+		c, ok := expected[block.GetCid()]
+		if !ok {
+			log.Printf("Peer %s sent unexpected block %q", remote, block.GetCid())
+			s.Reset()
+			return
+		}
+		delete(expected, block.GetCid())
+		if err := storage.Verify(c, block.GetData()); err != nil {
+			log.Printf("Peer %s pushed a corrupt block: %v", remote, err)
+			n.peers.misbehaved(remote)
+			s.Reset()
+			return
+		}
+		if err := n.store.PutCachedAs(c, block.GetData()); err != nil {
+			w.WriteMsg(&api.ReplicateResult{Error: err.Error()})
+			return
+		}
+	}
+
+	// Pin fetches anything the offer left out, so a replica is only
+	// acknowledged once it is complete.
+	ctx, cancel := context.WithTimeout(context.Background(), replicateTimeout)
+	defer cancel()
+	mode := storage.PinRecursive
+	if offer.GetDirect() {
+		mode = storage.PinDirect
+	}
+	result := &api.ReplicateResult{}
+	if err := n.Pin(ctx, root, mode); err != nil {
+		result.Error = err.Error()
+	}
+	if err := w.WriteMsg(result); err != nil {
+		log.Printf("Error writing replica result to %s: %v", remote, err)
+		s.Reset()
+		return
+	}
+	if result.Error != "" {
+		log.Printf("Failed to store replica of %s from %s: %s", root, remote, result.Error)
+		return
+	}
+	log.Printf("Stored replica of %s from %s", root, remote)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), replicateTimeout)
+		defer cancel()
+		blocks := []cid.Cid{root}
+		if mode == storage.PinRecursive {
+			var err error
+			if blocks, err = n.fileBlocks(root); err != nil {
+				log.Printf("Error listing blocks of %s: %v", root, err)
+				return
+			}
+		}
+		for _, c := range blocks {
+			if err := n.dht.Provide(ctx, c, true); err != nil {
+				log.Printf("Error providing %s: %v", c, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,427 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/p2p"
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-msgio/pbio"
+	"golang.org/x/net/context"
+)
+
+const (
+	// maxSessionPeers caps how many neighbours a session broadcasts to.
+	maxSessionPeers = 32
+	// wantHaveTimeout bounds how long a session waits for a neighbour's presences.
+	wantHaveTimeout = 5 * time.Second
+	// maxWantsPerMessage bounds the entries of a single want-list message.
+	maxWantsPerMessage = 1024
+	// wantBlockBatch is how many blocks a session asks a single peer for at once.
+	wantBlockBatch = 8
+)
+
+// pendingBlock is a block a session has asked a peer for.
+type pendingBlock struct {
+	from peer.ID
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// session fetches the blocks of one file from neighbouring peers. It
+// broadcasts the CIDs it wants to connected peers, remembers which of them
+// answered HAVE, and then asks those peers for the blocks in batches. Blocks
+// no neighbour has are left to the DHT-based fallback fetcher. Fetching
+// starts as soon as one neighbour has a block, while the others are still
+// answering.
+type session struct {
+	n        *Node
+	ctx      context.Context
+	fallback *blockFetcher
+
+	mu      sync.Mutex
+	order   []cid.Cid
+	index   map[cid.Cid]int
+	have    map[cid.Cid][]peer.ID
+	pending map[cid.Cid]*pendingBlock
+	// discovering counts the want-lists not answered yet. changed is
+	// closed, and replaced, every time one is.
+	discovering int
+	changed     chan struct{}
+}
+
+func (n *Node) newSession(ctx context.Context, fallback *blockFetcher) *session {
+	return &session{
+		n:        n,
+		ctx:      ctx,
+		fallback: fallback,
+		index:    make(map[cid.Cid]int),
+		have:     make(map[cid.Cid][]peer.ID),
+		pending:  make(map[cid.Cid]*pendingBlock),
+		changed:  make(chan struct{}),
+	}
+}
+
+// discover asks every neighbour which of cids it has, without waiting for
+// the answers. The order of cids is the order the session expects them to
+// be fetched in, which is used to batch requests.
+func (s *session) discover(ctx context.Context, cids []cid.Cid) {
+	peers := s.n.Host.Network().Peers()
+	if len(peers) > maxSessionPeers {
+		peers = peers[:maxSessionPeers]
+	}
+
+	s.mu.Lock()
+	s.order = cids
+	s.index = make(map[cid.Cid]int, len(cids))
+	for i := len(cids) - 1; i >= 0; i-- {
+		s.index[cids[i]] = i
+	}
+	s.discovering += len(peers)
+	s.mu.Unlock()
+
+	for _, p := range peers {
+		go func(p peer.ID) {
+			ctx, cancel := context.WithTimeout(ctx, wantHaveTimeout)
+			defer cancel()
+
+			has, err := s.n.wantHave(ctx, p, cids)
+			if err != nil {
+				log.Printf("Want-list to %s failed: %v", p, err)
+			}
+			s.mu.Lock()
+			for _, c := range has {
+				s.have[c] = append(s.have[c], p)
+			}
+			s.discovering--
+			close(s.changed)
+			s.changed = make(chan struct{})
+			s.mu.Unlock()
+		}(p)
+	}
+}
+
+// await waits until a neighbour says it has c or every want-list has been
+// answered, and reports whether any neighbour has c.
+func (s *session) await(ctx context.Context, c cid.Cid) (bool, error) {
+	for {
+		s.mu.Lock()
+		found, done, changed := len(s.have[c]) > 0, s.discovering == 0, s.changed
+		s.mu.Unlock()
+		if found || done {
+			return found, nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// fetch retrieves a block from the neighbours that have it, falling back to
+// the DHT once none of them can serve it.
+func (s *session) fetch(ctx context.Context, c cid.Cid) ([]byte, error) {
+	for attempt := 0; attempt < maxBlockAttempts; attempt++ {
+		if _, err := s.await(ctx, c); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		pb, ok := s.pending[c]
+		if !ok {
+			pb = s.requestLocked(c)
+		}
+		s.mu.Unlock()
+		if pb == nil {
+			break
+		}
+
+		select {
+		case <-pb.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		s.mu.Lock()
+		if s.pending[c] == pb {
+			delete(s.pending, c)
+		}
+		if pb.err != nil {
+			s.forgetLocked(c, pb.from)
+		}
+		s.mu.Unlock()
+
+		if pb.err == nil {
+			return pb.data, nil
+		}
+	}
+	return s.fallback.fetch(ctx, c)
+}
+
+// requestLocked picks the best neighbour holding c and asks it for c and the
+// next few blocks in session order that it also holds. It returns nil if no
+// neighbour has c. s.mu must be held.
+func (s *session) requestLocked(c cid.Cid) *pendingBlock {
+	holders := s.have[c]
+	if len(holders) == 0 {
+		return nil
+	}
+	candidates := make([]peer.AddrInfo, len(holders))
+	for i, p := range holders {
+		candidates[i] = peer.AddrInfo{ID: p}
+	}
+	from := s.n.peers.rank(candidates)[0].ID
+
+	batch := []cid.Cid{c}
+	if i, ok := s.index[c]; ok {
+		for _, next := range s.order[i+1:] {
+			if len(batch) >= wantBlockBatch {
+				break
+			}
+			if _, busy := s.pending[next]; busy || next.Equals(c) || !s.holds(from, next) {
+				continue
+			}
+			batch = append(batch, next)
+		}
+	}
+
+	pending := make([]*pendingBlock, len(batch))
+	for i, b := range batch {
+		pending[i] = &pendingBlock{from: from, done: make(chan struct{})}
+		s.pending[b] = pending[i]
+	}
+	go s.requestBatch(from, batch, pending)
+
+	return pending[0]
+}
+
+func (s *session) holds(p peer.ID, c cid.Cid) bool {
+	for _, h := range s.have[c] {
+		if h == p {
+			return true
+		}
+	}
+	return false
+}
+
+// forgetLocked stops the session from asking p for c again.
+func (s *session) forgetLocked(c cid.Cid, p peer.ID) {
+	holders := s.have[c]
+	for i, h := range holders {
+		if h == p {
+			s.have[c] = append(holders[:i:i], holders[i+1:]...)
+			return
+		}
+	}
+}
+
+// requestBatch asks p for a batch of blocks and resolves their pending entries.
+// A batch abandoned because the session ended does not count against p.
+func (s *session) requestBatch(p peer.ID, batch []cid.Cid, pending []*pendingBlock) {
+	ctx, cancel := context.WithTimeout(s.ctx, blockRequestTimeout)
+	defer cancel()
+
+	s.n.peers.start(p)
+	started := time.Now()
+	received := make(map[cid.Cid][]byte, len(batch))
+	err := s.n.wantBlocks(ctx, p, batch, func(c cid.Cid, data []byte) error {
+		if err := s.n.acceptBlock(p, c, data); err != nil {
+			return err
+		}
+		received[c] = data
+		return nil
+	})
+	switch {
+	case err != nil && s.ctx.Err() != nil:
+		s.n.peers.cancelled(p)
+	case err != nil:
+		s.n.peers.failed(p)
+	default:
+		s.n.peers.succeeded(p, time.Since(started))
+	}
+
+	for i, c := range batch {
+		data, ok := received[c]
+		switch {
+		case ok:
+			pending[i].data = data
+		case err != nil:
+			pending[i].err = err
+		default:
+			pending[i].err = errBlockNotFound
+		}
+		close(pending[i].done)
+	}
+}
+
+// wantHave asks p which of cids it has.
+func (n *Node) wantHave(ctx context.Context, p peer.ID, cids []cid.Cid) ([]cid.Cid, error) {
+	var has []cid.Cid
+	for start := 0; start < len(cids); start += maxWantsPerMessage {
+		end := min(start+maxWantsPerMessage, len(cids))
+		err := n.exchangeWants(ctx, p, cids[start:end], api.WantType_WANT_TYPE_HAVE, func(presences []*api.BlockPresence) error {
+			for _, pr := range presences {
+				if !pr.GetHave() {
+					continue
+				}
+				c, err := cid.Decode(pr.GetCid())
+				if err != nil {
+					return err
+				}
+				has = append(has, c)
+			}
+			return nil
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return has, nil
+}
+
+// wantBlocks asks p for cids and passes every block it sends to onBlock.
+// Blocks the peer does not have are simply not delivered.
+func (n *Node) wantBlocks(ctx context.Context, p peer.ID, cids []cid.Cid, onBlock func(cid.Cid, []byte) error) error {
+	return n.exchangeWants(ctx, p, cids, api.WantType_WANT_TYPE_BLOCK, nil, onBlock)
+}
+
+// exchangeWants sends one want-list to p and reads the reply: a presences
+// message, then one message per block p announced it would send.
+func (n *Node) exchangeWants(ctx context.Context, p peer.ID, cids []cid.Cid, wantType api.WantType,
+	onPresences func([]*api.BlockPresence) error, onBlock func(cid.Cid, []byte) error) error {
+	s, err := n.Host.NewStream(ctx, p, p2p.WantProtocolID)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		s.SetDeadline(deadline)
+	}
+
+	msg := &api.WantMessage{Wants: make([]*api.WantEntry, len(cids))}
+	for i, c := range cids {
+		msg.Wants[i] = &api.WantEntry{Cid: c.String(), Type: wantType}
+	}
+	if err := pbio.NewDelimitedWriter(s).WriteMsg(msg); err != nil {
+		s.Reset()
+		return err
+	}
+	s.CloseWrite()
+
+	r := pbio.NewDelimitedReader(s, p2p.MaxMessageSize)
+	defer r.Close()
+
+	reply := &api.WantMessage{}
+	if err := r.ReadMsg(reply); err != nil {
+		s.Reset()
+		return err
+	}
+	if onPresences != nil {
+		if err := onPresences(reply.GetPresences()); err != nil {
+			s.Reset()
+			return err
+		}
+	}
+	if wantType != api.WantType_WANT_TYPE_BLOCK {
+		return nil
+	}
+
+	expected := make(map[string]bool)
+	for _, pr := range reply.GetPresences() {
+		if pr.GetHave() {
+			expected[pr.GetCid()] = true
+		}
+	}
+	for len(expected) > 0 {
+		msg := &api.WantMessage{}
+		if err := r.ReadMsg(msg); err != nil {
+			s.Reset()
+			if err == io.EOF {
+				return errors.New("peer closed the stream before sending every block")
+			}
+			return err
+		}
+		block := msg.GetBlock()
+		if !expected[block.GetCid()] {
+			s.Reset()
+			return fmt.Errorf("peer sent unexpected block %q", block.GetCid())
+		}
+		delete(expected, block.GetCid())
+
+		c, err := cid.Decode(block.GetCid())
+		if err != nil {
+			s.Reset()
+			return err
+		}
+		if err := onBlock(c, block.GetData()); err != nil {
+			s.Reset()
+			return err
+		}
+	}
+	return nil
+}
+
+// handleWantStream answers a neighbour's want-list. Every entry gets a
+// presence; blocks asked for with WANT_BLOCK follow, subject to the same
+// per-peer rate limit as the block protocol.
+func (n *Node) handleWantStream(s network.Stream) {
+	defer s.Close()
+	remote := s.Conn().RemotePeer()
+
+	r := pbio.NewDelimitedReader(s, p2p.MaxMessageSize)
+	defer r.Close()
+
+	req := &api.WantMessage{}
+	if err := r.ReadMsg(req); err != nil {
+		log.Printf("Error reading want-list from %s: %v", remote, err)
+		s.Reset()
+		return
+	}
+
+	reply := &api.WantMessage{}
+	var send []cid.Cid
+	for _, want := range req.GetWants() {
+		have := false
+		c, err := cid.Decode(want.GetCid())
+		if err == nil {
+			have, _ = n.store.Has(c)
+		}
+		if have && want.GetType() == api.WantType_WANT_TYPE_BLOCK {
+			// A block we are not willing to send now is reported as missing
+			// so the requester asks someone else.
+			have = n.limiter.allow(remote)
+			if have {
+				send = append(send, c)
+			}
+		}
+		reply.Presences = append(reply.Presences, &api.BlockPresence{Cid: want.GetCid(), Have: have})
+	}
+
+	w := pbio.NewDelimitedWriter(s)
+	if err := w.WriteMsg(reply); err != nil {
+		log.Printf("Error writing presences to %s: %v", remote, err)
+		s.Reset()
+		return
+	}
+	for _, c := range send {
+		data, err := n.store.Get(c)
+		if err != nil {
+			log.Printf("Error getting block from store: %v", err)
+			s.Reset()
+			return
+		}
+		if err := w.WriteMsg(&api.WantMessage{Block: &api.BlockData{Cid: c.String(), Data: data}}); err != nil {
+			log.Printf("Error writing block to %s: %v", remote, err)
+			s.Reset()
+			return
+		}
+	}
+}
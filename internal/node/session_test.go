@@ -0,0 +1,88 @@
+package node
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Yashh56/p2p-storage/internal/p2p"
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/network"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"golang.org/x/net/context"
+)
+
+func TestSession_FetchesWhileDiscovering(t *testing.T) {
+	mn := mocknet.New()
+	defer mn.Close()
+	client := newExchangeNode(t, mn)
+	holder := newExchangeNode(t, mn)
+	// A neighbour that does not answer until it is released.
+	slow := newExchangeNode(t, mn)
+	release := make(chan struct{})
+	slow.Host.SetStreamHandler(p2p.WantProtocolID, func(s network.Stream) {
+		<-release
+		slow.handleWantStream(s)
+	})
+	defer func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	}()
+	if err := mn.LinkAll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mn.ConnectAllButSelf(); err != nil {
+		t.Fatal(err)
+	}
+
+	var blocks [][]byte
+	var cids []cid.Cid
+	for i := 0; i < 3; i++ {
+		data := []byte{byte(i), 'b'}
+		c, err := holder.store.Put(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, data)
+		cids = append(cids, c)
+	}
+	missing, err := slow.store.Put([]byte("nobody answers for this one in time"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), wantHaveTimeout)
+	defer cancel()
+	sess := client.newSession(ctx, nil)
+	sess.discover(ctx, append(cids, missing))
+
+	started := time.Now()
+	for i, c := range cids {
+		data, err := sess.fetch(ctx, c)
+		if err != nil || !bytes.Equal(data, blocks[i]) {
+			t.Fatalf("block %d: got %q, %v", i, data, err)
+		}
+	}
+	if elapsed := time.Since(started); elapsed > wantHaveTimeout/2 {
+		t.Fatalf("fetching took %v: it waited for the slow neighbour", elapsed)
+	}
+
+	// A block only the slow neighbour has is found once it answers.
+	found := make(chan bool)
+	go func() {
+		ok, _ := sess.await(ctx, missing)
+		found <- ok
+	}()
+	select {
+	case <-found:
+		t.Fatal("await returned before every neighbour answered")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	if ok := <-found; !ok {
+		t.Fatal("the slow neighbour's block was not found")
+	}
+}
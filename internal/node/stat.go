@@ -0,0 +1,109 @@
+package node
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"golang.org/x/net/context"
+)
+
+// ChunkInfo describes a chunk of a file.
+type ChunkInfo struct {
+	// Offset is the position of the chunk in the file.
+	Offset int64
+	// Size is -1 when the manifest does not record it, and Offset is then
+	// only known for the first chunk.
+	Size int64
+	Cid  cid.Cid
+	// Digest is the SHA-256 of the chunk's content. For an unencrypted
+	// file it comes from the CID; an encrypted chunk has to be fetched and
+	// decrypted to compute it.
+	Digest []byte
+}
+
+// StatFile returns the metadata of a file or directory without reading its
+// content. The file is named as for GetFile, and the key in opts is needed
+// for the metadata of an encrypted file.
+func (n *Node) StatFile(ctx context.Context, rootCIDStr string, opts GetOptions) (FileInfo, error) {
+	root, err := n.resolveRoot(ctx, rootCIDStr)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	m, _, _, err := n.openFile(ctx, root)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	if m.sealed != nil && opts.Key != nil {
+		if m, err = m.unseal(opts.Key); err != nil {
+			return FileInfo{}, err
+		}
+	}
+	m.info.Root = root
+	return m.info, nil
+}
+
+// ChunkIterator returns the next chunk of a file, or io.EOF after the last.
+type ChunkIterator func(ctx context.Context) (ChunkInfo, error)
+
+// FileChunks returns the metadata of a file and an iterator over the chunks
+// that overlap the range in opts, in order.
+func (n *Node) FileChunks(ctx context.Context, rootCIDStr string, opts GetOptions) (FileInfo, ChunkIterator, error) {
+	root, err := n.resolveRoot(ctx, rootCIDStr)
+	if err != nil {
+		return FileInfo{}, nil, err
+	}
+	m, fetch, discover, err := n.openFile(ctx, root)
+	if err != nil {
+		return FileInfo{}, nil, err
+	}
+	if m, err = m.unseal(opts.Key); err != nil {
+		return FileInfo{}, nil, err
+	}
+	m.info.Root = root
+	if opts.Offset < 0 || opts.Length < 0 || (m.info.Size >= 0 && opts.Offset > m.info.Size) {
+		return m.info, nil, fmt.Errorf("%w: offset %d, length %d", ErrInvalidRange, opts.Offset, opts.Length)
+	}
+	chunks, skip, err := m.chunkIter(ctx, fetch, discover, opts.Offset)
+	if err != nil {
+		return m.info, nil, err
+	}
+	var fetchChunk chunkFetchFunc
+	if m.key != nil {
+		if fetchChunk, err = n.chunkFetcher(m, fetch); err != nil {
+			return m.info, nil, err
+		}
+	}
+
+	offset := opts.Offset - skip
+	done := false
+	return m.info, func(ctx context.Context) (ChunkInfo, error) {
+		if done || (opts.Length > 0 && offset >= opts.Offset+opts.Length) {
+			return ChunkInfo{}, io.EOF
+		}
+		ref, err := chunks(ctx)
+		if err != nil {
+			return ChunkInfo{}, err
+		}
+		info := ChunkInfo{Offset: offset, Size: ref.size, Cid: ref.cid}
+		if fetchChunk != nil {
+			data, err := fetchChunk(ctx, ref)
+			if err != nil {
+				return ChunkInfo{}, fmt.Errorf("failed to get chunk %s: %w", ref.cid, err)
+			}
+			sum := sha256.Sum256(data)
+			info.Digest = sum[:]
+		} else if mh, err := multihash.Decode(ref.cid.Hash()); err == nil && mh.Code == multihash.SHA2_256 {
+			info.Digest = mh.Digest
+		}
+		if ref.size < 0 {
+			// Later offsets are unknown.
+			done = true
+		} else {
+			offset += ref.size
+		}
+		return info, nil
+	}, nil
+}
@@ -0,0 +1,134 @@
+package node
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"testing"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestFileChunks_Digests(t *testing.T) {
+	n := newTestNode(t)
+	ctx := context.Background()
+
+	content := bytes.Repeat([]byte("0123456789"), 10)
+	for _, mode := range []KeyMode{"", FileKey} {
+		root, key := storeFile(t, n, content, 16, mode)
+		opts := GetOptions{Key: key}
+
+		info, err := n.StatFile(ctx, root.String(), GetOptions{})
+		if err != nil {
+			t.Fatalf("%q: %v", mode, err)
+		}
+		if info.Encrypted != (mode != "") || !info.Root.Equals(root) {
+			t.Fatalf("%q: unexpected file info %+v", mode, info)
+		}
+		if mode != "" {
+			if _, _, err := n.FileChunks(ctx, root.String(), GetOptions{}); !errors.Is(err, ErrKeyRequired) {
+				t.Fatalf("got %v listing without a key, want ErrKeyRequired", err)
+			}
+		}
+
+		// A range lists the chunks that overlap it, from the chunk
+		// boundary before its start.
+		opts.Offset, opts.Length = 20, 30
+		_, chunks, err := n.FileChunks(ctx, root.String(), opts)
+		if err != nil {
+			t.Fatalf("%q: %v", mode, err)
+		}
+		var offsets []int64
+		for {
+			c, err := chunks(ctx)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%q: %v", mode, err)
+			}
+			offsets = append(offsets, c.Offset)
+			end := min(c.Offset+16, int64(len(content)))
+			if c.Size != end-c.Offset {
+				t.Fatalf("%q: chunk at %d has size %d", mode, c.Offset, c.Size)
+			}
+			if sum := sha256.Sum256(content[c.Offset:end]); !bytes.Equal(c.Digest, sum[:]) {
+				t.Fatalf("%q: wrong digest for the chunk at %d", mode, c.Offset)
+			}
+		}
+		if want := []int64{16, 32, 48}; !equalInt64s(offsets, want) {
+			t.Fatalf("%q: got chunks at %v, want %v", mode, offsets, want)
+		}
+	}
+}
+
+// storeFile stores content in chunks of size below a manifest, encrypted
+// with mode, and returns the root and the key.
+func storeFile(t *testing.T, n *Node, content []byte, size int, mode KeyMode) (cid.Cid, []byte) {
+	t.Helper()
+	var enc *chunkEncrypter
+	if mode != "" {
+		var err error
+		if enc, err = newChunkEncrypter(EncryptOptions{Mode: mode, Cipher: storage.DefaultCipher}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manifest := &api.Manifest{Version: manifestVersion, Size: uint64(len(content)), Name: "digits.txt"}
+	for off := 0; off < len(content); off += size {
+		chunk := content[off:min(off+size, len(content))]
+		data, key := chunk, []byte(nil)
+		if enc != nil {
+			var err error
+			if data, key, err = enc.encrypt(chunk); err != nil {
+				t.Fatal(err)
+			}
+		}
+		c, err := n.store.Put(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		link := &api.DagLink{Cid: c.String(), Size: uint64(len(chunk))}
+		if enc != nil {
+			if link.Key, err = enc.sealKey(c, key); err != nil {
+				t.Fatal(err)
+			}
+		}
+		manifest.Links = append(manifest.Links, link)
+		manifest.Chunks++
+	}
+	if enc != nil {
+		var err error
+		if manifest, err = enc.seal(manifest); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := proto.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := n.store.Put(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc != nil {
+		return root, enc.key
+	}
+	return root, nil
+}
+
+func equalInt64s(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
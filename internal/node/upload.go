@@ -0,0 +1,324 @@
+package node
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/Yashh56/p2p-storage/internal/file"
+	"github.com/Yashh56/p2p-storage/internal/storage"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// uploadPrefix is the metadata prefix of upload sessions. Each part is
+	// kept under the session's key, followed by its offset.
+	uploadPrefix = "uploads/"
+	// uploadExpiry is how long an upload session survives without new
+	// content. Expired sessions are dropped by the next garbage collection.
+	uploadExpiry = 24 * time.Hour
+)
+
+var (
+	// ErrUploadNotFound is returned for an unknown or expired upload session.
+	ErrUploadNotFound = errors.New("upload session not found")
+	// ErrUploadOffset is returned by WriteUpload for content that starts
+	// after the committed offset.
+	ErrUploadOffset = errors.New("upload offset is past the committed content")
+	// ErrUploadIncomplete is returned by FinishUpload when the committed
+	// content does not have the size the client expects.
+	ErrUploadIncomplete = errors.New("upload is incomplete")
+)
+
+// StartUpload opens a resumable upload session for a file added with opts
+// and returns its ID.
+func (n *Node) StartUpload(opts AddOptions) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	s := &api.UploadSession{
+		Replication:  uint32(opts.Replication),
+		DataShards:   uint32(opts.Erasure.DataShards),
+		ParityShards: uint32(opts.Erasure.ParityShards),
+		Encryption:   string(opts.Encryption.Mode),
+		Cipher:       string(opts.Encryption.Cipher),
+		Name:         opts.Name,
+		ContentType:  opts.ContentType,
+		UpdatedAt:    time.Now().Unix(),
+	}
+	if opts.Chunker != (file.Params{}) {
+		s.Chunker = opts.Chunker.String()
+	}
+	uploadID := hex.EncodeToString(id)
+	if err := n.saveUpload(uploadID, s); err != nil {
+		return "", err
+	}
+	return uploadID, nil
+}
+
+// WriteUpload commits data, which starts at offset in the file, to an upload
+// session and returns the committed offset. Data before the committed
+// offset was already received and is skipped.
+func (n *Node) WriteUpload(id string, offset int64, data []byte) (int64, error) {
+	n.gcLock.RLock()
+	defer n.gcLock.RUnlock()
+	n.uploadMu.Lock()
+	defer n.uploadMu.Unlock()
+
+	s, err := n.loadUpload(id)
+	if err != nil {
+		return 0, err
+	}
+	committed := s.Size
+	if offset > committed {
+		return committed, fmt.Errorf("%w: got %d, committed %d", ErrUploadOffset, offset, committed)
+	}
+	if skip := committed - offset; skip < int64(len(data)) {
+		data = data[skip:]
+	} else {
+		return committed, nil
+	}
+	c, err := n.store.Put(data)
+	if err != nil {
+		return committed, err
+	}
+	// A part left behind by a failed session update is replaced by the
+	// next write at the same offset.
+	part := &api.DagLink{Cid: c.String(), Size: uint64(len(data))}
+	if err := n.putMetaProto(uploadPartKey(id, committed), part); err != nil {
+		return committed, err
+	}
+	s.Size += int64(len(data))
+	s.UpdatedAt = time.Now().Unix()
+	if err := n.saveUpload(id, s); err != nil {
+		return committed, err
+	}
+	return committed + int64(len(data)), nil
+}
+
+// UploadOffset returns the number of bytes committed to an upload session.
+func (n *Node) UploadOffset(id string) (int64, error) {
+	s, err := n.loadUpload(id)
+	if err != nil {
+		return 0, err
+	}
+	return s.Size, nil
+}
+
+// FinishUpload stores the content of an upload session as AddFile would and
+// closes the session. size is the size of the whole file.
+func (n *Node) FinishUpload(ctx context.Context, id string, size int64) (AddResult, error) {
+	s, err := n.loadUpload(id)
+	if err != nil {
+		return AddResult{}, err
+	}
+	if s.Size != size {
+		return AddResult{}, fmt.Errorf("%w: %d of %d bytes committed", ErrUploadIncomplete, s.Size, size)
+	}
+	parts, err := n.uploadParts(id, s.Size)
+	if err != nil {
+		return AddResult{}, err
+	}
+	chunker, err := file.ParseParams(s.Chunker)
+	if err != nil {
+		return AddResult{}, err
+	}
+	opts := AddOptions{
+		Chunker:     chunker,
+		Replication: int(s.Replication),
+		Erasure: ErasureParams{
+			DataShards:   int(s.DataShards),
+			ParityShards: int(s.ParityShards),
+		},
+		Encryption: EncryptOptions{
+			Mode:   KeyMode(s.Encryption),
+			Cipher: storage.Cipher(s.Cipher),
+		},
+		Name:        s.Name,
+		ContentType: s.ContentType,
+	}
+	// The session keeps the parts from being collected until the file is
+	// pinned.
+	result, err := n.AddFile(ctx, &partReader{store: n.store, parts: parts}, opts)
+	if err != nil {
+		return AddResult{}, err
+	}
+	// The parts' blocks are left to the next garbage collection.
+	n.uploadMu.Lock()
+	defer n.uploadMu.Unlock()
+	if err := n.deleteUploadLocked(id); err != nil {
+		return AddResult{}, err
+	}
+	return result, nil
+}
+
+// uploadBlocks returns the parts of every live upload session, dropping
+// the sessions that expired. The caller holds gcLock.
+func (n *Node) uploadBlocks() ([]cid.Cid, error) {
+	n.uploadMu.Lock()
+	defer n.uploadMu.Unlock()
+
+	parts := make(map[string][]cid.Cid)
+	live := make(map[string]bool)
+	deadline := time.Now().Add(-uploadExpiry).Unix()
+	err := n.store.ScanMeta(uploadPrefix, func(key string, value []byte) error {
+		id, offset, isPart := strings.Cut(key, "/")
+		if !isPart {
+			s := &api.UploadSession{}
+			if err := proto.Unmarshal(value, s); err != nil {
+				return fmt.Errorf("invalid upload session %s: %w", id, err)
+			}
+			live[id] = s.UpdatedAt >= deadline
+			return nil
+		}
+		part := &api.DagLink{}
+		if err := proto.Unmarshal(value, part); err != nil {
+			return fmt.Errorf("invalid part %s of upload session %s: %w", offset, id, err)
+		}
+		c, err := cid.Decode(part.Cid)
+		if err != nil {
+			return fmt.Errorf("invalid part %s of upload session %s: %w", offset, id, err)
+		}
+		parts[id] = append(parts[id], c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Expired sessions are dropped, along with parts that outlived their
+	// session.
+	var keep []cid.Cid
+	for id, ok := range live {
+		if ok {
+			keep = append(keep, parts[id]...)
+			continue
+		}
+		if err := n.deleteUploadLocked(id); err != nil {
+			return nil, err
+		}
+	}
+	for id := range parts {
+		if _, ok := live[id]; !ok {
+			if err := n.deleteUploadLocked(id); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return keep, nil
+}
+
+// uploadParts returns the parts of an upload session that hold its first
+// size bytes, in order.
+func (n *Node) uploadParts(id string, size int64) ([]*api.DagLink, error) {
+	var parts []*api.DagLink
+	var next int64
+	err := n.store.ScanMeta(uploadPrefix+id+"/", func(offset string, value []byte) error {
+		if next >= size {
+			// A part written after the last commit of the session.
+			return nil
+		}
+		part := &api.DagLink{}
+		if err := proto.Unmarshal(value, part); err != nil {
+			return fmt.Errorf("invalid part %s of upload session %s: %w", offset, id, err)
+		}
+		if at, err := strconv.ParseInt(offset, 10, 64); err != nil || at != next {
+			return fmt.Errorf("upload session %s is missing the part at %d", id, next)
+		}
+		parts = append(parts, part)
+		next += int64(part.Size)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if next != size {
+		return nil, fmt.Errorf("upload session %s has %d of %d bytes in parts", id, next, size)
+	}
+	return parts, nil
+}
+
+// deleteUploadLocked deletes an upload session and its parts. uploadMu must
+// be held.
+func (n *Node) deleteUploadLocked(id string) error {
+	var keys []string
+	err := n.store.ScanMeta(uploadPrefix+id+"/", func(offset string, _ []byte) error {
+		keys = append(keys, uploadPrefix+id+"/"+offset)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range append(keys, uploadPrefix+id) {
+		if err := n.store.DeleteMeta(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *Node) loadUpload(id string) (*api.UploadSession, error) {
+	// An ID with a slash would name a part.
+	if strings.Contains(id, "/") {
+		return nil, ErrUploadNotFound
+	}
+	value, err := n.store.GetMeta(uploadPrefix + id)
+	if errors.Is(err, storage.ErrMetaNotFound) {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &api.UploadSession{}
+	if err := proto.Unmarshal(value, s); err != nil {
+		return nil, fmt.Errorf("invalid upload session %s: %w", id, err)
+	}
+	if s.UpdatedAt < time.Now().Add(-uploadExpiry).Unix() {
+		return nil, ErrUploadNotFound
+	}
+	return s, nil
+}
+
+func (n *Node) saveUpload(id string, s *api.UploadSession) error {
+	return n.putMetaProto(uploadPrefix+id, s)
+}
+
+// uploadPartKey returns the metadata key of the part at offset. Offsets are
+// zero-padded so that parts are scanned in order.
+func uploadPartKey(id string, offset int64) string {
+	return fmt.Sprintf("%s%s/%019d", uploadPrefix, id, offset)
+}
+
+// partReader reads the parts of an upload session in order.
+type partReader struct {
+	store *storage.BlockStore
+	parts []*api.DagLink
+	cur   []byte
+}
+
+func (r *partReader) Read(p []byte) (int, error) {
+	for len(r.cur) == 0 {
+		if len(r.parts) == 0 {
+			return 0, io.EOF
+		}
+		c, err := cid.Decode(r.parts[0].Cid)
+		if err != nil {
+			return 0, err
+		}
+		if r.cur, err = r.store.Get(c); err != nil {
+			return 0, fmt.Errorf("failed to read upload part %s: %w", c, err)
+		}
+		r.parts = r.parts[1:]
+	}
+	n := copy(p, r.cur)
+	r.cur = r.cur[n:]
+	return n, nil
+}
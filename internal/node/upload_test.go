@@ -0,0 +1,95 @@
+package node
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Yashh56/p2p-storage/internal/file"
+	"golang.org/x/net/context"
+)
+
+func TestUpload_ResumeAndGC(t *testing.T) {
+	n := newTestNode(t)
+
+	id, err := n.StartUpload(AddOptions{Chunker: file.DefaultCDCParams, Name: "video.mp4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("the quick brown fox jumps over the lazy dog")
+	if off, err := n.WriteUpload(id, 0, content[:10]); err != nil || off != 10 {
+		t.Fatalf("got offset %d, %v after the first write", off, err)
+	}
+	// Resending data that was already committed only adds the new bytes.
+	if off, err := n.WriteUpload(id, 5, content[5:20]); err != nil || off != 20 {
+		t.Fatalf("got offset %d, %v after an overlapping write", off, err)
+	}
+	if off, err := n.WriteUpload(id, 0, content[:15]); err != nil || off != 20 {
+		t.Fatalf("got offset %d, %v after a write of committed data", off, err)
+	}
+	if _, err := n.WriteUpload(id, 25, content[25:]); !errors.Is(err, ErrUploadOffset) {
+		t.Fatalf("got %v for a gap, want ErrUploadOffset", err)
+	}
+
+	// Garbage collection keeps the parts of a session in progress.
+	if _, err := n.GC(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if off, err := n.UploadOffset(id); err != nil || off != 20 {
+		t.Fatalf("got offset %d, %v after GC", off, err)
+	}
+	if _, err := n.WriteUpload(id, 20, content[20:]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.FinishUpload(context.Background(), id, int64(len(content))+1); !errors.Is(err, ErrUploadIncomplete) {
+		t.Fatalf("got %v finishing with the wrong size, want ErrUploadIncomplete", err)
+	}
+
+	s, err := n.loadUpload(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Chunker != file.DefaultCDCParams.String() || s.Name != "video.mp4" {
+		t.Fatalf("options were not kept: %v", s)
+	}
+	parts, err := n.uploadParts(id, s.Size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(&partReader{store: n.store, parts: parts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("got %q, want %q", got, content)
+	}
+
+	if _, err := n.UploadOffset("unknown"); !errors.Is(err, ErrUploadNotFound) {
+		t.Fatalf("got %v for an unknown session, want ErrUploadNotFound", err)
+	}
+
+	// An abandoned session expires, and GC drops it along with its parts.
+	s.UpdatedAt = time.Now().Add(-uploadExpiry - time.Minute).Unix()
+	if err := n.saveUpload(id, s); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.UploadOffset(id); !errors.Is(err, ErrUploadNotFound) {
+		t.Fatalf("got %v for an expired session, want ErrUploadNotFound", err)
+	}
+	res, err := n.GC(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RemovedBlocks != len(parts) {
+		t.Fatalf("GC removed %d blocks, want the %d parts", res.RemovedBlocks, len(parts))
+	}
+	err = n.store.ScanMeta(uploadPrefix, func(key string, _ []byte) error {
+		return fmt.Errorf("expired session kept %s", key)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
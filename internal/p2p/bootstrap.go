@@ -0,0 +1,148 @@
+package p2p
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+const (
+	// DefaultBootstrapMinPeers is the routing table size below which the
+	// bootstrap peers are dialled again.
+	DefaultBootstrapMinPeers = 4
+	// DefaultBootstrapInterval is how often the routing table size is checked.
+	DefaultBootstrapInterval = time.Minute
+	// bootstrapDialTimeout bounds a single dial to a bootstrap peer.
+	bootstrapDialTimeout = 15 * time.Second
+)
+
+// BootstrapConfig lists the peers a node dials to join the network. There
+// are no default peers: nodes form their own private network rather than
+// joining the public IPFS DHT.
+type BootstrapConfig struct {
+	// Peers are multiaddrs ending in /p2p/<peer-id>.
+	Peers []string
+	// MinPeers is the routing table size below which Peers are dialled again.
+	MinPeers int
+	// Interval is how often the routing table size is checked.
+	Interval time.Duration
+}
+
+// ParseBootstrapPeers turns multiaddrs into peer infos, merging addresses
+// that belong to the same peer.
+func ParseBootstrapPeers(addrs []string) ([]peer.AddrInfo, error) {
+	maddrs := make([]multiaddr.Multiaddr, 0, len(addrs))
+	for _, a := range addrs {
+		ma, err := multiaddr.NewMultiaddr(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bootstrap address %q: %w", a, err)
+		}
+		maddrs = append(maddrs, ma)
+	}
+	infos, err := peer.AddrInfosFromP2pAddrs(maddrs...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bootstrap address: %w", err)
+	}
+	return infos, nil
+}
+
+// LoadBootstrapFile reads bootstrap multiaddrs from a file, one per line.
+// Blank lines and lines starting with # are ignored.
+func LoadBootstrapFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var addrs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		addrs = append(addrs, line)
+	}
+	return addrs, scanner.Err()
+}
+
+// StartBootstrap dials the bootstrap peers and then, until ctx is cancelled,
+// dials them again whenever the DHT routing table drops below cfg.MinPeers.
+func StartBootstrap(ctx context.Context, h host.Host, d *dht.IpfsDHT, cfg BootstrapConfig) error {
+	peers, err := ParseBootstrapPeers(cfg.Peers)
+	if err != nil {
+		return err
+	}
+	if len(peers) == 0 {
+		log.Println("No bootstrap peers configured; waiting for other nodes to connect")
+		return nil
+	}
+	if cfg.MinPeers <= 0 {
+		cfg.MinPeers = DefaultBootstrapMinPeers
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultBootstrapInterval
+	}
+
+	connectBootstrapPeers(ctx, h, d, peers)
+
+	go func() {
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if size := d.RoutingTable().Size(); size < cfg.MinPeers {
+					log.Printf("Routing table has %d peers, re-dialling bootstrap peers", size)
+					connectBootstrapPeers(ctx, h, d, peers)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// connectBootstrapPeers dials every bootstrap peer in parallel and refreshes
+// the routing table if any of them answered.
+func connectBootstrapPeers(ctx context.Context, h host.Host, d *dht.IpfsDHT, peers []peer.AddrInfo) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	connected := 0
+	for _, p := range peers {
+		if p.ID == h.ID() {
+			continue
+		}
+		wg.Add(1)
+		go func(p peer.AddrInfo) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, bootstrapDialTimeout)
+			defer cancel()
+			if err := h.Connect(ctx, p); err != nil {
+				log.Printf("Failed to connect to bootstrap peer %s: %v", p.ID, err)
+				return
+			}
+			mu.Lock()
+			connected++
+			mu.Unlock()
+		}(p)
+	}
+	wg.Wait()
+
+	log.Printf("Connected to %d of %d bootstrap peers", connected, len(peers))
+	if connected > 0 {
+		d.RefreshRoutingTable()
+	}
+}
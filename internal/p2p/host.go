@@ -7,25 +7,53 @@ import (
 
 	"github.com/libp2p/go-libp2p"
 	dht "github.com/libp2p/go-libp2p-kad-dht"
+	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
 )
 
-func NewHost(ctx context.Context) (host.Host, error) {
-	host, err := libp2p.New(
-		libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"),
-	)
+// HostConfig holds the settings used to build the libp2p host.
+type HostConfig struct {
+	// PrivKey is the host's identity. If nil, libp2p generates a random one
+	// and the PeerID changes on every start.
+	PrivKey crypto.PrivKey
+	// ListenAddrs are the multiaddrs to listen on. If empty, the host
+	// listens on a random TCP port on all interfaces.
+	ListenAddrs []string
+}
+
+func NewHost(ctx context.Context, cfg HostConfig) (host.Host, error) {
+	listenAddrs := cfg.ListenAddrs
+	if len(listenAddrs) == 0 {
+		listenAddrs = []string{"/ip4/0.0.0.0/tcp/0"}
+	}
+	opts := []libp2p.Option{
+		libp2p.ListenAddrStrings(listenAddrs...),
+	}
+	if cfg.PrivKey != nil {
+		opts = append(opts, libp2p.Identity(cfg.PrivKey))
+	}
+
+	host, err := libp2p.New(opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	fmt.Println("Host Created with ID: %s\n", host.ID())
+	fmt.Printf("Host Created with ID: %s\n", host.ID())
 	fmt.Println("Listen Addresses:", host.Addrs())
 
 	return host, nil
 }
 
+// DHTProtocolPrefix keeps our DHT separate from the public IPFS network, so
+// nodes only ever route through peers running p2p-storage.
+const DHTProtocolPrefix = "/p2p-storage"
+
 func InitDHT(ctx context.Context, h host.Host) (*dht.IpfsDHT, error) {
-	dht, err := dht.New(ctx, h, dht.Mode(dht.ModeServer))
+	dht, err := dht.New(ctx, h,
+		dht.Mode(dht.ModeServer),
+		dht.ProtocolPrefix(DHTProtocolPrefix),
+		dht.NamespacedValidator(NameNamespace, NameValidator{}),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,221 @@
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+)
+
+// IdentityFile is the name of the host's private key inside the data directory.
+const IdentityFile = "identity.key"
+
+// DefaultKeyType is the key type generated when none is configured.
+const DefaultKeyType = "ed25519"
+
+// rsaKeyBits is the modulus size used for RSA identities.
+const rsaKeyBits = 2048
+
+var keyTypes = map[string]int{
+	"ed25519":   crypto.Ed25519,
+	"secp256k1": crypto.Secp256k1,
+	"ecdsa":     crypto.ECDSA,
+	"rsa":       crypto.RSA,
+}
+
+// ValidateKeyType reports whether keyType names a supported key type.
+func ValidateKeyType(keyType string) error {
+	if _, ok := keyTypes[strings.ToLower(keyType)]; !ok {
+		return fmt.Errorf("unsupported key type %q", keyType)
+	}
+	return nil
+}
+
+// KeyTypeName returns the configuration name of a key's type, e.g. "ed25519".
+func KeyTypeName(k crypto.PrivKey) string {
+	return strings.ToLower(k.Type().String())
+}
+
+// GenerateIdentity creates a new private key of the named type and writes it
+// to dataDir, replacing any existing identity. The replaced key is kept next
+// to it with a ".old" suffix, or a timestamped one if an older key already
+// has that name. The identity file exists throughout, so a failed rotation
+// leaves the previous identity in place.
+func GenerateIdentity(dataDir, keyType string) (crypto.PrivKey, error) {
+	priv, err := generateKey(keyType)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dataDir, 0o700); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dataDir, IdentityFile)
+	tmp, err := writeTempKey(path, priv)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		old, err := keepOldKey(path)
+		if err != nil {
+			os.Remove(tmp)
+			return nil, err
+		}
+		log.Printf("Previous identity saved as %s", old)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
+
+// keepOldKey links a copy of the key at path aside, without overwriting a
+// key kept by an earlier rotation, and returns where it was kept.
+func keepOldKey(path string) (string, error) {
+	old := path + ".old"
+	err := os.Link(path, old)
+	if errors.Is(err, os.ErrExist) {
+		old = path + "." + time.Now().UTC().Format("20060102T150405.000000000Z") + ".old"
+		err = os.Link(path, old)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to keep the previous key: %w", err)
+	}
+	return old, nil
+}
+
+func generateKey(keyType string) (crypto.PrivKey, error) {
+	typ, ok := keyTypes[strings.ToLower(keyType)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %q", keyType)
+	}
+	priv, _, err := crypto.GenerateKeyPair(typ, rsaKeyBits)
+	return priv, err
+}
+
+func writeKey(path string, priv crypto.PrivKey) error {
+	tmp, err := writeTempKey(path, priv)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// writeTempKey writes priv next to path and returns the temporary file, to
+// be renamed over path so a crash never leaves a truncated key.
+func writeTempKey(path string, priv crypto.PrivKey) (string, error) {
+	data, err := crypto.MarshalPrivateKey(priv)
+	if err != nil {
+		return "", err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return "", err
+	}
+	return tmp, nil
+}
+
+// LoadIdentity reads the private key stored in dataDir.
+func LoadIdentity(dataDir string) (crypto.PrivKey, error) {
+	return loadKey(filepath.Join(dataDir, IdentityFile))
+}
+
+func loadKey(path string) (crypto.PrivKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := crypto.UnmarshalPrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	return priv, nil
+}
+
+// LoadOrCreateIdentity loads the private key stored in dataDir, generating one
+// of the named type the first time the node starts. The PeerID is derived from
+// this key, so keeping it stable keeps our DHT provider records valid across
+// restarts.
+func LoadOrCreateIdentity(dataDir, keyType string) (crypto.PrivKey, error) {
+	priv, err := LoadIdentity(dataDir)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Printf("No identity found in %s, generating a new %s key", dataDir, keyType)
+		return GenerateIdentity(dataDir, keyType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if name := KeyTypeName(priv); keyType != "" && name != strings.ToLower(keyType) {
+		log.Printf("Using existing %s identity; rotate the key to switch to %s", name, keyType)
+	}
+	return priv, nil
+}
+
+// KeysDir is the directory inside the data directory that holds the named
+// keys a node can publish names under, besides its identity.
+const KeysDir = "keys"
+
+// SelfKey is the name of the node identity among the named keys.
+const SelfKey = "self"
+
+var keyName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// GenerateNamedKey creates a new private key of the named type and stores
+// it in dataDir under name. It fails if the key already exists.
+func GenerateNamedKey(dataDir, name, keyType string) (crypto.PrivKey, error) {
+	if name == SelfKey || !keyName.MatchString(name) {
+		return nil, fmt.Errorf("invalid key name %q", name)
+	}
+	priv, err := generateKey(keyType)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(dataDir, KeysDir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, name+".key")
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("key %q: %w", name, os.ErrExist)
+	}
+	if err := writeKey(path, priv); err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
+
+// LoadNamedKey reads the key stored in dataDir under name. SelfKey names the
+// node identity.
+func LoadNamedKey(dataDir, name string) (crypto.PrivKey, error) {
+	if name == SelfKey {
+		return LoadIdentity(dataDir)
+	}
+	if !keyName.MatchString(name) {
+		return nil, fmt.Errorf("invalid key name %q", name)
+	}
+	return loadKey(filepath.Join(dataDir, KeysDir, name+".key"))
+}
+
+// ListNamedKeys returns the names of the keys stored in dataDir, in order,
+// not counting the node identity.
+func ListNamedKeys(dataDir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(dataDir, KeysDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".key"); ok && !e.IsDir() && keyName.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
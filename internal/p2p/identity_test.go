@@ -0,0 +1,67 @@
+package p2p
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestLoadOrCreateIdentity_StableAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+
+	first, err := LoadOrCreateIdentity(dir, "ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := LoadOrCreateIdentity(dir, "ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.Equals(second) {
+		t.Fatal("identity changed between loads")
+	}
+
+	rotated, err := GenerateIdentity(dir, "secp256k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if KeyTypeName(rotated) != "secp256k1" {
+		t.Fatalf("expected secp256k1 key, got %s", KeyTypeName(rotated))
+	}
+	oldID, _ := peer.IDFromPrivateKey(first)
+	newID, _ := peer.IDFromPrivateKey(rotated)
+	if oldID == newID {
+		t.Fatal("rotation kept the same PeerID")
+	}
+	if old, err := loadKey(filepath.Join(dir, IdentityFile+".old")); err != nil || !old.Equals(first) {
+		t.Fatalf("previous key was not kept: %v", err)
+	}
+
+	// A second rotation keeps both earlier keys.
+	again, err := GenerateIdentity(dir, "ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current, err := LoadIdentity(dir); err != nil || !current.Equals(again) {
+		t.Fatalf("rotated key was not stored: %v", err)
+	}
+	if old, err := loadKey(filepath.Join(dir, IdentityFile+".old")); err != nil || !old.Equals(first) {
+		t.Fatalf("first key was overwritten: %v", err)
+	}
+	kept, err := filepath.Glob(filepath.Join(dir, IdentityFile+".*.old"))
+	if err != nil || len(kept) != 1 {
+		t.Fatalf("got kept keys %v, %v", kept, err)
+	}
+	if old, err := loadKey(kept[0]); err != nil || !old.Equals(rotated) {
+		t.Fatalf("second key was not kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, IdentityFile+".tmp")); !os.IsNotExist(err) {
+		t.Fatalf("temporary key was left behind: %v", err)
+	}
+
+	if _, err := GenerateIdentity(dir, "dsa"); err == nil {
+		t.Fatal("expected unsupported key type to fail")
+	}
+}
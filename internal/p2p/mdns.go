@@ -0,0 +1,75 @@
+package p2p
+
+import (
+	"context"
+	"log"
+	"time"
+
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/discovery/mdns"
+)
+
+// DefaultMDNSServiceTag is the service name nodes advertise on the LAN.
+// Only nodes using the same tag discover each other.
+const DefaultMDNSServiceTag = "p2p-storage"
+
+// mdnsConnectTimeout bounds the dial to a peer found on the LAN.
+const mdnsConnectTimeout = 10 * time.Second
+
+// MDNSConfig controls local peer discovery.
+type MDNSConfig struct {
+	Enabled    bool
+	ServiceTag string
+}
+
+// mdnsNotifee connects to peers announced over mDNS and adds them to the
+// DHT routing table, so LAN clusters work without any bootstrap peers.
+type mdnsNotifee struct {
+	ctx context.Context
+	h   host.Host
+	dht *dht.IpfsDHT
+}
+
+func (m *mdnsNotifee) HandlePeerFound(pi peer.AddrInfo) {
+	if pi.ID == m.h.ID() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(m.ctx, mdnsConnectTimeout)
+	defer cancel()
+
+	if err := m.h.Connect(ctx, pi); err != nil {
+		log.Printf("Failed to connect to LAN peer %s: %v", pi.ID, err)
+		return
+	}
+	if _, err := m.dht.RoutingTable().TryAddPeer(pi.ID, true, true); err != nil {
+		log.Printf("Failed to add LAN peer %s to routing table: %v", pi.ID, err)
+		return
+	}
+	log.Printf("Discovered LAN peer %s", pi.ID)
+}
+
+// StartMDNS advertises the host on the local network and connects to other
+// nodes advertising the same service tag. It stops when ctx is cancelled.
+func StartMDNS(ctx context.Context, h host.Host, d *dht.IpfsDHT, cfg MDNSConfig) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	tag := cfg.ServiceTag
+	if tag == "" {
+		tag = DefaultMDNSServiceTag
+	}
+
+	svc := mdns.NewMdnsService(h, tag, &mdnsNotifee{ctx: ctx, h: h, dht: d})
+	if err := svc.Start(); err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		svc.Close()
+	}()
+
+	log.Printf("mDNS discovery enabled with service tag %q", tag)
+	return nil
+}
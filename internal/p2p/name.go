@@ -0,0 +1,146 @@
+package p2p
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	api "github.com/Yashh56/p2p-storage/api/v1"
+	"github.com/ipfs/go-cid"
+	record "github.com/libp2p/go-libp2p-record"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+// NameNamespace is the DHT namespace of name records.
+const NameNamespace = "name"
+
+// nameSignaturePrefix is prepended to the data a name record signs, so that
+// the signature cannot be passed off as one over another kind of message.
+var nameSignaturePrefix = []byte("p2p-storage-name-record:")
+
+// NameRecord is the content of a signed name record.
+type NameRecord struct {
+	Cid cid.Cid
+	// Sequence orders the records of a name: a higher one wins.
+	Sequence uint64
+	Expires  time.Time
+}
+
+// NameKey returns the DHT key of the name records of id.
+func NameKey(id peer.ID) string {
+	return "/" + NameNamespace + "/" + string(id)
+}
+
+// SignNameRecord returns rec signed with priv, ready to be stored under the
+// NameKey of priv's peer ID.
+func SignNameRecord(priv crypto.PrivKey, rec NameRecord) ([]byte, error) {
+	data, err := proto.Marshal(&api.NameRecordData{
+		Cid:       rec.Cid.String(),
+		Sequence:  rec.Sequence,
+		ExpiresAt: rec.Expires.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	sig, err := priv.Sign(append(bytes.Clone(nameSignaturePrefix), data...))
+	if err != nil {
+		return nil, err
+	}
+	pub, err := crypto.MarshalPublicKey(priv.GetPublic())
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(&api.NameRecord{Data: data, Signature: sig, PublicKey: pub})
+}
+
+// ParseNameRecord checks that value is a name record of id, signed with
+// id's key, and returns its content. It does not check the expiry.
+func ParseNameRecord(id peer.ID, value []byte) (NameRecord, error) {
+	var signed api.NameRecord
+	if err := proto.Unmarshal(value, &signed); err != nil {
+		return NameRecord{}, fmt.Errorf("invalid name record: %w", err)
+	}
+	pub, err := crypto.UnmarshalPublicKey(signed.PublicKey)
+	if err != nil {
+		return NameRecord{}, fmt.Errorf("invalid name record key: %w", err)
+	}
+	if !id.MatchesPublicKey(pub) {
+		return NameRecord{}, errors.New("name record is signed with another key")
+	}
+	ok, err := pub.Verify(append(bytes.Clone(nameSignaturePrefix), signed.Data...), signed.Signature)
+	if err != nil || !ok {
+		return NameRecord{}, errors.New("invalid name record signature")
+	}
+	var data api.NameRecordData
+	if err := proto.Unmarshal(signed.Data, &data); err != nil {
+		return NameRecord{}, fmt.Errorf("invalid name record: %w", err)
+	}
+	c, err := cid.Decode(data.Cid)
+	if err != nil {
+		return NameRecord{}, fmt.Errorf("invalid name record CID: %w", err)
+	}
+	return NameRecord{Cid: c, Sequence: data.Sequence, Expires: time.Unix(data.ExpiresAt, 0)}, nil
+}
+
+// NameValidator validates the records of NameNamespace for the DHT: a
+// record must be signed by the key its name is derived from and must not
+// have expired. Of several records, the one with the highest sequence
+// number wins, then the one that expires last.
+type NameValidator struct{}
+
+func (NameValidator) Validate(key string, value []byte) error {
+	id, err := nameKeyID(key)
+	if err != nil {
+		return err
+	}
+	rec, err := ParseNameRecord(id, value)
+	if err != nil {
+		return err
+	}
+	if time.Now().After(rec.Expires) {
+		return errors.New("name record has expired")
+	}
+	return nil
+}
+
+func (NameValidator) Select(key string, values [][]byte) (int, error) {
+	id, err := nameKeyID(key)
+	if err != nil {
+		return 0, err
+	}
+	best := -1
+	var bestRec NameRecord
+	for i, v := range values {
+		rec, err := ParseNameRecord(id, v)
+		if err != nil {
+			continue
+		}
+		if best < 0 || rec.Sequence > bestRec.Sequence ||
+			(rec.Sequence == bestRec.Sequence && rec.Expires.After(bestRec.Expires)) {
+			best, bestRec = i, rec
+		}
+	}
+	if best < 0 {
+		return 0, errors.New("no valid name record")
+	}
+	return best, nil
+}
+
+// nameKeyID returns the peer ID a DHT key of NameNamespace names.
+func nameKeyID(key string) (peer.ID, error) {
+	ns, rest, err := record.SplitKey(key)
+	if err != nil {
+		return "", err
+	}
+	if ns != NameNamespace {
+		return "", fmt.Errorf("not a name record key: %q", key)
+	}
+	id, err := peer.IDFromBytes([]byte(rest))
+	if err != nil {
+		return "", fmt.Errorf("invalid name record key: %w", err)
+	}
+	return id, nil
+}
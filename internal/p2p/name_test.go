@@ -0,0 +1,78 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multihash"
+)
+
+func TestNameValidator(t *testing.T) {
+	dir := t.TempDir()
+	priv, err := GenerateNamedKey(dir, "site", "ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GenerateNamedKey(dir, "site", "ed25519"); err == nil {
+		t.Fatal("expected an existing key not to be replaced")
+	}
+	if _, err := GenerateNamedKey(dir, SelfKey, "ed25519"); err == nil {
+		t.Fatal("expected the self key name to be reserved")
+	}
+	if names, err := ListNamedKeys(dir); err != nil || len(names) != 1 || names[0] != "site" {
+		t.Fatalf("got keys %v, %v", names, err)
+	}
+	// RSA peer IDs do not embed the key, so the record must carry it.
+	other, err := GenerateNamedKey(dir, "other", "rsa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := peer.IDFromPrivateKey(priv)
+	otherID, _ := peer.IDFromPrivateKey(other)
+
+	record := func(name string, seq uint64, expires time.Time) []byte {
+		t.Helper()
+		key, err := LoadNamedKey(dir, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, _ := multihash.Sum([]byte{byte(seq)}, multihash.SHA2_256, -1)
+		value, err := SignNameRecord(key, NameRecord{Cid: cid.NewCidV1(cid.Raw, h), Sequence: seq, Expires: expires})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+	v := NameValidator{}
+	later := time.Now().Add(time.Hour)
+
+	if err := v.Validate(NameKey(id), record("site", 1, later)); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Validate(NameKey(otherID), record("other", 1, later)); err != nil {
+		t.Fatalf("rsa record: %v", err)
+	}
+	if err := v.Validate(NameKey(otherID), record("site", 1, later)); err == nil {
+		t.Fatal("expected a record signed with another key to be rejected")
+	}
+	if err := v.Validate(NameKey(id), record("site", 1, time.Now().Add(-time.Minute))); err == nil {
+		t.Fatal("expected an expired record to be rejected")
+	}
+	tampered := record("site", 1, later)
+	tampered[len(tampered)-1] ^= 1
+	if err := v.Validate(NameKey(id), tampered); err == nil {
+		t.Fatal("expected a tampered record to be rejected")
+	}
+
+	// The highest sequence number wins, then the latest expiry.
+	values := [][]byte{record("site", 1, later), record("site", 3, later), tampered, record("site", 3, later.Add(time.Hour)), record("site", 2, later)}
+	if best, err := v.Select(NameKey(id), values); err != nil || best != 3 {
+		t.Fatalf("selected %d, %v, want 3", best, err)
+	}
+	rec, err := ParseNameRecord(id, values[3])
+	if err != nil || rec.Sequence != 3 || rec.Expires.Unix() != later.Add(time.Hour).Unix() {
+		t.Fatalf("got %+v, %v", rec, err)
+	}
+}
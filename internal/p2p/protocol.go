@@ -1,3 +1,24 @@
 package p2p
 
+// BlockProtocolID is the original block exchange protocol: the requester
+// writes a single CID string and the responder writes the raw block back.
+// It is still served so that older peers can fetch from us.
 const BlockProtocolID = "/p2p-storage/blocks/1.0.0"
+
+// BlockProtocolV2ID frames varint length-prefixed BlockRequest and
+// BlockResponse messages, allowing many blocks per stream and reporting
+// failures such as NOT_FOUND explicitly.
+const BlockProtocolV2ID = "/p2p-storage/blocks/2.0.0"
+
+// MaxMessageSize bounds a single framed protocol message. It leaves room for
+// the largest chunk the chunker can produce plus message overhead.
+const MaxMessageSize = 17 * 1024 * 1024
+
+// WantProtocolID carries want-lists between neighbours: a node asks which of
+// a set of blocks a peer has, then asks for the blocks themselves, without
+// a DHT lookup per block.
+const WantProtocolID = "/p2p-storage/wants/1.0.0"
+
+// ReplicateProtocolID pushes the blocks of a file to a peer that is asked to
+// keep a pinned copy of it.
+const ReplicateProtocolID = "/p2p-storage/replicate/1.0.0"
@@ -1,37 +1,260 @@
 package storage
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/dgraph-io/badger/v4"
 	"github.com/ipfs/go-cid"
 )
 
+// ErrNotFound is returned by Get when the block is not in the store.
+var ErrNotFound = errors.New("block not found")
+
+// ErrQuotaExceeded is returned by Put when a block does not fit in the
+// configured maximum size.
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
 type BlockStore struct {
 	db *badger.DB
+
+	// maxSize is the maximum number of block bytes held, or 0 for no limit.
+	maxSize int64
+	// evict allows Put to delete cached blocks to make room.
+	evict bool
+
+	// mu guards size and the cache list, and serialises writes that change
+	// them.
+	mu    sync.Mutex
+	size  int64
+	cache *blockCache
 }
 
-func NewBlockStore(path string) (*BlockStore, error) {
-	opts := badger.DefaultOptions(path)
-	db, err := badger.Open(opts)
+// Option configures a BlockStore.
+type Option func(*BlockStore)
+
+// WithMaxSize limits the total size of the blocks in the store. Only block
+// data is counted, not the database's own overhead. Zero means no limit.
+func WithMaxSize(n int64) Option {
+	return func(bs *BlockStore) { bs.maxSize = n }
+}
+
+// WithEviction lets the store delete the least recently used cached blocks,
+// those stored with PutCached, when a new block would exceed the maximum size.
+func WithEviction(enabled bool) Option {
+	return func(bs *BlockStore) { bs.evict = enabled }
+}
+
+func NewBlockStore(path string, opts ...Option) (*BlockStore, error) {
+	badgerOpts := badger.DefaultOptions(path)
+	db, err := badger.Open(badgerOpts)
 
 	if err != nil {
 		return nil, err
 	}
-	return &BlockStore{
-		db: db,
-	}, nil
+	bs := &BlockStore{
+		db:    db,
+		cache: newBlockCache(),
+	}
+	for _, opt := range opts {
+		opt(bs)
+	}
+	if err := bs.load(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return bs, nil
+}
+
+// load computes the size of the stored blocks and restores the cache list.
+func (bs *BlockStore) load() error {
+	return bs.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			key := item.Key()
+			switch {
+			case bytes.HasPrefix(key, cachePrefix):
+				err := item.Value(func(val []byte) error {
+					return bs.cache.load(string(key[len(cachePrefix):]), val)
+				})
+				if err != nil {
+					return err
+				}
+			case !bytes.HasPrefix(key, []byte(metaPrefix)):
+				bs.size += item.ValueSize()
+			}
+		}
+		bs.cache.sort()
+		return nil
+	})
+}
+
+// Size returns the total size of the blocks in the store.
+func (bs *BlockStore) Size() int64 {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	return bs.size
+}
+
+// MaxSize returns the configured maximum size, or 0 if there is none.
+func (bs *BlockStore) MaxSize() int64 {
+	return bs.maxSize
 }
 
+// Put stores a block the node owns, such as a chunk of a file added
+// locally. A block that was cached becomes owned and is no longer evicted.
 func (bs *BlockStore) Put(data []byte) (cid.Cid, error) {
 	c, err := Sum(data)
 	if err != nil {
 		return cid.Undef, err
 	}
-	key := []byte(c.KeyString())
-	return c, bs.db.Update(func(txn *badger.Txn) error {
-		return txn.Set(key, data)
+	return c, bs.put(c, data, false)
+}
+
+// PutCached stores a block fetched from another peer. Cached blocks may be
+// evicted to make room for new ones unless they are pinned first.
+func (bs *BlockStore) PutCached(data []byte) (cid.Cid, error) {
+	c, err := Sum(data)
+	if err != nil {
+		return cid.Undef, err
+	}
+	return c, bs.put(c, data, true)
+}
+
+// PutCachedAs is PutCached for a block whose CID is already known, which
+// may use another codec or hash function than DefaultCidBuilder. The caller
+// must have checked data against c with Verify.
+func (bs *BlockStore) PutCachedAs(c cid.Cid, data []byte) error {
+	return bs.put(c, data, true)
+}
+
+func (bs *BlockStore) put(c cid.Cid, data []byte, cached bool) error {
+	key := c.KeyString()
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if ok, err := bs.Has(c); err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+		return err
+	} else if ok {
+		if cached {
+			bs.cache.touch(key)
+		} else if bs.cache.contains(key) {
+			return bs.uncacheLocked(key)
+		}
+		return nil
+	}
+
+	if err := bs.reserveLocked(int64(len(data))); err != nil {
+		return err
+	}
+	err := bs.db.Update(func(txn *badger.Txn) error {
+		if err := txn.Set([]byte(key), data); err != nil {
+			return err
+		}
+		if cached {
+			return txn.Set(cacheKey(key), cacheStamp())
+		}
+		return nil
 	})
+	if err != nil {
+		return err
+	}
+	bs.size += int64(len(data))
+	if cached {
+		bs.cache.add(key)
+	}
+	return nil
+}
+
+// reserveLocked makes sure n more bytes fit in the store, evicting cached
+// blocks if that is allowed. bs.mu must be held.
+func (bs *BlockStore) reserveLocked(n int64) error {
+	if bs.maxSize <= 0 || bs.size+n <= bs.maxSize {
+		return nil
+	}
+	if n > bs.maxSize {
+		return fmt.Errorf("%w: block of %d bytes is larger than the %d byte limit", ErrQuotaExceeded, n, bs.maxSize)
+	}
+	if bs.evict {
+		for bs.size+n > bs.maxSize {
+			key, ok := bs.cache.oldest()
+			if !ok {
+				break
+			}
+			if err := bs.evictLocked(key); err != nil {
+				return err
+			}
+		}
+	}
+	if bs.size+n > bs.maxSize {
+		return fmt.Errorf("%w: %d of %d bytes used", ErrQuotaExceeded, bs.size, bs.maxSize)
+	}
+	return nil
+}
+
+// evictLocked deletes a cached block unless it has been pinned, in which
+// case it only stops being cached. bs.mu must be held.
+func (bs *BlockStore) evictLocked(key string) error {
+	var freed int64
+	err := bs.db.Update(func(txn *badger.Txn) error {
+		if err := txn.Delete(cacheKey(key)); err != nil {
+			return err
+		}
+		if _, err := txn.Get(pinKeyString(key)); err == nil {
+			return nil
+		}
+		item, err := txn.Get([]byte(key))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		freed = item.ValueSize()
+		return txn.Delete([]byte(key))
+	})
+	if err != nil {
+		return err
+	}
+	bs.cache.remove(key)
+	bs.size -= freed
+	return nil
+}
+
+// Uncache turns cached blocks into owned blocks that are never evicted.
+// Blocks that are not cached are ignored.
+func (bs *BlockStore) Uncache(cids ...cid.Cid) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	for _, c := range cids {
+		if key := c.KeyString(); bs.cache.contains(key) {
+			if err := bs.uncacheLocked(key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (bs *BlockStore) uncacheLocked(key string) error {
+	err := bs.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(cacheKey(key))
+	})
+	if err != nil {
+		return err
+	}
+	bs.cache.remove(key)
+	return nil
 }
 
 func (bs *BlockStore) Get(c cid.Cid) ([]byte, error) {
@@ -47,6 +270,14 @@ func (bs *BlockStore) Get(c cid.Cid) ([]byte, error) {
 			return nil
 		})
 	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, ErrNotFound
+	}
+	if err == nil {
+		bs.mu.Lock()
+		bs.cache.touch(string(key))
+		bs.mu.Unlock()
+	}
 	return blockData, err
 }
 
@@ -65,3 +296,9 @@ func (bs *BlockStore) Close() {
 		log.Println("Error Closing the BadgerDB", err)
 	}
 }
+
+// cacheStamp records when a block was cached, so that eviction order
+// survives a restart.
+func cacheStamp() []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(time.Now().UnixNano()))
+}
@@ -1,13 +1,17 @@
 package storage
 
 import (
-	"os"
+	"context"
+	"errors"
+	"path/filepath"
 	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
 )
 
 func TestBlockStore_PutGetHas(t *testing.T) {
-	_ = os.RemoveAll("./tmpdb")
-	store, err := NewBlockStore("./tmpdb")
+	store, err := NewBlockStore(filepath.Join(t.TempDir(), "db"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -33,3 +37,163 @@ func TestBlockStore_PutGetHas(t *testing.T) {
 	}
 
 }
+
+func TestBlockStore_PutCachedAs(t *testing.T) {
+	store, err := NewBlockStore(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	// A block fetched by a CID that the default builder would not produce
+	// is found again under that CID.
+	data := []byte("raw leaf")
+	c, err := cid.V1Builder{Codec: cid.Raw, MhType: multihash.SHA2_512}.Sum(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.PutCachedAs(c, data); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := store.Has(c); !ok {
+		t.Fatalf("block not found under %s: %v", c, err)
+	}
+	if got, err := store.Get(c); err != nil || string(got) != string(data) {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	if store.Size() != int64(len(data)) {
+		t.Fatalf("store holds %d bytes, want %d", store.Size(), len(data))
+	}
+}
+
+func TestBlockStore_PinAndGC(t *testing.T) {
+	store, err := NewBlockStore(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	child, _ := store.Put([]byte("child"))
+	root, _ := store.Put([]byte("root"))
+	direct, _ := store.Put([]byte("direct"))
+	garbage, _ := store.Put([]byte("garbage"))
+
+	if err := store.Pin(root, PinRecursive); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Pin(direct, PinDirect); err != nil {
+		t.Fatal(err)
+	}
+	descendants := func(c cid.Cid) ([]cid.Cid, error) {
+		if !c.Equals(root) {
+			t.Fatalf("unexpected recursive pin %s", c)
+		}
+		return []cid.Cid{child}, nil
+	}
+
+	res, err := store.GC(context.Background(), descendants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RemovedBlocks != 1 || res.FreedBytes != int64(len("garbage")) {
+		t.Fatalf("unexpected GC result %+v", res)
+	}
+	for _, c := range []cid.Cid{child, root, direct} {
+		if ok, _ := store.Has(c); !ok {
+			t.Fatalf("pinned block %s was collected", c)
+		}
+	}
+	if ok, _ := store.Has(garbage); ok {
+		t.Fatal("unpinned block survived GC")
+	}
+
+	if err := store.Unpin(root); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Unpin(root); !errors.Is(err, ErrNotPinned) {
+		t.Fatalf("expected ErrNotPinned, got %v", err)
+	}
+	if _, err := store.GC(context.Background(), descendants); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := store.Has(child); ok {
+		t.Fatal("block of an unpinned file survived GC")
+	}
+	if ok, _ := store.Has(root); ok {
+		t.Fatal("unpinned root survived GC")
+	}
+	if pins, _ := store.Pins(); len(pins) != 1 || !pins[0].Cid.Equals(direct) {
+		t.Fatalf("unexpected pin set %v", pins)
+	}
+}
+
+func TestBlockStore_GCKeepsGivenBlocks(t *testing.T) {
+	store, err := NewBlockStore(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	kept, _ := store.Put([]byte("kept"))
+	garbage, _ := store.Put([]byte("garbage"))
+	noPins := func(c cid.Cid) ([]cid.Cid, error) {
+		t.Fatalf("unexpected recursive pin %s", c)
+		return nil, nil
+	}
+
+	// Blocks passed to GC as kept survive without a pin.
+	res, err := store.GC(context.Background(), noPins, kept)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RemovedBlocks != 1 {
+		t.Fatalf("unexpected GC result %+v", res)
+	}
+	if ok, _ := store.Has(kept); !ok {
+		t.Fatal("kept block was collected")
+	}
+	if ok, _ := store.Has(garbage); ok {
+		t.Fatal("unpinned block survived GC")
+	}
+}
+
+func TestBlockStore_QuotaAndEviction(t *testing.T) {
+	store, err := NewBlockStore(filepath.Join(t.TempDir(), "db"), WithMaxSize(12), WithEviction(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	owned, err := store.Put([]byte("owned"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, _ := store.PutCached([]byte("old"))
+	if _, err := store.PutCached([]byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if store.Size() != 11 {
+		t.Fatalf("expected 11 bytes stored, got %d", store.Size())
+	}
+
+	// Reading "old" makes it the most recently used cached block.
+	if _, err := store.Get(old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Put([]byte("abcd")); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := store.Has(old); !ok {
+		t.Fatal("recently used block was evicted")
+	}
+	if ok, _ := store.Has(owned); !ok {
+		t.Fatal("owned block was evicted")
+	}
+	if store.Size() != 12 {
+		t.Fatalf("expected 12 bytes stored, got %d", store.Size())
+	}
+
+	if _, err := store.Put([]byte("too big")); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+}
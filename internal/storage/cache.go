@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"container/list"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+var cachePrefix = metaKey("cache/")
+
+func cacheKey(key string) []byte {
+	return append(append([]byte(nil), cachePrefix...), key...)
+}
+
+// blockCache orders cached blocks from most to least recently used. Access
+// order is only kept in memory; after a restart blocks are ordered by when
+// they were cached.
+type blockCache struct {
+	order *list.List
+	elems map[string]*list.Element
+	// stamps holds the cache time of loaded entries until sort orders them.
+	stamps map[string]uint64
+}
+
+func newBlockCache() *blockCache {
+	return &blockCache{
+		order:  list.New(),
+		elems:  make(map[string]*list.Element),
+		stamps: make(map[string]uint64),
+	}
+}
+
+// load records a cache entry read from the database.
+func (bc *blockCache) load(key string, stamp []byte) error {
+	if len(stamp) != 8 {
+		return fmt.Errorf("corrupt cache entry for %x", key)
+	}
+	bc.stamps[key] = binary.BigEndian.Uint64(stamp)
+	return nil
+}
+
+// sort builds the eviction order from the loaded entries.
+func (bc *blockCache) sort() {
+	keys := make([]string, 0, len(bc.stamps))
+	for key := range bc.stamps {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return bc.stamps[keys[i]] > bc.stamps[keys[j]] })
+	for _, key := range keys {
+		bc.elems[key] = bc.order.PushBack(key)
+	}
+	bc.stamps = make(map[string]uint64)
+}
+
+func (bc *blockCache) add(key string) {
+	if e, ok := bc.elems[key]; ok {
+		bc.order.MoveToFront(e)
+		return
+	}
+	bc.elems[key] = bc.order.PushFront(key)
+}
+
+func (bc *blockCache) contains(key string) bool {
+	_, ok := bc.elems[key]
+	return ok
+}
+
+// touch marks a cached block as recently used.
+func (bc *blockCache) touch(key string) {
+	if e, ok := bc.elems[key]; ok {
+		bc.order.MoveToFront(e)
+	}
+}
+
+func (bc *blockCache) remove(key string) {
+	if e, ok := bc.elems[key]; ok {
+		bc.order.Remove(e)
+		delete(bc.elems, key)
+	}
+}
+
+// oldest returns the least recently used cached block.
+func (bc *blockCache) oldest() (string, bool) {
+	e := bc.order.Back()
+	if e == nil {
+		return "", false
+	}
+	return e.Value.(string), true
+}
@@ -3,6 +3,8 @@ package storage
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"fmt"
 
 	"github.com/ipfs/go-cid"
 	"github.com/multiformats/go-multihash"
@@ -23,3 +25,19 @@ var DefaultCidBuilder = cid.V1Builder{
 func Sum(data []byte) (cid.Cid, error) {
 	return DefaultCidBuilder.Sum(data)
 }
+
+// ErrHashMismatch is returned when a block's data does not hash to its CID.
+var ErrHashMismatch = errors.New("block data does not match its CID")
+
+// Verify re-hashes data with the hash function, version and codec of c and
+// checks that it matches c.
+func Verify(c cid.Cid, data []byte) error {
+	got, err := c.Prefix().Sum(data)
+	if err != nil {
+		return err
+	}
+	if !got.Equals(c) {
+		return fmt.Errorf("%w: expected %s, got %s", ErrHashMismatch, c, got)
+	}
+	return nil
+}
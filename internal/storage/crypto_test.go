@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+)
+
+func TestVerify(t *testing.T) {
+	data := []byte("some block content")
+	sum := func(prefix cid.Prefix, data []byte) cid.Cid {
+		t.Helper()
+		c, err := prefix.Sum(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	def, err := Sum(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v0 := cid.Prefix{Version: 0, Codec: cid.DagProtobuf, MhType: multihash.SHA2_256, MhLength: -1}
+	raw := cid.Prefix{Version: 1, Codec: cid.Raw, MhType: multihash.SHA2_256, MhLength: -1}
+	sha512 := cid.Prefix{Version: 1, Codec: cid.DagProtobuf, MhType: multihash.SHA2_512, MhLength: -1}
+
+	for _, tc := range []struct {
+		name    string
+		c       cid.Cid
+		data    []byte
+		wantErr error
+	}{
+		{"default builder", def, data, nil},
+		{"CIDv0", sum(v0, data), data, nil},
+		{"raw codec", sum(raw, data), data, nil},
+		{"sha2-512", sum(sha512, data), data, nil},
+		{"other content", def, []byte("other content"), ErrHashMismatch},
+		{"other content, sha2-512", sum(sha512, data), []byte("other content"), ErrHashMismatch},
+		{"empty block", sum(raw, nil), nil, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := Verify(tc.c, tc.data); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
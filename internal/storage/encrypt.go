@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// KeySize is the size of every encryption key.
+const KeySize = 32
+
+// Cipher names an AEAD used to encrypt blocks.
+type Cipher string
+
+const (
+	CipherAESGCM            Cipher = "aes-256-gcm"
+	CipherXChaCha20Poly1305 Cipher = "xchacha20-poly1305"
+)
+
+// DefaultCipher is used when no cipher is chosen.
+const DefaultCipher = CipherAESGCM
+
+// ErrDecrypt is returned when data cannot be decrypted, which almost always
+// means the key is wrong.
+var ErrDecrypt = errors.New("decryption failed")
+
+// NewAEAD returns the AEAD named by c keyed with key.
+func NewAEAD(c Cipher, key []byte) (cipher.AEAD, error) {
+	switch c {
+	case CipherAESGCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case CipherXChaCha20Poly1305:
+		return chacha20poly1305.NewX(key)
+	}
+	return nil, fmt.Errorf("unsupported cipher %q", c)
+}
+
+// NewKey returns a random key.
+func NewKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ConvergentKey derives a key from the plaintext itself, so that identical
+// plaintexts encrypt to identical blocks and are stored only once. Anyone
+// holding a plaintext can tell whether it is stored, so per-file keys should
+// be used for data that must not be confirmable.
+func ConvergentKey(plaintext []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte("p2p-storage convergent key\x00"))
+	h.Write(plaintext)
+	return h.Sum(nil)
+}
+
+// Seal encrypts plaintext and prepends the nonce. A convergent key is only
+// ever used for one plaintext, so its nonce is fixed to keep the ciphertext
+// deterministic; otherwise the nonce is random.
+func Seal(aead cipher.AEAD, plaintext, additionalData []byte, convergent bool) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if !convergent {
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// Open decrypts data produced by Seal.
+func Open(aead cipher.AEAD, data, additionalData []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, additionalData)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// EncodeKey renders a key in the form handed to users.
+func EncodeKey(key []byte) string {
+	return base64.RawURLEncoding.EncodeToString(key)
+}
+
+// DecodeKey parses a key produced by EncodeKey.
+func DecodeKey(s string) ([]byte, error) {
+	key, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(key) != KeySize {
+		return nil, errors.New("invalid encryption key")
+	}
+	return key, nil
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// metaPrefix marks keys that hold node metadata rather than blocks. Block
+// keys are binary CIDs, which never start with '/'.
+const metaPrefix = "/"
+
+// ErrMetaNotFound is returned by GetMeta for a key that is not set.
+var ErrMetaNotFound = errors.New("metadata not found")
+
+func metaKey(key string) []byte {
+	return []byte(metaPrefix + key)
+}
+
+// PutMeta stores a metadata value next to the blocks. Metadata does not
+// count towards the size limit and is never garbage collected.
+func (bs *BlockStore) PutMeta(key string, value []byte) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(metaKey(key), value)
+	})
+}
+
+// GetMeta returns the metadata value stored under key.
+func (bs *BlockStore) GetMeta(key string) ([]byte, error) {
+	var value []byte
+	err := bs.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(metaKey(key))
+		if err != nil {
+			return err
+		}
+		value, err = item.ValueCopy(nil)
+		return err
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, ErrMetaNotFound
+	}
+	return value, err
+}
+
+// DeleteMeta removes a metadata value. Deleting a missing key is not an error.
+func (bs *BlockStore) DeleteMeta(key string) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(metaKey(key))
+	})
+}
+
+// ScanMeta calls fn for every metadata key starting with prefix, in key
+// order. The key passed to fn has the prefix removed, and the value is only
+// valid until fn returns.
+func (bs *BlockStore) ScanMeta(prefix string, fn func(key string, value []byte) error) error {
+	return bs.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = metaKey(prefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			key := string(bytes.TrimPrefix(item.Key(), opts.Prefix))
+			if err := item.Value(func(val []byte) error { return fn(key, val) }); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ipfs/go-cid"
+)
+
+var pinPrefix = metaKey("pins/")
+
+// ErrNotPinned is returned by Unpin for a CID that has no pin.
+var ErrNotPinned = errors.New("not pinned")
+
+// PinMode says what a pin protects from garbage collection.
+type PinMode byte
+
+const (
+	// PinDirect protects a single block.
+	PinDirect PinMode = iota + 1
+	// PinRecursive protects a root manifest and every block it references.
+	PinRecursive
+)
+
+func (m PinMode) String() string {
+	switch m {
+	case PinDirect:
+		return "direct"
+	case PinRecursive:
+		return "recursive"
+	}
+	return fmt.Sprintf("PinMode(%d)", byte(m))
+}
+
+// Pin is an entry of the pin set.
+type Pin struct {
+	Cid  cid.Cid
+	Mode PinMode
+}
+
+func pinKey(c cid.Cid) []byte {
+	return pinKeyString(c.KeyString())
+}
+
+func pinKeyString(key string) []byte {
+	return append(append([]byte(nil), pinPrefix...), key...)
+}
+
+// Pin adds c to the pin set, replacing any existing pin on it.
+func (bs *BlockStore) Pin(c cid.Cid, mode PinMode) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(pinKey(c), []byte{byte(mode)})
+	})
+}
+
+// PinNew adds c to the pin set unless it is pinned already, and reports
+// whether it added the pin.
+func (bs *BlockStore) PinNew(c cid.Cid, mode PinMode) (bool, error) {
+	added := false
+	err := bs.db.Update(func(txn *badger.Txn) error {
+		if _, err := txn.Get(pinKey(c)); err == nil {
+			return nil
+		} else if !errors.Is(err, badger.ErrKeyNotFound) {
+			return err
+		}
+		added = true
+		return txn.Set(pinKey(c), []byte{byte(mode)})
+	})
+	return added && err == nil, err
+}
+
+// Unpin removes c from the pin set.
+func (bs *BlockStore) Unpin(c cid.Cid) error {
+	key := pinKey(c)
+	return bs.db.Update(func(txn *badger.Txn) error {
+		if _, err := txn.Get(key); errors.Is(err, badger.ErrKeyNotFound) {
+			return ErrNotPinned
+		} else if err != nil {
+			return err
+		}
+		return txn.Delete(key)
+	})
+}
+
+// Pins lists the pin set.
+func (bs *BlockStore) Pins() ([]Pin, error) {
+	var pins []Pin
+	err := bs.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = pinPrefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			c, err := cid.Cast(bytes.TrimPrefix(item.Key(), pinPrefix))
+			if err != nil {
+				return err
+			}
+			err = item.Value(func(val []byte) error {
+				if len(val) != 1 {
+					return fmt.Errorf("corrupt pin entry for %s", c)
+				}
+				pins = append(pins, Pin{Cid: c, Mode: PinMode(val[0])})
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return pins, err
+}
+
+// DescendantsFunc returns every block reachable from a recursively pinned root.
+type DescendantsFunc func(root cid.Cid) ([]cid.Cid, error)
+
+// GCResult summarises a garbage collection run.
+type GCResult struct {
+	RemovedBlocks int
+	FreedBytes    int64
+}
+
+// GC deletes every block that is neither pinned, reachable from a
+// recursive pin, nor listed in keep. The store does not understand
+// manifests, so the caller supplies descendants to expand recursive pins.
+// If any pin cannot be expanded, nothing is deleted.
+func (bs *BlockStore) GC(ctx context.Context, descendants DescendantsFunc, keep ...cid.Cid) (GCResult, error) {
+	var result GCResult
+
+	// Mark.
+	pins, err := bs.Pins()
+	if err != nil {
+		return result, err
+	}
+	live := make(map[string]bool)
+	for _, c := range keep {
+		live[c.KeyString()] = true
+	}
+	for _, p := range pins {
+		live[p.Cid.KeyString()] = true
+		if p.Mode != PinRecursive {
+			continue
+		}
+		children, err := descendants(p.Cid)
+		if err != nil {
+			return result, fmt.Errorf("failed to walk pinned root %s: %w", p.Cid, err)
+		}
+		for _, c := range children {
+			live[c.KeyString()] = true
+		}
+	}
+
+	// Sweep. Scanning reads a snapshot and does not hold mu, so reads and
+	// writes carry on meanwhile.
+	var dead [][]byte
+	err = bs.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			key := it.Item().Key()
+			if bytes.HasPrefix(key, []byte(metaPrefix)) || live[string(key)] {
+				continue
+			}
+			dead = append(dead, it.Item().KeyCopy(nil))
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	if err := bs.sweep(dead, &result); err != nil {
+		return result, err
+	}
+
+	// Deleted values only leave the disk once badger rewrites the value
+	// log. This runs outside mu and stops early if ctx is cancelled.
+	for ctx.Err() == nil && bs.db.RunValueLogGC(0.5) == nil {
+	}
+	log.Printf("GC removed %d blocks (%d bytes)", result.RemovedBlocks, result.FreedBytes)
+	return result, nil
+}
+
+// sweep deletes dead blocks. Holding mu keeps the size accounting right:
+// blocks evicted since the scan are skipped, and Put cannot count a block
+// as stored while it is being deleted.
+func (bs *BlockStore) sweep(dead [][]byte, result *GCResult) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	var freed int64
+	var deleted [][]byte
+	err := bs.db.View(func(txn *badger.Txn) error {
+		for _, key := range dead {
+			item, err := txn.Get(key)
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			freed += item.ValueSize()
+			deleted = append(deleted, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	wb := bs.db.NewWriteBatch()
+	defer wb.Cancel()
+	for _, key := range deleted {
+		if err := wb.Delete(key); err != nil {
+			return err
+		}
+		if bs.cache.contains(string(key)) {
+			if err := wb.Delete(cacheKey(string(key))); err != nil {
+				return err
+			}
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		return err
+	}
+	for _, key := range deleted {
+		bs.cache.remove(string(key))
+	}
+	bs.size -= freed
+	result.RemovedBlocks = len(deleted)
+	result.FreedBytes = freed
+	return nil
+}